@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Snapshot current state of a ticker used to evaluate the rules
+// Company is nil and Sentiment empty when they could not be retrieved,
+// in that case the rules that depend on them keep their state
+type Snapshot struct {
+	Company         *models.CompanyData
+	Sentiment       ratings.Sentiment
+	Recommendations []models.Recommendation
+}
+
+// Event is a firing of a rule
+type Event struct {
+	Message string
+	Value   float64
+}
+
+// Evaluate evaluates the rule with the snapshot of its ticker, updates the state of the rule
+// and returns the events to notify
+//
+// the price, change and sentiment rules fire when the condition changes from not met to met,
+// the recommendation rules fire once for each recommendation newer than the last evaluated
+func Evaluate(rule *models.AlertRule, snapshot Snapshot) []Event {
+	switch rule.Type {
+	case models.PriceAboveAlert, models.PriceBelowAlert, models.ChangePercentAlert:
+		if snapshot.Company == nil || snapshot.Company.Price <= 0 {
+			return nil
+		}
+
+		met, event := evaluateQuote(rule, *snapshot.Company)
+		return trigger(rule, met, event)
+	case models.SentimentAlert:
+		if snapshot.Sentiment == "" {
+			return nil
+		}
+
+		event := Event{Message: fmt.Sprintf("%s sentiment turned %s", rule.TickerID, snapshot.Sentiment)}
+		return trigger(rule, snapshot.Sentiment == rule.Sentiment, event)
+	case models.RecommendationAlert:
+		return evaluateRecommendations(rule, snapshot.Recommendations)
+	}
+
+	return nil
+}
+
+// evaluateQuote checks the condition of the price and change rules
+func evaluateQuote(rule *models.AlertRule, company models.CompanyData) (bool, Event) {
+	switch rule.Type {
+	case models.PriceAboveAlert:
+		return company.Price >= rule.Threshold, Event{
+			Message: fmt.Sprintf("%s price %.2f crossed above %.2f", rule.TickerID, company.Price, rule.Threshold),
+			Value:   company.Price,
+		}
+	case models.PriceBelowAlert:
+		return company.Price <= rule.Threshold, Event{
+			Message: fmt.Sprintf("%s price %.2f crossed below %.2f", rule.TickerID, company.Price, rule.Threshold),
+			Value:   company.Price,
+		}
+	default:
+		return math.Abs(company.ChangePercentage) >= rule.Threshold, Event{
+			Message: fmt.Sprintf("%s changed %.2f%% today, the limit is %.2f%%", rule.TickerID, company.ChangePercentage, rule.Threshold),
+			Value:   company.ChangePercentage,
+		}
+	}
+}
+
+// trigger fires the event only if the condition was not met in the last evaluation
+func trigger(rule *models.AlertRule, met bool, event Event) []Event {
+	fire := met && !rule.Triggered
+	rule.Triggered = met
+
+	if !fire {
+		return nil
+	}
+
+	return []Event{event}
+}
+
+// evaluateRecommendations fires the recommendations newer than LastRecommendationID with the action of the rule
+func evaluateRecommendations(rule *models.AlertRule, recommendations []models.Recommendation) []Event {
+	newer := make([]models.Recommendation, 0)
+	for _, recommendation := range recommendations {
+		if recommendation.ID > rule.LastRecommendationID {
+			newer = append(newer, recommendation)
+		}
+	}
+
+	sort.Slice(newer, func(i, j int) bool {
+		return newer[i].ID < newer[j].ID
+	})
+
+	var events []Event
+	for _, recommendation := range newer {
+		rule.LastRecommendationID = recommendation.ID
+		if rule.Action != "" && recommendation.Action.Normalize() != rule.Action {
+			continue
+		}
+
+		events = append(events, Event{
+			Message: recommendationMessage(rule.TickerID, recommendation),
+			Value:   recommendation.TargetTo,
+		})
+	}
+
+	return events
+}
+
+// recommendationMessage describes the recommendation, example "Goldman Sachs downgraded AAPL from Buy to Neutral, target 180.00"
+func recommendationMessage(ticker string, recommendation models.Recommendation) string {
+	brokerage := recommendation.Brokerage.Name
+	if brokerage == "" {
+		brokerage = "Anonymous"
+	}
+
+	action := recommendation.Action.Normalize()
+	if action == "" {
+		action = recommendation.Action
+	}
+
+	message := fmt.Sprintf("%s %s %s", brokerage, action, ticker)
+	if recommendation.RatingFrom != "" && recommendation.RatingFrom != recommendation.RatingTo {
+		message += fmt.Sprintf(" from %s to %s", recommendation.RatingFrom, recommendation.RatingTo)
+	} else if recommendation.RatingTo != "" {
+		message += fmt.Sprintf(" as %s", recommendation.RatingTo)
+	}
+
+	if recommendation.TargetTo > 0 {
+		message += fmt.Sprintf(", target %.2f", recommendation.TargetTo)
+	}
+
+	return message
+}
@@ -0,0 +1,88 @@
+package alerts
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePrice(t *testing.T) {
+	rule := &models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 200}
+
+	testCases := []struct {
+		desc      string
+		company   *models.CompanyData
+		fired     bool
+		triggered bool
+	}{
+		{desc: "under the threshold", company: &models.CompanyData{Price: 195}, fired: false, triggered: false},
+		{desc: "crosses above", company: &models.CompanyData{Price: 201.5}, fired: true, triggered: true},
+		{desc: "stays above", company: &models.CompanyData{Price: 205}, fired: false, triggered: true},
+		{desc: "unknown price keeps the state", company: nil, fired: false, triggered: true},
+		{desc: "goes back under", company: &models.CompanyData{Price: 199}, fired: false, triggered: false},
+		{desc: "crosses above again", company: &models.CompanyData{Price: 200}, fired: true, triggered: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			events := Evaluate(rule, Snapshot{Company: tC.company})
+
+			assert.Equal(t, tC.fired, len(events) == 1)
+			assert.Equal(t, tC.triggered, rule.Triggered)
+			if tC.fired {
+				assert.Equal(t, tC.company.Price, events[0].Value)
+				assert.Contains(t, events[0].Message, "crossed above 200.00")
+			}
+		})
+	}
+}
+
+func TestEvaluatePriceBelowAndChange(t *testing.T) {
+	below := &models.AlertRule{TickerID: "AAPL", Type: models.PriceBelowAlert, Threshold: 150}
+	assert.Empty(t, Evaluate(below, Snapshot{Company: &models.CompanyData{Price: 151}}))
+	assert.Len(t, Evaluate(below, Snapshot{Company: &models.CompanyData{Price: 149}}), 1)
+
+	change := &models.AlertRule{TickerID: "AAPL", Type: models.ChangePercentAlert, Threshold: 5}
+	assert.Empty(t, Evaluate(change, Snapshot{Company: &models.CompanyData{Price: 100, ChangePercentage: 4.9}}))
+
+	events := Evaluate(change, Snapshot{Company: &models.CompanyData{Price: 100, ChangePercentage: -6.2}})
+	assert.Len(t, events, 1)
+	assert.Equal(t, -6.2, events[0].Value)
+}
+
+func TestEvaluateSentiment(t *testing.T) {
+	rule := &models.AlertRule{TickerID: "AAPL", Type: models.SentimentAlert, Sentiment: ratings.NegativeSentiment}
+
+	assert.Empty(t, Evaluate(rule, Snapshot{Sentiment: ratings.PositiveSentiment}))
+	assert.Empty(t, Evaluate(rule, Snapshot{}))
+
+	events := Evaluate(rule, Snapshot{Sentiment: ratings.NegativeSentiment})
+	assert.Len(t, events, 1)
+	assert.Equal(t, "AAPL sentiment turned negative", events[0].Message)
+
+	assert.Empty(t, Evaluate(rule, Snapshot{Sentiment: ratings.NegativeSentiment}))
+}
+
+func TestEvaluateRecommendations(t *testing.T) {
+	rule := &models.AlertRule{TickerID: "AAPL", Type: models.RecommendationAlert, Action: "downgraded", LastRecommendationID: 10}
+	recommendations := []models.Recommendation{
+		{ID: 12, Action: "downgraded by", RatingFrom: "Buy", RatingTo: "Neutral", TargetTo: 180, Brokerage: models.Brokerage{Name: "Goldman Sachs"}},
+		{ID: 9, Action: "downgraded by", RatingFrom: "Buy", RatingTo: "Sell"},
+		{ID: 11, Action: "upgraded by", RatingFrom: "Hold", RatingTo: "Buy"},
+	}
+
+	events := Evaluate(rule, Snapshot{Recommendations: recommendations})
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Goldman Sachs downgraded AAPL from Buy to Neutral, target 180.00", events[0].Message)
+	assert.Equal(t, 180.0, events[0].Value)
+	assert.Equal(t, uint(12), rule.LastRecommendationID)
+
+	// the recommendations already evaluated do not fire again
+	assert.Empty(t, Evaluate(rule, Snapshot{Recommendations: recommendations}))
+
+	// without action fires all the new recommendations
+	all := &models.AlertRule{TickerID: "AAPL", Type: models.RecommendationAlert, LastRecommendationID: 10}
+	assert.Len(t, Evaluate(all, Snapshot{Recommendations: recommendations}), 2)
+}
@@ -0,0 +1,87 @@
+package alerts
+
+// alerts evaluates the rules of the users over the tickers and delivers
+// the notifications through the sinks of each channel
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// NormalizeRule validates the condition and the channel of the rule
+// and normalizes the ticker, the sentiment and the action
+func NormalizeRule(rule models.AlertRule) (models.AlertRule, error) {
+	rule.TickerID = strings.ToUpper(strings.TrimSpace(rule.TickerID))
+	if rule.TickerID == "" || len(rule.TickerID) > 5 {
+		return rule, fmt.Errorf("the ticker is required and must have at most 5 characters")
+	}
+
+	switch rule.Type {
+	case models.PriceAboveAlert, models.PriceBelowAlert, models.ChangePercentAlert:
+		if rule.Threshold <= 0 {
+			return rule, fmt.Errorf("the threshold of the %s alerts must be greater than 0", rule.Type)
+		}
+		rule.Sentiment = ""
+		rule.Action = ""
+	case models.SentimentAlert:
+		rule.Sentiment = ratings.Sentiment(strings.ToLower(strings.TrimSpace(string(rule.Sentiment))))
+		if rule.Sentiment != ratings.PositiveSentiment && rule.Sentiment != ratings.NeutralSentiment && rule.Sentiment != ratings.NegativeSentiment {
+			return rule, fmt.Errorf("the sentiment must be %s, %s or %s", ratings.PositiveSentiment, ratings.NeutralSentiment, ratings.NegativeSentiment)
+		}
+		rule.Threshold = 0
+		rule.Action = ""
+	case models.RecommendationAlert:
+		if rule.Action != "" {
+			action := rule.Action.Normalize()
+			if action == "" {
+				return rule, fmt.Errorf("invalid action: %s", rule.Action)
+			}
+			rule.Action = action
+		}
+		rule.Threshold = 0
+		rule.Sentiment = ""
+	default:
+		return rule, fmt.Errorf("invalid type: the supported types are %s", joinTypes(models.AlertTypes))
+	}
+
+	rule.Target = strings.TrimSpace(rule.Target)
+	switch rule.Channel {
+	case models.WebhookChannel:
+		target, err := url.Parse(rule.Target)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return rule, fmt.Errorf("the target of the webhook alerts must be a http or https url")
+		}
+
+		if err := checkHost(target.Hostname()); err != nil {
+			return rule, err
+		}
+	case models.EmailChannel:
+		address, err := mail.ParseAddress(rule.Target)
+		if err != nil {
+			return rule, fmt.Errorf("the target of the email alerts must be an email address")
+		}
+		rule.Target = address.Address
+	default:
+		return rule, fmt.Errorf("invalid channel: the supported channels are %s and %s", models.WebhookChannel, models.EmailChannel)
+	}
+
+	if len(rule.Target) > 500 {
+		return rule, fmt.Errorf("the target must have at most 500 characters")
+	}
+
+	return rule, nil
+}
+
+// joinTypes joins the types separated by comma
+func joinTypes(types []models.AlertType) string {
+	values := make([]string, len(types))
+	for i, alertType := range types {
+		values[i] = string(alertType)
+	}
+
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,59 @@
+package alerts
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeRule(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		rule     models.AlertRule
+		expected models.AlertRule
+		hasError bool
+	}{
+		{
+			desc:     "price alert",
+			rule:     models.AlertRule{TickerID: " aapl ", Type: models.PriceAboveAlert, Threshold: 200, Sentiment: "negative", Channel: models.WebhookChannel, Target: "https://example.com/hook"},
+			expected: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 200, Channel: models.WebhookChannel, Target: "https://example.com/hook"},
+		},
+		{
+			desc:     "sentiment alert",
+			rule:     models.AlertRule{TickerID: "AAPL", Type: models.SentimentAlert, Sentiment: "Negative", Threshold: 3, Channel: models.EmailChannel, Target: "Trader <trader@example.com>"},
+			expected: models.AlertRule{TickerID: "AAPL", Type: models.SentimentAlert, Sentiment: ratings.NegativeSentiment, Channel: models.EmailChannel, Target: "trader@example.com"},
+		},
+		{
+			desc:     "recommendation alert",
+			rule:     models.AlertRule{TickerID: "AAPL", Type: models.RecommendationAlert, Action: "Downgraded by", Channel: models.WebhookChannel, Target: "http://hooks.example.com:9000"},
+			expected: models.AlertRule{TickerID: "AAPL", Type: models.RecommendationAlert, Action: "downgraded", Channel: models.WebhookChannel, Target: "http://hooks.example.com:9000"},
+		},
+		{desc: "without ticker", rule: models.AlertRule{Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "https://example.com"}, hasError: true},
+		{desc: "unknown type", rule: models.AlertRule{TickerID: "AAPL", Type: "volume", Channel: models.WebhookChannel, Target: "https://example.com"}, hasError: true},
+		{desc: "price without threshold", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceBelowAlert, Channel: models.WebhookChannel, Target: "https://example.com"}, hasError: true},
+		{desc: "unknown sentiment", rule: models.AlertRule{TickerID: "AAPL", Type: models.SentimentAlert, Sentiment: "bullish", Channel: models.WebhookChannel, Target: "https://example.com"}, hasError: true},
+		{desc: "unknown action", rule: models.AlertRule{TickerID: "AAPL", Type: models.RecommendationAlert, Action: "sold", Channel: models.WebhookChannel, Target: "https://example.com"}, hasError: true},
+		{desc: "unknown channel", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: "sms", Target: "+1555"}, hasError: true},
+		{desc: "webhook without url", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "ftp://example.com"}, hasError: true},
+		{desc: "webhook to localhost", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "http://localhost:9000"}, hasError: true},
+		{desc: "webhook to loopback", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "http://127.0.0.1/hook"}, hasError: true},
+		{desc: "webhook to metadata", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "http://169.254.169.254/latest"}, hasError: true},
+		{desc: "webhook to private range", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "https://10.0.0.8"}, hasError: true},
+		{desc: "webhook to ipv6 loopback", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.WebhookChannel, Target: "http://[::1]:8080"}, hasError: true},
+		{desc: "email without address", rule: models.AlertRule{TickerID: "AAPL", Type: models.PriceAboveAlert, Threshold: 1, Channel: models.EmailChannel, Target: "trader"}, hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rule, err := NormalizeRule(tC.rule)
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, rule)
+		})
+	}
+}
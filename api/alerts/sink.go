@@ -0,0 +1,50 @@
+package alerts
+
+import (
+	"api/config"
+	"api/models"
+	"context"
+	"time"
+)
+
+// Notification is the payload delivered to the sinks when a rule fires
+type Notification struct {
+	RuleID  uint             `json:"ruleId"`
+	Ticker  string           `json:"ticker"`
+	Type    models.AlertType `json:"type"`
+	Message string           `json:"message"`
+	Value   float64          `json:"value"`
+	FiredAt time.Time        `json:"firedAt"`
+}
+
+// Destination of the notifications of a rule, Target is the url or the address of the channel
+// and Secret signs the webhooks, each rule has its own so the receivers can tell whose rule fired
+type Destination struct {
+	Target string
+	Secret string
+}
+
+// Sink delivers the notifications of a channel to the destination of the rule
+type Sink interface {
+	Send(ctx context.Context, destination Destination, notification Notification) error
+}
+
+// DefaultSinks creates the sinks configured in the environment
+// the webhook sink is always available and the email sink requires SMTP_HOST
+func DefaultSinks() map[models.AlertChannel]Sink {
+	sinks := map[models.AlertChannel]Sink{
+		models.WebhookChannel: NewWebhookSink(config.Alerts().WebhookTimeout),
+	}
+
+	if config.Alerts().SMTPHost != "" {
+		sinks[models.EmailChannel] = NewSMTPSink(SMTPConfig{
+			Host:     config.Alerts().SMTPHost,
+			Port:     config.Alerts().SMTPPort,
+			Username: config.Alerts().SMTPUsername,
+			Password: config.Alerts().SMTPPassword,
+			From:     config.Alerts().SMTPFrom,
+		})
+	}
+
+	return sinks
+}
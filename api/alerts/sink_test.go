@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"api/models"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNotification = Notification{
+	RuleID:  7,
+	Ticker:  "AAPL",
+	Type:    models.PriceAboveAlert,
+	Message: "AAPL price 201.50 crossed above 200.00",
+	Value:   201.5,
+	FiredAt: time.Date(2025, 6, 2, 15, 30, 0, 0, time.UTC),
+}
+
+// newLocalWebhookSink returns a sink that can post to the test servers on the loopback
+func newLocalWebhookSink() *WebhookSink {
+	sink := NewWebhookSink(time.Second)
+	sink.client.Transport = http.DefaultTransport
+	return sink
+}
+
+func TestWebhookSink(t *testing.T) {
+	secret := []byte("secret")
+
+	t.Run("posts the notification signed", func(t *testing.T) {
+		var received Notification
+		var validSignature bool
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			validSignature = VerifySignature(secret, body, r.Header.Get(SignatureHeader))
+			json.Unmarshal(body, &received)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		err := newLocalWebhookSink().Send(context.Background(), Destination{Target: server.URL, Secret: string(secret)}, testNotification)
+
+		assert.NoError(t, err)
+		assert.True(t, validSignature)
+		assert.Equal(t, testNotification, received)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		err := newLocalWebhookSink().Send(context.Background(), Destination{Target: server.URL, Secret: string(secret)}, testNotification)
+		assert.ErrorContains(t, err, "status 500")
+	})
+
+	t.Run("refuses internal addresses", func(t *testing.T) {
+		var called bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+
+		err := NewWebhookSink(time.Second).Send(context.Background(), Destination{Target: server.URL, Secret: string(secret)}, testNotification)
+		assert.ErrorIs(t, err, ErrForbiddenTarget)
+		assert.False(t, called)
+	})
+
+	t.Run("refuses the rules without secret", func(t *testing.T) {
+		var called bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer server.Close()
+
+		err := newLocalWebhookSink().Send(context.Background(), Destination{Target: server.URL}, testNotification)
+		assert.ErrorContains(t, err, "no secret")
+		assert.False(t, called)
+	})
+}
+
+func TestCheckWebhookTarget(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		host     string
+		hasError bool
+	}{
+		{desc: "public ip", host: "93.184.216.34"},
+		{desc: "public ipv6", host: "2606:2800:220:1:248:1893:25c8:1946"},
+		{desc: "localhost", host: "localhost", hasError: true},
+		{desc: "loopback", host: "127.0.0.2", hasError: true},
+		{desc: "metadata", host: "169.254.169.254", hasError: true},
+		{desc: "private range", host: "192.168.1.10", hasError: true},
+		{desc: "unspecified", host: "0.0.0.0", hasError: true},
+		{desc: "ipv6 unique local", host: "fd00::1", hasError: true},
+		{desc: "shared address space", host: "100.64.0.1", hasError: true},
+		{desc: "this network", host: "0.1.2.3", hasError: true},
+		{desc: "ipv4-mapped loopback", host: "::ffff:127.0.0.1", hasError: true},
+		{desc: "ipv4-mapped private range", host: "::ffff:10.0.0.1", hasError: true},
+		{desc: "nat64 metadata", host: "64:ff9b::a9fe:a9fe", hasError: true},
+		{desc: "6to4 loopback", host: "2002:7f00:1::", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := CheckWebhookTarget(context.Background(), tC.host)
+			if tC.hasError {
+				assert.ErrorIs(t, err, ErrForbiddenTarget)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ticker":"AAPL"}`)
+	signature := Sign([]byte("secret"), body)
+
+	assert.True(t, VerifySignature([]byte("secret"), body, signature))
+	assert.False(t, VerifySignature([]byte("other"), body, signature))
+	assert.False(t, VerifySignature([]byte("secret"), []byte(`{"ticker":"MSFT"}`), signature))
+}
+
+func TestSMTPSink(t *testing.T) {
+	var addr, from string
+	var to []string
+	var message string
+
+	sink := NewSMTPSink(SMTPConfig{Host: "smtp.example.com", Port: "587", From: "alerts@example.com"})
+	sink.sendMail = func(a string, auth smtp.Auth, f string, t []string, msg []byte) error {
+		addr, from, to, message = a, f, t, string(msg)
+		return nil
+	}
+
+	err := sink.Send(context.Background(), Destination{Target: "trader@example.com"}, testNotification)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, "alerts@example.com", from)
+	assert.Equal(t, []string{"trader@example.com"}, to)
+	assert.Contains(t, message, "To: trader@example.com\r\n")
+	assert.Contains(t, message, "Subject: [StockVision] AAPL alert: price_above\r\n")
+	assert.Contains(t, message, "\r\n\r\nAAPL price 201.50 crossed above 200.00\r\n")
+}
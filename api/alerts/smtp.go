@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig configuration of the SMTP server, Username empty disables the authentication
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// sendMailFunc sends a message, the signature of smtp.SendMail
+type sendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// SMTPSink sends the notifications by email to the address of the target
+type SMTPSink struct {
+	config   SMTPConfig
+	sendMail sendMailFunc
+}
+
+// NewSMTPSink creates a new SMTPSink
+func NewSMTPSink(config SMTPConfig) *SMTPSink {
+	return &SMTPSink{
+		config:   config,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send sends the notification as a plain text email
+// smtp.SendMail does not accept a context, the context is only checked before sending
+func (s *SMTPSink) Send(ctx context.Context, destination Destination, notification Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+	if err := s.sendMail(addr, auth, s.config.From, []string{destination.Target}, s.message(destination.Target, notification)); err != nil {
+		return fmt.Errorf("[SMTPSink] failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// message builds the email with the headers and the text of the notification
+func (s *SMTPSink) message(target string, notification Notification) []byte {
+	var message strings.Builder
+
+	message.WriteString("From: " + s.config.From + "\r\n")
+	message.WriteString("To: " + target + "\r\n")
+	message.WriteString(fmt.Sprintf("Subject: [StockVision] %s alert: %s\r\n", notification.Ticker, notification.Type))
+	message.WriteString("Date: " + notification.FiredAt.Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(notification.Message + "\r\n")
+	message.WriteString(fmt.Sprintf("\r\nRule: %d\r\nFired at: %s\r\n", notification.RuleID, notification.FiredAt.Format(time.RFC3339)))
+
+	return []byte(message.String())
+}
@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenTarget is returned for the webhooks to the loopback, link-local, private and reserved addresses,
+// the webhooks are posted from inside the cluster and must not reach its internal services
+var ErrForbiddenTarget = errors.New("the webhook target must be a public address")
+
+// forbiddenPrefixes ranges that are not reachable from the internet or that embed an ipv4 address
+// that could be internal, like the NAT64, 6to4 and Teredo ranges
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// forbiddenIP checks the address is not reachable from the internet,
+// the ipv4-mapped addresses are checked as ipv4
+func forbiddenIP(ip net.IP) bool {
+	address, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+
+	address = address.Unmap()
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkHost rejects the ip literals and the names of the local addresses without resolving them
+func checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenTarget
+	}
+
+	if ip := net.ParseIP(host); ip != nil && forbiddenIP(ip) {
+		return ErrForbiddenTarget
+	}
+
+	return nil
+}
+
+// CheckWebhookTarget resolves the host of the webhook and rejects it if any of its addresses is not public,
+// the addresses are checked again when the webhook is posted because the dns can change
+func CheckWebhookTarget(ctx context.Context, host string) error {
+	if err := checkHost(host); err != nil {
+		return err
+	}
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("the host of the webhook can not be resolved: %s", host)
+	}
+
+	for _, address := range addresses {
+		if forbiddenIP(address.IP) {
+			return ErrForbiddenTarget
+		}
+	}
+
+	return nil
+}
+
+// controlDial rejects the connections to the addresses that are not public,
+// it runs after the resolution so it also covers the hosts that change their dns after the check
+func controlDial(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || forbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+
+	return nil
+}
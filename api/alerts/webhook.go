@@ -0,0 +1,99 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader header with the HMAC-SHA256 of the body of the webhooks, with the format sha256=<hex>
+const SignatureHeader = "X-Alert-Signature"
+
+// WebhookSink posts the notifications as json to the url of the target
+// the body is signed with the secret of the rule so the receivers can verify the origin
+// the connections to the loopback, link-local and private addresses are refused
+type WebhookSink struct {
+	client *http.Client
+}
+
+// NewWebhookSink creates a new WebhookSink, timeout limits each delivery
+func NewWebhookSink(timeout time.Duration) *WebhookSink {
+	dialer := &net.Dialer{Timeout: timeout, Control: controlDial}
+
+	return &WebhookSink{
+		client: &http.Client{
+			Timeout: timeout,
+			// without proxy, the dialer must see the address of the webhook
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// the redirects are not followed, they could point to an internal address
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send posts the notification, the responses out of the 2xx range are errors
+func (s *WebhookSink) Send(ctx context.Context, destination Destination, notification Notification) error {
+	if destination.Secret == "" {
+		return errors.New("[WebhookSink] the rule has no secret to sign the notification")
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("[WebhookSink] failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination.Target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("[WebhookSink] failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign([]byte(destination.Secret), body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("[WebhookSink] failed to post notification: %w", err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("[WebhookSink] the webhook responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// GenerateSecret creates the random secret that signs the webhooks of a rule
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign calculates the signature of the body with the format sha256=<hex>
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks the signature of a webhook body in constant time
+func VerifySignature(secret []byte, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,124 @@
+package analytics
+
+// analytics measures the outcome of the recommendations of the brokerages
+// with the closes after each call
+
+import (
+	"api/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// horizons in calendar days used to evaluate the calls
+const (
+	ShortHorizonDays  = 30
+	LongHorizonDays   = 90
+	TargetHorizonDays = 90
+)
+
+// BrokerageMetrics calculates the stats of each brokerage of the recommendations
+// the upgrades are evaluated after ShortHorizonDays and LongHorizonDays and the targets after TargetHorizonDays
+// prices are the historical prices per ticker, the base of a call is the close
+// of the first trading day after it, so the prices known before the call are not used
+func BrokerageMetrics(recommendations []models.Recommendation, prices map[string][]models.HistoricalPrice) map[uint]*models.BrokerageStats {
+	series := make(map[string][]models.HistoricalPrice, len(prices))
+	for ticker, tickerPrices := range prices {
+		series[strings.ToUpper(ticker)] = models.SortPrices(tickerPrices)
+	}
+
+	stats := make(map[uint]*models.BrokerageStats)
+	targetErrors := make(map[uint]float64)
+
+	for _, recommendation := range recommendations {
+		brokerageStats, ok := stats[recommendation.BrokerageID]
+		if !ok {
+			brokerageStats = &models.BrokerageStats{BrokerageID: recommendation.BrokerageID}
+			stats[recommendation.BrokerageID] = brokerageStats
+		}
+
+		brokerageStats.Calls++
+		if recommendation.Time.After(brokerageStats.LastCallAt) {
+			brokerageStats.LastCallAt = recommendation.Time
+		}
+
+		tickerPrices := series[strings.ToUpper(recommendation.TickerID)]
+		base, hasBase := closeAfter(tickerPrices, recommendation.Time, 0)
+
+		switch recommendation.Action.Normalize() {
+		case "upgraded":
+			brokerageStats.Upgrades++
+			if !hasBase {
+				break
+			}
+
+			if close, ok := closeAfter(tickerPrices, recommendation.Time, ShortHorizonDays); ok {
+				brokerageStats.Upgrades30++
+				if close > base {
+					brokerageStats.Gains30++
+				}
+			}
+
+			if close, ok := closeAfter(tickerPrices, recommendation.Time, LongHorizonDays); ok {
+				brokerageStats.Upgrades90++
+				if close > base {
+					brokerageStats.Gains90++
+				}
+			}
+		case "downgraded":
+			brokerageStats.Downgrades++
+		}
+
+		if recommendation.TargetTo > 0 {
+			if close, ok := closeAfter(tickerPrices, recommendation.Time, TargetHorizonDays); ok {
+				brokerageStats.TargetsEvaluated++
+				targetErrors[recommendation.BrokerageID] += math.Abs(close-recommendation.TargetTo) / recommendation.TargetTo * 100
+			}
+		}
+	}
+
+	for id, brokerageStats := range stats {
+		brokerageStats.UpgradeHitRate30 = ratio(brokerageStats.Gains30, brokerageStats.Upgrades30)
+		brokerageStats.UpgradeHitRate90 = ratio(brokerageStats.Gains90, brokerageStats.Upgrades90)
+
+		if brokerageStats.TargetsEvaluated > 0 {
+			targetError := targetErrors[id] / float64(brokerageStats.TargetsEvaluated)
+			brokerageStats.TargetError = &targetError
+		}
+	}
+
+	return stats
+}
+
+// closeAfter returns the close of the first trading day after the days since the time
+// with 0 days it is the first trading day after the day of the time
+// returns false if the prices do not reach that date yet
+func closeAfter(prices []models.HistoricalPrice, since time.Time, days int) (float64, bool) {
+	var date string
+	if days == 0 {
+		date = since.Format("2006-01-02")
+		index := sort.Search(len(prices), func(i int) bool { return prices[i].Date > date })
+		if index >= len(prices) {
+			return 0, false
+		}
+		return prices[index].Close, true
+	}
+
+	date = since.AddDate(0, 0, days).Format("2006-01-02")
+	index := sort.Search(len(prices), func(i int) bool { return prices[i].Date >= date })
+	if index >= len(prices) {
+		return 0, false
+	}
+
+	return prices[index].Close, true
+}
+
+func ratio(count int, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+
+	value := float64(count) / float64(total)
+	return &value
+}
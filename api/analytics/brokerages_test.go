@@ -0,0 +1,99 @@
+package analytics
+
+import (
+	"api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func TestBrokerageMetrics(t *testing.T) {
+	prices := map[string][]models.HistoricalPrice{
+		"aapl": {
+			{Date: "2025-04-01", Close: 130},
+			{Date: "2025-02-03", Close: 90},
+			{Date: "2025-01-02", Close: 100},
+			{Date: "2025-01-01", Close: 50}, // day of the calls, not used as base
+		},
+		"MSFT": {
+			{Date: "2025-01-02", Close: 100},
+			{Date: "2025-02-03", Close: 110},
+		},
+	}
+
+	recommendations := []models.Recommendation{
+		// base 100, after 30 days 90 (loss), after 90 days 130 (gain), target error |130-120|/120
+		{BrokerageID: 1, TickerID: "AAPL", Action: "upgraded by", TargetTo: 120, Time: date("2025-01-01")},
+		// base 100, after 30 days 110 (gain), 90 days not reached, target not evaluated
+		{BrokerageID: 1, TickerID: "MSFT", Action: "upgraded by", TargetTo: 150, Time: date("2025-01-01")},
+		// no prices
+		{BrokerageID: 1, TickerID: "TSLA", Action: "upgraded by", Time: date("2025-01-01")},
+		// target error |130-100|/100
+		{BrokerageID: 2, TickerID: "AAPL", Action: "downgraded by", TargetTo: 100, Time: date("2025-01-01")},
+		{BrokerageID: 2, TickerID: "AAPL", Action: "reiterated by", Time: date("2025-03-01")},
+	}
+
+	stats := BrokerageMetrics(recommendations, prices)
+
+	assert.Len(t, stats, 2)
+
+	first := stats[1]
+	assert.Equal(t, 3, first.Calls)
+	assert.Equal(t, 3, first.Upgrades)
+	assert.Equal(t, 0, first.Downgrades)
+	assert.Equal(t, 2, first.Upgrades30)
+	assert.Equal(t, 1, first.Gains30)
+	assert.InDelta(t, 0.5, *first.UpgradeHitRate30, 1e-9)
+	assert.Equal(t, 1, first.Upgrades90)
+	assert.Equal(t, 1, first.Gains90)
+	assert.InDelta(t, 1, *first.UpgradeHitRate90, 1e-9)
+	assert.Equal(t, 1, first.TargetsEvaluated)
+	assert.InDelta(t, 10.0/120*100, *first.TargetError, 1e-9)
+	assert.Equal(t, date("2025-01-01"), first.LastCallAt)
+
+	second := stats[2]
+	assert.Equal(t, 2, second.Calls)
+	assert.Equal(t, 0, second.Upgrades)
+	assert.Equal(t, 1, second.Downgrades)
+	assert.Nil(t, second.UpgradeHitRate30)
+	assert.Nil(t, second.UpgradeHitRate90)
+	assert.InDelta(t, 30, *second.TargetError, 1e-9)
+	assert.Equal(t, date("2025-03-01"), second.LastCallAt)
+}
+
+func TestCloseAfter(t *testing.T) {
+	prices := []models.HistoricalPrice{
+		{Date: "2025-01-02", Close: 1},
+		{Date: "2025-01-03", Close: 2},
+		{Date: "2025-01-06", Close: 3},
+	}
+
+	testCases := []struct {
+		desc     string
+		since    time.Time
+		days     int
+		expected float64
+		found    bool
+	}{
+		{desc: "next trading day", since: date("2025-01-02"), days: 0, expected: 2, found: true},
+		{desc: "weekend", since: date("2025-01-03"), days: 1, expected: 3, found: true},
+		{desc: "exact day", since: date("2025-01-01"), days: 2, expected: 2, found: true},
+		{desc: "not reached", since: date("2025-01-06"), days: 0, found: false},
+		{desc: "horizon not reached", since: date("2025-01-02"), days: 30, found: false},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			close, found := closeAfter(prices, tC.since, tC.days)
+
+			assert.Equal(t, tC.found, found)
+			assert.Equal(t, tC.expected, close)
+		})
+	}
+}
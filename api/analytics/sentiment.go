@@ -0,0 +1,54 @@
+package analytics
+
+import (
+	"api/models"
+	"math"
+	"time"
+)
+
+// weights of the weighted sentiment
+const (
+	// SentimentHalfLifeDays days after which a rating weights half
+	SentimentHalfLifeDays = 90
+	// reliabilityPriorUpgrades upgrades with a hit rate of 0.5 added to the stats of each brokerage,
+	// so a brokerage with few evaluated calls stays close to the neutral weight
+	reliabilityPriorUpgrades = 5
+	// minReliabilityWeight keeps the ratings of the least reliable brokerages in the sentiment
+	minReliabilityWeight = 0.1
+)
+
+// SentimentWeights calculates the weight of each recommendation as its RecencyWeight by the
+// ReliabilityWeight of its brokerage, stats are the stats of the brokerages by id
+func SentimentWeights(recommendations []models.Recommendation, stats map[uint]*models.BrokerageStats, now time.Time) []float64 {
+	weights := make([]float64, len(recommendations))
+	for i, recommendation := range recommendations {
+		weights[i] = RecencyWeight(recommendation.Time, now) * ReliabilityWeight(stats[recommendation.BrokerageID])
+	}
+
+	return weights
+}
+
+// RecencyWeight decays exponentially with the age of the call, 1 for the calls of now
+// and 0.5 after SentimentHalfLifeDays, the calls in the future weight 1
+func RecencyWeight(callTime time.Time, now time.Time) float64 {
+	ageDays := now.Sub(callTime).Hours() / 24
+	if ageDays <= 0 {
+		return 1
+	}
+
+	return math.Exp(-math.Ln2 * ageDays / SentimentHalfLifeDays)
+}
+
+// ReliabilityWeight is two times the hit rate of the upgrades after LongHorizonDays, between
+// minReliabilityWeight and 2, the hit rate is smoothed towards 0.5 so the brokerages without
+// stats or with few evaluated upgrades weight 1
+func ReliabilityWeight(stats *models.BrokerageStats) float64 {
+	var gains, upgrades float64
+	if stats != nil {
+		gains = float64(stats.Gains90)
+		upgrades = float64(stats.Upgrades90)
+	}
+
+	hitRate := (gains + 0.5*reliabilityPriorUpgrades) / (upgrades + reliabilityPriorUpgrades)
+	return math.Max(2*hitRate, minReliabilityWeight)
+}
@@ -0,0 +1,69 @@
+package analytics
+
+import (
+	"api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecencyWeight(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc     string
+		callTime time.Time
+		expected float64
+	}{
+		{desc: "now", callTime: now, expected: 1},
+		{desc: "future", callTime: now.AddDate(0, 0, 3), expected: 1},
+		{desc: "half life", callTime: now.AddDate(0, 0, -SentimentHalfLifeDays), expected: 0.5},
+		{desc: "two half lives", callTime: now.AddDate(0, 0, -2*SentimentHalfLifeDays), expected: 0.25},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, RecencyWeight(tC.callTime, now), 1e-9)
+		})
+	}
+}
+
+func TestReliabilityWeight(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		stats    *models.BrokerageStats
+		expected float64
+	}{
+		{desc: "without stats", stats: nil, expected: 1},
+		{desc: "without evaluated upgrades", stats: &models.BrokerageStats{Calls: 10}, expected: 1},
+		{desc: "half of the upgrades gained", stats: &models.BrokerageStats{Upgrades90: 20, Gains90: 10}, expected: 1},
+		{desc: "all the upgrades gained", stats: &models.BrokerageStats{Upgrades90: 15, Gains90: 15}, expected: 1.75},
+		{desc: "no upgrade gained", stats: &models.BrokerageStats{Upgrades90: 15, Gains90: 0}, expected: 0.25},
+		{desc: "minimum weight", stats: &models.BrokerageStats{Upgrades90: 1000, Gains90: 0}, expected: minReliabilityWeight},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.InDelta(t, tC.expected, ReliabilityWeight(tC.stats), 1e-9)
+		})
+	}
+}
+
+func TestSentimentWeights(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recommendations := []models.Recommendation{
+		{BrokerageID: 1, Time: now},
+		{BrokerageID: 2, Time: now.AddDate(0, 0, -SentimentHalfLifeDays)},
+		{BrokerageID: 3, Time: now},
+	}
+	stats := map[uint]*models.BrokerageStats{
+		1: {BrokerageID: 1, Upgrades90: 15, Gains90: 15},
+		2: {BrokerageID: 2, Upgrades90: 15, Gains90: 0},
+	}
+
+	weights := SentimentWeights(recommendations, stats, now)
+
+	assert.Len(t, weights, 3)
+	assert.InDelta(t, 1.75, weights[0], 1e-9)
+	assert.InDelta(t, 0.125, weights[1], 1e-9)
+	assert.InDelta(t, 1, weights[2], 1e-9)
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidApiKey is returned when the key does not exist or was revoked
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// ApiKeyPrefix is the prefix of the keys generated by the api, used to tell them apart from JWTs
+const ApiKeyPrefix = "sv_"
+
+// GenerateApiKey creates a new random api key
+// returns the raw key, shown only once to the user, and its visible prefix
+func GenerateApiKey() (string, string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	key := ApiKeyPrefix + hex.EncodeToString(secret)
+	return key, key[:len(ApiKeyPrefix)+8], nil
+}
+
+// HashApiKey returns the sha256 of the key, only the hash is stored in the database
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsApiKey check if the credential has the format of an api key
+func IsApiKey(credential string) bool {
+	return strings.HasPrefix(credential, ApiKeyPrefix)
+}
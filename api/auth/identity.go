@@ -0,0 +1,32 @@
+package auth
+
+import "context"
+
+// Method is the mechanism used to authenticate the request
+type Method string
+
+const (
+	MethodApiKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+)
+
+// Identity represents the caller of a request
+// Subject is the user or client that owns the credentials
+type Identity struct {
+	Subject string `json:"subject"`
+	Method  Method `json:"method"`
+	KeyID   uint   `json:"keyId,omitempty"`
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of the context with the identity of the caller
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity of the caller stored in the context
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHS256(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	valid, _ := SignHS256(Claims{Subject: "analyst", ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+	expired, _ := SignHS256(Claims{Subject: "analyst", ExpiresAt: now.Add(-time.Hour).Unix()}, secret)
+	notBefore, _ := SignHS256(Claims{Subject: "analyst", NotBefore: now.Add(time.Hour).Unix()}, secret)
+	withoutSubject, _ := SignHS256(Claims{ExpiresAt: now.Add(time.Hour).Unix()}, secret)
+	otherSecret, _ := SignHS256(Claims{Subject: "analyst"}, []byte("other"))
+
+	// alg none must be rejected even without signature
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := strings.Split(valid, ".")[1]
+	algNone := noneHeader + "." + payload + "."
+
+	testCases := []struct {
+		desc     string
+		token    string
+		expected error
+	}{
+		{desc: "valid token", token: valid, expected: nil},
+		{desc: "expired token", token: expired, expected: ErrExpiredToken},
+		{desc: "not valid yet", token: notBefore, expected: ErrInvalidToken},
+		{desc: "without subject", token: withoutSubject, expected: ErrInvalidToken},
+		{desc: "signed with other secret", token: otherSecret, expected: ErrInvalidToken},
+		{desc: "alg none", token: algNone, expected: ErrInvalidToken},
+		{desc: "malformed", token: "abc.def", expected: ErrInvalidToken},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			claims, err := ParseHS256(tC.token, secret, now)
+			if tC.expected != nil {
+				assert.ErrorIs(t, err, tC.expected)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "analyst", claims.Subject)
+		})
+	}
+}
+
+func TestGenerateApiKey(t *testing.T) {
+	key, prefix, err := GenerateApiKey()
+	assert.NoError(t, err)
+	assert.True(t, IsApiKey(key))
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Len(t, HashApiKey(key), 64)
+	assert.NotEqual(t, key, HashApiKey(key))
+	assert.Equal(t, HashApiKey(key), HashApiKey(key))
+}
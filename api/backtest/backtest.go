@@ -0,0 +1,191 @@
+package backtest
+
+// backtest simulates rule based strategies over the analyst recommendations
+// and measures their outcome with the historical prices
+
+import (
+	"api/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TradingDaysPerYear used to annualize the Sharpe ratio
+const TradingDaysPerYear = 252
+
+// Trade is a position opened by a signal, the entry is the close of the first trading day
+// after the signal, to not use prices known before the recommendation
+type Trade struct {
+	Ticker     string  `json:"ticker"`
+	Brokerage  string  `json:"brokerage"`
+	Reason     string  `json:"reason"`
+	SignalDate string  `json:"signalDate"`
+	EntryDate  string  `json:"entryDate"`
+	EntryPrice float64 `json:"entryPrice"`
+	ExitDate   string  `json:"exitDate"`
+	ExitPrice  float64 `json:"exitPrice"`
+	Return     float64 `json:"return"`
+}
+
+// Result metrics of the simulation, the returns are ratios (0.1 is 10%)
+//
+// the portfolio invests the same weight in each open trade and stays in cash without trades,
+// TotalReturn, CAGR, MaxDrawdown and Sharpe are calculated over its daily returns.
+// Skipped are the signals without prices to close the trade or with a trade of the ticker open
+type Result struct {
+	Strategy      Strategy `json:"strategy"`
+	Signals       int      `json:"signals"`
+	Skipped       int      `json:"skipped"`
+	TotalReturn   float64  `json:"totalReturn"`
+	CAGR          float64  `json:"cagr"`
+	MaxDrawdown   float64  `json:"maxDrawdown"`
+	Sharpe        float64  `json:"sharpe"`
+	HitRate       float64  `json:"hitRate"`
+	AverageReturn float64  `json:"averageReturn"`
+	Trades        []Trade  `json:"trades"`
+}
+
+// Simulate opens a trade for each signal and closes it after the hold days of the strategy
+// only one trade per ticker is open at the same time, prices are the historical prices per ticker
+func Simulate(strategy Strategy, signals []Signal, prices map[string][]models.HistoricalPrice) Result {
+	result := Result{
+		Strategy: strategy,
+		Signals:  len(signals),
+		Trades:   make([]Trade, 0),
+	}
+
+	sortedSignals := append([]Signal(nil), signals...)
+	sort.SliceStable(sortedSignals, func(i, j int) bool { return sortedSignals[i].Date.Before(sortedSignals[j].Date) })
+
+	series := make(map[string][]models.HistoricalPrice, len(prices))
+	for ticker, tickerPrices := range prices {
+		series[strings.ToUpper(ticker)] = models.SortPrices(tickerPrices)
+	}
+
+	// daily returns of the open trades per date
+	dailySum := make(map[string]float64)
+	dailyCount := make(map[string]int)
+	openUntil := make(map[string]string)
+	calendar := make(map[string]bool)
+
+	for _, signal := range sortedSignals {
+		tickerPrices := series[signal.Ticker]
+		signalDate := signal.Date.Format("2006-01-02")
+
+		entry := sort.Search(len(tickerPrices), func(i int) bool { return tickerPrices[i].Date > signalDate })
+		exit := entry + strategy.HoldDays
+		if exit >= len(tickerPrices) || tickerPrices[entry].Date <= openUntil[signal.Ticker] {
+			result.Skipped++
+			continue
+		}
+		openUntil[signal.Ticker] = tickerPrices[exit].Date
+
+		for day := entry + 1; day <= exit; day++ {
+			date := tickerPrices[day].Date
+			dailySum[date] += tickerPrices[day].Close/tickerPrices[day-1].Close - 1
+			dailyCount[date]++
+		}
+
+		for day := entry; day <= exit; day++ {
+			calendar[tickerPrices[day].Date] = true
+		}
+
+		result.Trades = append(result.Trades, Trade{
+			Ticker:     signal.Ticker,
+			Brokerage:  signal.Brokerage,
+			Reason:     signal.Reason,
+			SignalDate: signalDate,
+			EntryDate:  tickerPrices[entry].Date,
+			EntryPrice: tickerPrices[entry].Close,
+			ExitDate:   tickerPrices[exit].Date,
+			ExitPrice:  tickerPrices[exit].Close,
+			Return:     tickerPrices[exit].Close/tickerPrices[entry].Close - 1,
+		})
+	}
+
+	if len(result.Trades) == 0 {
+		return result
+	}
+
+	wins, sumReturns := 0, 0.0
+	for _, trade := range result.Trades {
+		if trade.Return > 0 {
+			wins++
+		}
+		sumReturns += trade.Return
+	}
+	result.HitRate = float64(wins) / float64(len(result.Trades))
+	result.AverageReturn = sumReturns / float64(len(result.Trades))
+
+	dates := make([]string, 0, len(calendar))
+	for date := range calendar {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	// the first date is the entry of the first trade, without return
+	returns := make([]float64, 0, len(dates))
+	for _, date := range dates[1:] {
+		dailyReturn := 0.0
+		if dailyCount[date] > 0 {
+			dailyReturn = dailySum[date] / float64(dailyCount[date])
+		}
+		returns = append(returns, dailyReturn)
+	}
+
+	result.TotalReturn, result.MaxDrawdown = equityCurve(returns)
+	result.CAGR = cagr(result.TotalReturn, dates[0], dates[len(dates)-1])
+	result.Sharpe = Sharpe(returns)
+
+	return result
+}
+
+// equityCurve compounds the daily returns, returns the total return and the max drawdown
+func equityCurve(returns []float64) (float64, float64) {
+	equity, peak, maxDrawdown := 1.0, 1.0, 0.0
+	for _, r := range returns {
+		equity *= 1 + r
+		peak = math.Max(peak, equity)
+		maxDrawdown = math.Max(maxDrawdown, (peak-equity)/peak)
+	}
+
+	return equity - 1, maxDrawdown
+}
+
+// cagr annualizes the total return between the dates (YYYY-MM-DD)
+func cagr(totalReturn float64, from string, to string) float64 {
+	start, errStart := time.Parse("2006-01-02", from)
+	end, errEnd := time.Parse("2006-01-02", to)
+	if errStart != nil || errEnd != nil || !end.After(start) || totalReturn <= -1 {
+		return 0
+	}
+
+	years := end.Sub(start).Hours() / 24 / 365.25
+	return math.Pow(1+totalReturn, 1/years) - 1
+}
+
+// Sharpe annualized Sharpe ratio of the daily returns with a risk free rate of 0
+func Sharpe(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(returns)-1))
+
+	if deviation == 0 {
+		return 0
+	}
+
+	return mean / deviation * math.Sqrt(TradingDaysPerYear)
+}
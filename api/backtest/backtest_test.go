@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"api/models"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func prices(dates []string, closes []float64) []models.HistoricalPrice {
+	result := make([]models.HistoricalPrice, len(dates))
+	for i := range dates {
+		// ordered from the newest to the oldest like the financial api
+		result[len(dates)-1-i] = models.HistoricalPrice{Date: dates[i], Close: closes[i]}
+	}
+	return result
+}
+
+func recommendation(ticker string, brokerage string, action models.Action, ratingTo string, time string) models.Recommendation {
+	return models.Recommendation{
+		TickerID:  ticker,
+		Brokerage: models.Brokerage{Name: brokerage},
+		Action:    action,
+		RatingTo:  ratingTo,
+		Time:      date(time),
+	}
+}
+
+func TestStrategyNormalize(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		strategy Strategy
+		expected Strategy
+		hasError bool
+	}{
+		{
+			desc:     "action with defaults",
+			strategy: Strategy{Type: "Action", Action: "upgraded by"},
+			expected: Strategy{Type: ActionStrategy, Action: "upgraded", HoldDays: DefaultHoldDays},
+		},
+		{
+			desc:     "sentiment with defaults",
+			strategy: Strategy{Type: "sentiment", Action: "upgraded", HoldDays: 5},
+			expected: Strategy{Type: SentimentStrategy, Window: DefaultWindow, HoldDays: 5},
+		},
+		{desc: "unknown type", strategy: Strategy{Type: "momentum"}, hasError: true},
+		{desc: "action required", strategy: Strategy{Type: ActionStrategy}, hasError: true},
+		{desc: "invalid hold days", strategy: Strategy{Type: SentimentStrategy, HoldDays: MaxHoldDays + 1}, hasError: true},
+		{desc: "invalid window", strategy: Strategy{Type: SentimentStrategy, Window: -1}, hasError: true},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			strategy, err := tC.strategy.Normalize()
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, strategy)
+		})
+	}
+}
+
+func TestSignals(t *testing.T) {
+	recommendations := []models.Recommendation{
+		recommendation("AAPL", "Goldman Sachs", "upgraded by", "Buy", "2025-01-10"),
+		recommendation("AAPL", "Morgan Stanley", "upgraded by", "Buy", "2025-01-05"),
+		recommendation("MSFT", "Goldman Sachs", "downgraded by", "Sell", "2025-01-06"),
+		recommendation("MSFT", "goldman sachs", "upgraded by", "Buy", "2025-02-01"),
+	}
+
+	t.Run("action filtered by brokerage and range", func(t *testing.T) {
+		strategy := Strategy{Type: ActionStrategy, Action: "upgraded", Brokerage: "Goldman Sachs", HoldDays: 1}
+		signals := strategy.Signals(recommendations, date("2025-01-01"), date("2025-01-31"))
+
+		assert.Len(t, signals, 1)
+		assert.Equal(t, "AAPL", signals[0].Ticker)
+		assert.Equal(t, date("2025-01-10"), signals[0].Date)
+	})
+
+	t.Run("sentiment turns positive", func(t *testing.T) {
+		strategy := Strategy{Type: SentimentStrategy, Window: 2, HoldDays: 1}
+		ratingsHistory := []models.Recommendation{
+			recommendation("TSLA", "A", "initiated by", "Buy", "2025-01-01"),       // positive, before the range
+			recommendation("TSLA", "B", "downgraded by", "Sell", "2025-01-02"),     // neutral (0)
+			recommendation("TSLA", "C", "upgraded by", "Buy", "2025-01-03"),        // neutral (0)
+			recommendation("TSLA", "D", "upgraded by", "Buy", "2025-01-04"),        // positive
+			recommendation("TSLA", "E", "reiterated by", "Buy", "2025-01-05"),      // still positive
+			recommendation("TSLA", "F", "downgraded by", "Sell", "2025-01-06"),     // neutral
+			recommendation("TSLA", "G", "upgraded by", "Outperform", "2025-01-07"), // neutral
+			recommendation("TSLA", "H", "upgraded by", "Buy", "2025-01-08"),        // positive
+		}
+
+		signals := strategy.Signals(ratingsHistory, date("2025-01-02"), time.Time{})
+
+		assert.Len(t, signals, 2)
+		assert.Equal(t, date("2025-01-04"), signals[0].Date)
+		assert.Equal(t, "D", signals[0].Brokerage)
+		assert.Equal(t, date("2025-01-08"), signals[1].Date)
+	})
+}
+
+func TestSimulate(t *testing.T) {
+	strategy := Strategy{Type: ActionStrategy, Action: "upgraded", HoldDays: 2}
+	dates := []string{"2025-01-02", "2025-01-03", "2025-01-06", "2025-01-07", "2025-01-08", "2025-01-09"}
+	history := map[string][]models.HistoricalPrice{
+		"AAPL": prices(dates, []float64{100, 100, 110, 99, 120, 130}),
+	}
+
+	signals := []Signal{
+		{Ticker: "AAPL", Date: date("2025-01-02")}, // entry 01-03 at 100, exit 01-07 at 99
+		{Ticker: "AAPL", Date: date("2025-01-06")}, // skipped, the first trade is open
+		{Ticker: "AAPL", Date: date("2025-01-08")}, // skipped, no prices to close
+		{Ticker: "MSFT", Date: date("2025-01-02")}, // skipped, no prices
+	}
+
+	result := Simulate(strategy, signals, history)
+
+	assert.Equal(t, 4, result.Signals)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Len(t, result.Trades, 1)
+	assert.InDelta(t, -0.01, result.Trades[0].Return, 1e-9)
+
+	result.Trades[0].Return = 0
+	assert.Equal(t, []Trade{{
+		Ticker:     "AAPL",
+		SignalDate: "2025-01-02",
+		EntryDate:  "2025-01-03",
+		EntryPrice: 100,
+		ExitDate:   "2025-01-07",
+		ExitPrice:  99,
+	}}, result.Trades)
+
+	// daily returns: +10%, -10%
+	assert.InDelta(t, -0.01, result.TotalReturn, 1e-9)
+	assert.InDelta(t, 0.1, result.MaxDrawdown, 1e-9)
+	assert.Equal(t, float64(0), result.HitRate)
+	assert.InDelta(t, -0.01, result.AverageReturn, 1e-9)
+	assert.Less(t, result.CAGR, -0.01)
+	assert.InDelta(t, 0, result.Sharpe, 1e-9)
+}
+
+func TestSimulateEqualWeight(t *testing.T) {
+	strategy := Strategy{Type: ActionStrategy, Action: "upgraded", HoldDays: 1}
+	dates := []string{"2025-01-02", "2025-01-03", "2025-01-06"}
+	history := map[string][]models.HistoricalPrice{
+		"AAPL": prices(dates, []float64{100, 100, 120}),
+		"MSFT": prices(dates, []float64{50, 50, 45}),
+	}
+
+	signals := []Signal{
+		{Ticker: "AAPL", Date: date("2025-01-02")},
+		{Ticker: "MSFT", Date: date("2025-01-02")},
+	}
+
+	result := Simulate(strategy, signals, history)
+
+	assert.Len(t, result.Trades, 2)
+	// average of +20% and -10%
+	assert.InDelta(t, 0.05, result.TotalReturn, 1e-9)
+	assert.Equal(t, 0.5, result.HitRate)
+	assert.Equal(t, float64(0), result.MaxDrawdown)
+}
+
+func TestSimulateWithoutTrades(t *testing.T) {
+	result := Simulate(Strategy{HoldDays: 1}, nil, nil)
+
+	assert.Equal(t, Result{Strategy: Strategy{HoldDays: 1}, Trades: []Trade{}}, result)
+}
+
+func TestSharpe(t *testing.T) {
+	returns := []float64{0.01, -0.01, 0.02, 0}
+
+	// mean 0.005, sample std dev 0.0129099
+	assert.InDelta(t, 0.005/0.012909944487358056*math.Sqrt(252), Sharpe(returns), 1e-9)
+	assert.Equal(t, float64(0), Sharpe([]float64{0.01, 0.01}))
+	assert.Equal(t, float64(0), Sharpe([]float64{0.01}))
+}
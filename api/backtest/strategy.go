@@ -0,0 +1,143 @@
+package backtest
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// types of the supported strategies
+const (
+	// ActionStrategy buys when a brokerage publishes a recommendation with the action
+	ActionStrategy = "action"
+	// SentimentStrategy buys when the sentiment of the latest ratings turns positive
+	SentimentStrategy = "sentiment"
+)
+
+// defaults and limits of the strategies
+const (
+	DefaultHoldDays = 20
+	MaxHoldDays     = 252
+	DefaultWindow   = 5
+	MaxWindow       = 100
+)
+
+// Strategy rules to open the trades, every trade is closed after HoldDays trading days
+//
+// Action and Brokerage filter the recommendations of the action strategy, Brokerage is optional.
+// Window is the number of latest ratings of the ticker used to calculate the sentiment
+type Strategy struct {
+	Type      string        `json:"type"`
+	Action    models.Action `json:"action,omitempty"`
+	Brokerage string        `json:"brokerage,omitempty"`
+	Window    int           `json:"window,omitempty"`
+	HoldDays  int           `json:"holdDays"`
+}
+
+// Signal is the moment to open a trade
+type Signal struct {
+	Ticker    string
+	Date      time.Time
+	Brokerage string
+	Reason    string
+}
+
+// Normalize sets the defaults of the strategy and validates it
+func (s Strategy) Normalize() (Strategy, error) {
+	s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+	s.Brokerage = strings.TrimSpace(s.Brokerage)
+
+	if s.HoldDays == 0 {
+		s.HoldDays = DefaultHoldDays
+	}
+
+	if s.HoldDays < 1 || s.HoldDays > MaxHoldDays {
+		return s, fmt.Errorf("holdDays must be between 1 and %d", MaxHoldDays)
+	}
+
+	switch s.Type {
+	case ActionStrategy:
+		s.Window = 0
+		s.Action = s.Action.Normalize()
+		if s.Action == "" {
+			return s, errors.New("action is required: downgraded, initiated, reiterated, target lowered, target raised, target set or upgraded")
+		}
+	case SentimentStrategy:
+		s.Action = ""
+		if s.Window == 0 {
+			s.Window = DefaultWindow
+		}
+
+		if s.Window < 1 || s.Window > MaxWindow {
+			return s, fmt.Errorf("window must be between 1 and %d", MaxWindow)
+		}
+	default:
+		return s, fmt.Errorf("invalid type: the supported strategies are %s and %s", ActionStrategy, SentimentStrategy)
+	}
+
+	return s, nil
+}
+
+// Signals returns the signals of the strategy in the recommendations between from and to
+// the recommendations before from are used to calculate the sentiment, zero times are not limits
+func (s Strategy) Signals(recommendations []models.Recommendation, from time.Time, to time.Time) []Signal {
+	sorted := append([]models.Recommendation(nil), recommendations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	inRange := func(t time.Time) bool {
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+	}
+
+	signals := make([]Signal, 0)
+	switch s.Type {
+	case ActionStrategy:
+		for _, recommendation := range sorted {
+			if !inRange(recommendation.Time) || recommendation.Action.Normalize() != s.Action {
+				continue
+			}
+
+			if s.Brokerage != "" && !strings.EqualFold(recommendation.Brokerage.Name, s.Brokerage) {
+				continue
+			}
+
+			signals = append(signals, Signal{
+				Ticker:    strings.ToUpper(recommendation.TickerID),
+				Date:      recommendation.Time,
+				Brokerage: recommendation.Brokerage.Name,
+				Reason:    fmt.Sprintf("%s %s to %s", recommendation.Action.Normalize(), recommendation.RatingFrom, recommendation.RatingTo),
+			})
+		}
+	case SentimentStrategy:
+		collections := make(map[string]ratings.RatingCollection)
+		previous := make(map[string]ratings.Sentiment)
+
+		for _, recommendation := range sorted {
+			ticker := strings.ToUpper(recommendation.TickerID)
+
+			collection := append(collections[ticker], ratings.Rating(recommendation.RatingTo))
+			if len(collection) > s.Window {
+				collection = collection[len(collection)-s.Window:]
+			}
+			collections[ticker] = collection
+
+			sentiment := collection.CalculateSentiment().Sentiment
+			turnedPositive := sentiment == ratings.PositiveSentiment && previous[ticker] != ratings.PositiveSentiment
+			previous[ticker] = sentiment
+
+			if turnedPositive && inRange(recommendation.Time) {
+				signals = append(signals, Signal{
+					Ticker:    ticker,
+					Date:      recommendation.Time,
+					Brokerage: recommendation.Brokerage.Name,
+					Reason:    fmt.Sprintf("sentiment of the last %d ratings turned positive", len(collection)),
+				})
+			}
+		}
+	}
+
+	return signals
+}
@@ -1,7 +1,10 @@
 package cache
 
 import (
+	"api/metrics"
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"golang.org/x/sync/singleflight"
@@ -21,43 +24,90 @@ type ICache interface {
 	Ping(ctx context.Context) error
 }
 
+// loadTimeout limits the load shared by the requests of the same key, it does not end with the request that started it
+const loadTimeout = time.Minute
+
 // GetOrLoad utility function to retrieve a value from the cache, if not found, load it using the loader function
 // if the cache is nil, it will load the value using the loader function
-func GetOrLoad[T any](ctx context.Context, cache ICache, key string, expiration time.Duration, loadFunc func() (T, error)) (T, error) {
+//
+// the load is shared by the concurrent requests of the same key, so loadFunc receives a context that is not
+// canceled with the request that started it, each request waits for the value only until its own ctx is done
+func GetOrLoad[T any](ctx context.Context, cache ICache, key string, expiration time.Duration, loadFunc func(ctx context.Context) (T, error)) (T, error) {
 	var value T
 	var zero T
 	var err error
 
 	// if cache is nil or key is empty, return the result of loadFunc
 	if cache == nil || key == "" {
-		return loadFunc()
+		return loadFunc(ctx)
 	}
 
+	prefix := keyPrefix(key)
+
 	err = cache.Get(ctx, key, &value)
 	if err == nil {
+		metrics.CacheRequestsTotal.WithLabelValues(prefix, "hit").Inc()
 		return value, nil
 	}
 
 	// prevent multiple requests update the cache for the same key
-	result, err, _ := group.Do(key, func() (interface{}, error) {
+	executed := false
+	results := group.DoChan(key, func() (result interface{}, err error) {
+		executed = true
+
+		// the load runs in its own goroutine, a panic would stop the server
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("[cache] failed to load key %s: %v", key, r)
+			}
+		}()
+
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+
 		// security validate if not is cached
 		var cached T
-		if e := cache.Get(ctx, key, &cached); e == nil {
+		if e := cache.Get(loadCtx, key, &cached); e == nil {
+			metrics.CacheRequestsTotal.WithLabelValues(prefix, "hit").Inc()
 			return cached, nil
 		}
 
 		// if cache miss, load the value using the loader function
-		_value, _err := loadFunc()
+		metrics.CacheRequestsTotal.WithLabelValues(prefix, "miss").Inc()
+		_value, _err := loadFunc(loadCtx)
 		if _err != nil {
 			return _value, _err
 		}
-		cache.Set(ctx, key, _value, expiration)
+		cache.Set(loadCtx, key, _value, expiration)
 		return _value, nil
 	})
 
-	if err != nil {
-		return zero, err
+	select {
+	case result := <-results:
+		// the value was loaded by another request with the same key
+		if !executed {
+			metrics.CacheRequestsTotal.WithLabelValues(prefix, "shared").Inc()
+		}
+
+		if result.Err != nil {
+			metrics.CacheRequestsTotal.WithLabelValues(prefix, "error").Inc()
+			return zero, result.Err
+		}
+
+		return result.Val.(T), nil
+	case <-ctx.Done():
+		// the load continues for the other requests and fills the cache
+		metrics.CacheRequestsTotal.WithLabelValues(prefix, "canceled").Inc()
+		return zero, ctx.Err()
+	}
+}
+
+// keyPrefix returns the first segment of the key to group the metrics
+// without a label value per key
+func keyPrefix(key string) string {
+	if i := strings.Index(key, ":"); i > 0 {
+		return key[:i]
 	}
 
-	return result.(T), nil
+	return key
 }
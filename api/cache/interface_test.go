@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache stores the values encoded in json like redis
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: make(map[string][]byte)}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, value interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.values[key]
+	if !ok {
+		return errors.New("cache miss")
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = data
+	return nil
+}
+
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	return nil
+}
+
+func (c *memoryCache) Close() error                   { return nil }
+func (c *memoryCache) Ping(ctx context.Context) error { return nil }
+
+func TestGetOrLoadCancelInFlight(t *testing.T) {
+	memory := newMemoryCache()
+	started := make(chan struct{})
+	gate := make(chan struct{})
+	loaderErr := make(chan error, 1)
+
+	loader := func(ctx context.Context) (string, error) {
+		close(started)
+		<-gate
+		loaderErr <- ctx.Err()
+		return "value", nil
+	}
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := GetOrLoad(firstCtx, memory, "test:in-flight", time.Minute, loader)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		value, err := GetOrLoad(context.Background(), memory, "test:in-flight", time.Minute, loader)
+		assert.NoError(t, err)
+		second <- value
+	}()
+
+	// the first request stops waiting without the value
+	cancel()
+	select {
+	case err := <-first:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("the canceled request is still waiting for the load")
+	}
+
+	// the load is not canceled with the request that started it
+	close(gate)
+	assert.NoError(t, <-loaderErr)
+	select {
+	case value := <-second:
+		assert.Equal(t, "value", value)
+	case <-time.After(time.Second):
+		t.Fatal("the shared load did not finish")
+	}
+
+	var cached string
+	assert.NoError(t, memory.Get(context.Background(), "test:in-flight", &cached))
+	assert.Equal(t, "value", cached)
+}
+
+func TestGetOrLoadRecoversPanic(t *testing.T) {
+	_, err := GetOrLoad(context.Background(), newMemoryCache(), "test:panic", time.Minute, func(ctx context.Context) (string, error) {
+		panic("failed")
+	})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a message published in a topic
+// ID orders the messages of all the topics, it is the id of the message in the history
+type Message struct {
+	ID      string
+	Topic   string
+	Payload []byte
+}
+
+// IPubSub publishes messages to all the replicas and keeps a short history of each topic
+// to resume the subscriptions after a disconnection
+type IPubSub interface {
+	// Publish appends the message to the history of the topic and sends it to the subscribers, returns the id
+	Publish(ctx context.Context, topic string, payload []byte) (string, error)
+	// History returns the messages of the topic newer than afterID, the oldest first
+	History(ctx context.Context, topic string, afterID string) ([]Message, error)
+	// Subscribe creates a subscription without topics, the topics are added with Subscription.Subscribe
+	Subscribe(ctx context.Context) Subscription
+}
+
+// Subscription receives the messages of its topics
+type Subscription interface {
+	Subscribe(ctx context.Context, topics ...string) error
+	Unsubscribe(ctx context.Context, topics ...string) error
+	// Messages is closed when the subscription is closed
+	Messages() <-chan Message
+	Close() error
+}
+
+// ILocker takes short leases shared by all the replicas
+type ILocker interface {
+	// TryLock takes or extends the lease of the key for the owner, returns false if other owner has it
+	TryLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key string, owner string) error
+}
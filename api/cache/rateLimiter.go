@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimit defines a token bucket of Limit tokens refilled completely every Period
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// RateLimitResult state of the bucket after taking a token
+// RetryAfter is the time to wait for the next token when is not allowed
+// ResetAfter is the time until the bucket is full again
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// IRateLimiter is the interface of the rate limiters
+// Allow takes a token from the bucket of the key
+type IRateLimiter interface {
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
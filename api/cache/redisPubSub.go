@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// historySize max messages kept in the history of each topic, the history expires after historyTTL without messages
+const (
+	historySize = 1000
+	historyTTL  = 24 * time.Hour
+)
+
+// pubSubEnvelope is the message sent by redis pub/sub, with the id of the message in the history
+type pubSubEnvelope struct {
+	ID      string `json:"id"`
+	Payload []byte `json:"payload"`
+}
+
+// Publish implements IPubSub, the history is a redis stream and the message is sent with redis pub/sub
+func (r *Reddis) Publish(ctx context.Context, topic string, payload []byte) (string, error) {
+	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: historyKey(topic),
+		MaxLen: historySize,
+		Approx: true,
+		Values: map[string]interface{}{"payload": payload},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+
+	r.client.Expire(ctx, historyKey(topic), historyTTL)
+
+	envelope, err := json.Marshal(pubSubEnvelope{ID: id, Payload: payload})
+	if err != nil {
+		return "", err
+	}
+
+	return id, r.client.Publish(ctx, topic, envelope).Err()
+}
+
+// History implements IPubSub
+func (r *Reddis) History(ctx context.Context, topic string, afterID string) ([]Message, error) {
+	start := "-"
+	if afterID != "" {
+		start = "(" + afterID
+	}
+
+	entries, err := r.client.XRange(ctx, historyKey(topic), start, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		payload, ok := entry.Values["payload"].(string)
+		if !ok {
+			continue
+		}
+
+		messages = append(messages, Message{ID: entry.ID, Topic: topic, Payload: []byte(payload)})
+	}
+
+	return messages, nil
+}
+
+// Subscribe implements IPubSub
+func (r *Reddis) Subscribe(ctx context.Context) Subscription {
+	pubsub := r.client.Subscribe(ctx)
+	subscription := &redisSubscription{
+		pubsub:   pubsub,
+		messages: make(chan Message, 100),
+	}
+
+	go subscription.forward()
+
+	return subscription
+}
+
+// lockScript takes the key if it is free or extends it if the owner already has it
+var lockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+if current == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// TryLock implements ILocker with a key that expires after the ttl
+func (r *Reddis) TryLock(ctx context.Context, key string, owner string, ttl time.Duration) (bool, error) {
+	locked, err := lockScript.Run(ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+
+	return locked == 1, nil
+}
+
+// unlockScript deletes the key only if the owner has it
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Unlock implements ILocker
+func (r *Reddis) Unlock(ctx context.Context, key string, owner string) error {
+	return unlockScript.Run(ctx, r.client, []string{key}, owner).Err()
+}
+
+// historyKey is the key of the stream with the history of the topic
+func historyKey(topic string) string {
+	return topic + ":history"
+}
+
+// redisSubscription adapts the redis pub/sub messages to Message
+type redisSubscription struct {
+	pubsub   *redis.PubSub
+	messages chan Message
+}
+
+// forward converts the messages until the subscription is closed
+func (s *redisSubscription) forward() {
+	defer close(s.messages)
+
+	for message := range s.pubsub.Channel() {
+		var envelope pubSubEnvelope
+		if err := json.Unmarshal([]byte(message.Payload), &envelope); err != nil {
+			continue
+		}
+
+		s.messages <- Message{ID: envelope.ID, Topic: message.Channel, Payload: envelope.Payload}
+	}
+}
+
+func (s *redisSubscription) Subscribe(ctx context.Context, topics ...string) error {
+	return s.pubsub.Subscribe(ctx, topics...)
+}
+
+func (s *redisSubscription) Unsubscribe(ctx context.Context, topics ...string) error {
+	return s.pubsub.Unsubscribe(ctx, topics...)
+}
+
+func (s *redisSubscription) Messages() <-chan Message {
+	return s.messages
+}
+
+func (s *redisSubscription) Close() error {
+	return s.pubsub.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"api/config"
 	"context"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -66,3 +67,62 @@ func (r *Reddis) Close() error {
 func (r *Reddis) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
+
+// tokenBucketScript refills the bucket with the elapsed time since the last request and takes a token
+// the time is taken from redis to share the same clock in all the replicas
+// returns allowed (0/1), remaining tokens, retry after (ms) and reset after (ms)
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local rate = capacity / period
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+// Allow implements IRateLimiter with a token bucket stored in redis
+func (r *Reddis) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	if limit.Limit <= 0 || limit.Period <= 0 {
+		return RateLimitResult{Allowed: true}, nil
+	}
+
+	values, err := tokenBucketScript.Run(ctx, r.client, []string{key}, limit.Limit, limit.Period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit response: %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var apiKeysCmd = &cobra.Command{
+	Use:   "api-keys",
+	Short: "Manage the api keys used to authenticate the requests",
+}
+
+var createApiKeyCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an api key, the key is shown only once",
+	Long:  `Run api-keys create --name <name> --subject <user> to create a key, the subject is the identity of the requests made with the key`,
+	RunE:  createApiKey,
+}
+
+var revokeApiKeyCmd = &cobra.Command{
+	Use:   "revoke [id]",
+	Short: "Revoke an api key by id",
+	Args:  cobra.ExactArgs(1),
+	RunE:  revokeApiKey,
+}
+
+var listApiKeysCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the api keys",
+	RunE:  listApiKeys,
+}
+
+var apiKeyName string
+var apiKeySubject string
+
+func init() {
+	createApiKeyCmd.Flags().StringVar(&apiKeyName, "name", "", "Name to identify the key")
+	createApiKeyCmd.Flags().StringVar(&apiKeySubject, "subject", "", "User or client that owns the key")
+	createApiKeyCmd.MarkFlagRequired("name")
+	createApiKeyCmd.MarkFlagRequired("subject")
+
+	apiKeysCmd.AddCommand(createApiKeyCmd, revokeApiKeyCmd, listApiKeysCmd)
+}
+
+// createApiKey creates a key and prints it
+func createApiKey(cmd *cobra.Command, args []string) error {
+	apiKeyService, err := getApiKeyService()
+	if err != nil {
+		return err
+	}
+
+	rawKey, apiKey, err := apiKeyService.CreateApiKey(context.Background(), apiKeyName, apiKeySubject)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[createApiKey] failed to create api key")
+		return err
+	}
+
+	fmt.Println("Api key created with ID:", apiKey.ID)
+	fmt.Println("Store the key, it will not be shown again:")
+	fmt.Println(rawKey)
+	return nil
+}
+
+// revokeApiKey revokes the key with the id of the first argument
+func revokeApiKey(cmd *cobra.Command, args []string) error {
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id: %s", args[0])
+	}
+
+	apiKeyService, err := getApiKeyService()
+	if err != nil {
+		return err
+	}
+
+	if err := apiKeyService.RevokeApiKey(context.Background(), uint(id)); err != nil {
+		apilogger.Logger().Err(err).Msg("[revokeApiKey] failed to revoke api key")
+		return err
+	}
+
+	fmt.Println("Api key revoked:", id)
+	return nil
+}
+
+// listApiKeys prints the keys without the secret
+func listApiKeys(cmd *cobra.Command, args []string) error {
+	apiKeyService, err := getApiKeyService()
+	if err != nil {
+		return err
+	}
+
+	apiKeys, err := apiKeyService.GetApiKeys(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[listApiKeys] failed to list api keys")
+		return err
+	}
+
+	for _, apiKey := range apiKeys {
+		status := "active"
+		if apiKey.RevokedAt != nil {
+			status = "revoked"
+		}
+
+		fmt.Printf("%d\t%s\t%s\t%s...\t%s\n", apiKey.ID, apiKey.Name, apiKey.Subject, apiKey.Prefix, status)
+	}
+
+	return nil
+}
+
+func getApiKeyService() (*services.ApiKeyService, error) {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[apiKeys] failed to get database instance")
+		return nil, err
+	}
+
+	apiKeyService := services.NewApiKeyService(db.DB)
+	return &apiKeyService, nil
+}
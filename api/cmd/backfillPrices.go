@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"api/database"
+	apilogger "api/logger"
+	"api/models"
+	"api/services"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var backfillPricesCmd = &cobra.Command{
+	Use:   "backfill-prices",
+	Short: "Store the end of day prices of the tickers in the historical_prices table",
+	Long: `Run backfill-prices --from 2020-01-01 --concurrency 5 to store the prices of all the tickers since the date,
+the dates already stored are not retrieved again, so the overview, the indicators and the backtests work without the financial API`,
+	RunE: backfillPrices,
+}
+
+var (
+	backfillFrom        string
+	backfillTo          string
+	backfillTickers     string
+	backfillConcurrency int
+)
+
+func init() {
+	backfillPricesCmd.Flags().StringVar(&backfillFrom, "from", "", "First date of the prices (YYYY-MM-DD), default 5 years ago")
+	backfillPricesCmd.Flags().StringVar(&backfillTo, "to", "", "Last date of the prices (YYYY-MM-DD), default today")
+	backfillPricesCmd.Flags().StringVar(&backfillTickers, "tickers", "", "Comma separated tickers, empty for all")
+	backfillPricesCmd.Flags().IntVar(&backfillConcurrency, "concurrency", 5, "Tickers retrieved at the same time")
+}
+
+// backfillPrices stores the prices of the tickers of the flags
+func backfillPrices(cmd *cobra.Command, args []string) error {
+	to := time.Now()
+	from := to.AddDate(-5, 0, 0)
+	var err error
+
+	if backfillFrom != "" {
+		if from, err = time.Parse("2006-01-02", backfillFrom); err != nil {
+			return fmt.Errorf("invalid from date format: the format must be YYYY-MM-DD")
+		}
+	}
+
+	if backfillTo != "" {
+		if to, err = time.Parse("2006-01-02", backfillTo); err != nil {
+			return fmt.Errorf("invalid to date format: the format must be YYYY-MM-DD")
+		}
+	}
+
+	if from.After(to) {
+		return fmt.Errorf("invalid range: from must be before to")
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[backfillPrices] failed to get database instance")
+		return err
+	}
+
+	var tickers []string
+	if backfillTickers != "" {
+		for _, ticker := range strings.Split(backfillTickers, ",") {
+			if ticker = strings.ToUpper(strings.TrimSpace(ticker)); ticker != "" {
+				tickers = append(tickers, ticker)
+			}
+		}
+	} else if err := db.DB.Model(&models.Ticker{}).Pluck("id", &tickers).Error; err != nil {
+		apilogger.Logger().Err(err).Msg("[backfillPrices] failed to retrieve tickers")
+		return err
+	}
+
+	store := services.NewHistoricalPriceStore(db.DB, services.NewFinancialService(nil, services.FinancialCacheExpiration{}))
+
+	fmt.Println("Start backfill-prices")
+	result, err := store.Backfill(context.Background(), tickers, from, to, backfillConcurrency)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[backfillPrices] failed to backfill prices")
+		return err
+	}
+
+	fmt.Println("Tickers:", result.Tickers)
+	fmt.Println("Tickers without prices:", result.FailedTickers)
+	fmt.Println("Prices stored:", result.Prices)
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"api/backtest"
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/models"
+	"api/services"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var backtestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Simulate a strategy over the recommendations and the historical prices",
+	Long: `Run backtest --type action --action upgraded --brokerage "<name>" --hold 20 to buy on the upgrades of a brokerage and hold 20 trading days,
+or backtest --type sentiment --window 5 --hold 20 to buy when the sentiment of the last 5 ratings of a ticker turns positive`,
+	RunE: runBacktest,
+}
+
+var (
+	backtestType      string
+	backtestAction    string
+	backtestBrokerage string
+	backtestWindow    int
+	backtestHoldDays  int
+	backtestFrom      string
+	backtestTo        string
+	backtestTickers   string
+)
+
+func init() {
+	backtestCmd.Flags().StringVar(&backtestType, "type", backtest.ActionStrategy, "Strategy: action or sentiment")
+	backtestCmd.Flags().StringVar(&backtestAction, "action", "", "Action of the recommendations of the action strategy, example upgraded")
+	backtestCmd.Flags().StringVar(&backtestBrokerage, "brokerage", "", "Brokerage of the recommendations of the action strategy, empty for all")
+	backtestCmd.Flags().IntVar(&backtestWindow, "window", backtest.DefaultWindow, "Latest ratings used to calculate the sentiment")
+	backtestCmd.Flags().IntVar(&backtestHoldDays, "hold", backtest.DefaultHoldDays, "Trading days to hold each trade")
+	backtestCmd.Flags().StringVar(&backtestFrom, "from", "", fmt.Sprintf("First date of the signals (YYYY-MM-DD), empty for %d days before the last date", services.MaxBacktestDays))
+	backtestCmd.Flags().StringVar(&backtestTo, "to", "", "Last date of the signals (YYYY-MM-DD)")
+	backtestCmd.Flags().StringVar(&backtestTickers, "tickers", "", "Comma separated tickers, empty for all")
+}
+
+// runBacktest runs the strategy of the flags and prints the metrics and the trades
+func runBacktest(cmd *cobra.Command, args []string) error {
+	from, to, err := parseBacktestRange()
+	if err != nil {
+		return err
+	}
+
+	var tickers []string
+	if backtestTickers != "" {
+		tickers = strings.Split(backtestTickers, ",")
+	}
+
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[backtest] failed to get database instance")
+		return err
+	}
+
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	backtestService := services.NewBacktestService(db.DB, tickerService)
+
+	strategy := backtest.Strategy{
+		Type:      backtestType,
+		Action:    models.Action(backtestAction),
+		Brokerage: backtestBrokerage,
+		Window:    backtestWindow,
+		HoldDays:  backtestHoldDays,
+	}
+
+	result, err := backtestService.Run(context.Background(), strategy, from, to, tickers)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[backtest] failed to run backtest")
+		return err
+	}
+
+	for _, trade := range result.Trades {
+		fmt.Printf("%s\t%s\t%s %.2f\t%s %.2f\t%.2f%%\t%s\n", trade.Ticker, trade.SignalDate, trade.EntryDate, trade.EntryPrice, trade.ExitDate, trade.ExitPrice, trade.Return*100, trade.Brokerage)
+	}
+
+	fmt.Println("Signals:", result.Signals)
+	fmt.Println("Trades:", len(result.Trades))
+	fmt.Println("Skipped:", result.Skipped)
+	fmt.Printf("Total return: %.2f%%\n", result.TotalReturn*100)
+	fmt.Printf("CAGR: %.2f%%\n", result.CAGR*100)
+	fmt.Printf("Max drawdown: %.2f%%\n", result.MaxDrawdown*100)
+	fmt.Printf("Sharpe: %.2f\n", result.Sharpe)
+	fmt.Printf("Hit rate: %.2f%%\n", result.HitRate*100)
+	fmt.Printf("Average return: %.2f%%\n", result.AverageReturn*100)
+	return nil
+}
+
+// parseBacktestRange parses the dates of the flags, empty dates are zero times
+func parseBacktestRange() (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if backtestFrom != "" {
+		if from, err = time.Parse("2006-01-02", backtestFrom); err != nil {
+			return from, to, fmt.Errorf("invalid from date format: the format must be YYYY-MM-DD")
+		}
+	}
+
+	if backtestTo != "" {
+		if to, err = time.Parse("2006-01-02", backtestTo); err != nil {
+			return from, to, fmt.Errorf("invalid to date format: the format must be YYYY-MM-DD")
+		}
+	}
+
+	return from, to, nil
+}
@@ -24,10 +24,21 @@ func init() {
 	rootCmd.AddCommand(fillDbCmd)
 	fillDbCmd.Flags().StringVar(&jsonPath, "json", "", "Path to the JSON file (optional)")
 
+	rootCmd.AddCommand(syncRatingsCmd)
+	rootCmd.AddCommand(apiKeysCmd)
+	rootCmd.AddCommand(scorePredictionsCmd)
+	rootCmd.AddCommand(backtestCmd)
+	rootCmd.AddCommand(refreshBrokeragesCmd)
+	rootCmd.AddCommand(evaluateAlertsCmd)
+	rootCmd.AddCommand(refreshSnapshotsCmd)
+	rootCmd.AddCommand(backfillPricesCmd)
+
 }
 
 // Execute runs the command
 // fill-db: fills the database with initial data
+// sync-ratings: inserts the new recommendations since the last sync
+// api-keys: creates, revokes and lists the api keys
 func (c Cmd) Execute() error {
 	if len(os.Args) > 1 {
 		err := rootCmd.Execute()
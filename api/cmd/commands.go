@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"api/config"
 	"api/database"
 	apilogger "api/logger"
 	"api/models"
@@ -39,11 +40,11 @@ func fillDb(cmd *cobra.Command, args []string) error {
 	}
 
 	// insert tickers and brokerages
-	tickerService := services.NewTickerService(db.DB, nil)
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
 
 	// clean and prepare the entities for insertion
 	fmt.Println("Clean data")
-	tickers, brokerages := cleanAndPrepareEntities(stockRecommendations)
+	tickers, brokerages := services.PrepareEntities(stockRecommendations)
 
 	// insert tickers and brokerages
 	fmt.Println("Insert tickers")
@@ -60,8 +61,8 @@ func fillDb(cmd *cobra.Command, args []string) error {
 	}
 
 	// create the map of brokerages with ids
-	brokeragesWithIdsMap := createBrokeragesWithIdsMap(brokerages)
-	recommendations := createRecommendations(stockRecommendations, brokeragesWithIdsMap)
+	brokeragesWithIdsMap := services.BrokerageIDsByName(brokerages)
+	recommendations := services.BuildRecommendations(stockRecommendations, brokeragesWithIdsMap)
 
 	fmt.Println("Insert recommendations")
 	_, err = tickerService.InsertRecommendations(context.Background(), recommendations, 1000)
@@ -75,41 +76,6 @@ func fillDb(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// cleanAndPrepareEntities cleans and prepares the entities for insertion and remove the brokerages with empty name
-func cleanAndPrepareEntities(stockRecommendations []models.StockRecommendation) ([]models.Ticker, []models.Brokerage) {
-	var brokerages []models.Brokerage = make([]models.Brokerage, 0)
-	var tickers []models.Ticker = make([]models.Ticker, 0)
-	var brokeragesMap = make(map[string]bool)
-	var tickersMap = make(map[string]bool)
-
-	for _, recommendation := range stockRecommendations {
-		if recommendation.Ticker != "" {
-			if _, ok := tickersMap[recommendation.Ticker]; !ok {
-				ticker := models.Ticker{
-					ID:      models.TickerID(recommendation.Ticker),
-					Company: recommendation.Company,
-				}
-
-				tickersMap[recommendation.Ticker] = true
-				tickers = append(tickers, ticker)
-			}
-		}
-
-		if recommendation.Brokerage != "" {
-			if _, ok := brokeragesMap[recommendation.Brokerage]; !ok {
-				brokeragesMap[recommendation.Brokerage] = true
-
-				brokerage := models.Brokerage{
-					Name: recommendation.Brokerage,
-				}
-				brokerages = append(brokerages, brokerage)
-			}
-		}
-	}
-
-	return tickers, brokerages
-}
-
 func getRecommendationsData(cmd *cobra.Command, db *database.Database) ([]models.StockRecommendation, error) {
 	// get recommendations stock
 	analystRatingsService := services.NewAnalystRatingsService(db.DB)
@@ -118,15 +84,15 @@ func getRecommendationsData(cmd *cobra.Command, db *database.Database) ([]models
 	var stockRecommendations []models.StockRecommendation
 	var err error
 
-	var getStocksFunc func() ([]models.StockRecommendation, error) = analystRatingsService.GetAll
+	var getStocksFunc func(ctx context.Context) ([]models.StockRecommendation, error) = analystRatingsService.GetAll
 
 	if jsonPath != "" {
-		getStocksFunc = func() ([]models.StockRecommendation, error) {
+		getStocksFunc = func(ctx context.Context) ([]models.StockRecommendation, error) {
 			return getRecommendationsFromJson(jsonPath)
 		}
 	}
 
-	stockRecommendations, err = getStocksFunc()
+	stockRecommendations, err = getStocksFunc(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -148,41 +114,3 @@ func getRecommendationsFromJson(path string) ([]models.StockRecommendation, erro
 
 	return recommendations, nil
 }
-
-func createBrokeragesWithIdsMap(brokerages []models.Brokerage) map[string]uint {
-	var brokeragesMap = make(map[string]uint)
-	for _, brokerage := range brokerages {
-		if brokerage.Name != "" {
-			brokeragesMap[brokerage.Name] = brokerage.ID
-		}
-	}
-
-	return brokeragesMap
-}
-
-func createRecommendations(stockRecommendations []models.StockRecommendation, brokeragesWithIdsMap map[string]uint) []models.Recommendation {
-	var recommendations []models.Recommendation = make([]models.Recommendation, 0)
-	for _, recommendation := range stockRecommendations {
-
-		parsedTime, err := ParseTimeNanoToRFC3339(recommendation.Time)
-		if err != nil {
-			apilogger.Logger().Err(err).Msg("[createRecommendations] failed to parse time, recommendation: " + recommendation.Ticker)
-			continue
-		}
-
-		recommendation := models.Recommendation{
-			TickerID:    recommendation.Ticker,
-			BrokerageID: brokeragesWithIdsMap[recommendation.Brokerage],
-			TargetFrom:  recommendation.TargetFrom.CurrencyToFloat(),
-			TargetTo:    recommendation.TargetTo.CurrencyToFloat(),
-			Action:      recommendation.Action,
-			RatingFrom:  recommendation.RatingFrom,
-			RatingTo:    recommendation.RatingTo,
-			Time:        parsedTime,
-		}
-
-		recommendations = append(recommendations, recommendation)
-	}
-
-	return recommendations
-}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"api/alerts"
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var evaluateAlertsCmd = &cobra.Command{
+	Use:   "evaluate-alerts",
+	Short: "Evaluate the alerts and deliver the notifications of the alerts that fire",
+	Long:  `Run evaluate-alerts to check the enabled alerts with the current price, sentiment and recommendations of their tickers, the firings are recorded in /api/v1/alerts/{id}/events`,
+	RunE:  evaluateAlerts,
+}
+
+// evaluateAlerts evaluates the enabled alerts once
+func evaluateAlerts(cmd *cobra.Command, args []string) error {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[evaluateAlerts] failed to get database instance")
+		return err
+	}
+
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	alertService := services.NewAlertService(db.DB, tickerService, alerts.DefaultSinks())
+
+	fmt.Println("Start evaluate-alerts")
+	result, err := alertService.Evaluate(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[evaluateAlerts] failed to evaluate alerts")
+		return err
+	}
+
+	fmt.Println("Alerts evaluated:", result.Rules)
+	fmt.Println("Alerts fired:", result.Fired)
+	fmt.Println("Failed deliveries:", result.Failed)
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var refreshBrokeragesCmd = &cobra.Command{
+	Use:   "refresh-brokerages",
+	Short: "Recalculate the accuracy of the calls of each brokerage",
+	Long:  `Run refresh-brokerages to compare the calls of each brokerage with the real prices after them, the leaderboard is exposed in /api/v1/brokerages`,
+	RunE:  refreshBrokerages,
+}
+
+// refreshBrokerages recalculates the stats of all the brokerages
+func refreshBrokerages(cmd *cobra.Command, args []string) error {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[refreshBrokerages] failed to get database instance")
+		return err
+	}
+
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	brokerageService := services.NewBrokerageService(db.DB, tickerService)
+
+	fmt.Println("Start refresh-brokerages")
+	result, err := brokerageService.RefreshStats(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[refreshBrokerages] failed to refresh brokerage stats")
+		return err
+	}
+
+	fmt.Println("Brokerages updated:", result.Brokerages)
+	fmt.Println("Tickers evaluated:", result.Tickers)
+	fmt.Println("Tickers without prices:", result.FailedTickers)
+	return nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var refreshSnapshotsCmd = &cobra.Command{
+	Use:   "refresh-snapshots",
+	Short: "Save the current company data of each ticker for the screener",
+	Long:  `Run refresh-snapshots to copy the sector, industry, country, market cap, beta, price and volume of each ticker from the financial API, the snapshots are filtered in /api/v1/screener`,
+	RunE:  refreshSnapshots,
+}
+
+// refreshSnapshots saves the company data of all the tickers
+func refreshSnapshots(cmd *cobra.Command, args []string) error {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[refreshSnapshots] failed to get database instance")
+		return err
+	}
+
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	screenerService := services.NewScreenerService(db.DB, tickerService)
+
+	fmt.Println("Start refresh-snapshots")
+	result, err := screenerService.RefreshSnapshots(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[refreshSnapshots] failed to refresh company snapshots")
+		return err
+	}
+
+	fmt.Println("Tickers:", result.Tickers)
+	fmt.Println("Tickers without company data:", result.FailedTickers)
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var scorePredictionsCmd = &cobra.Command{
+	Use:   "score-predictions",
+	Short: "Join the saved predictions with the real prices of the dates that passed",
+	Long:  `Run score-predictions to fetch the real close of the predicted dates that already passed, the accuracy is exposed in /api/v1/tickers/{id}/predictions/accuracy`,
+	RunE:  scorePredictions,
+}
+
+// scorePredictions scores the pending predictions of all the tickers
+func scorePredictions(cmd *cobra.Command, args []string) error {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[scorePredictions] failed to get database instance")
+		return err
+	}
+
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	predictionService := services.NewPredictionService(db.DB, tickerService)
+
+	fmt.Println("Start score-predictions")
+	result, err := predictionService.ScorePredictions(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[scorePredictions] failed to score predictions")
+		return err
+	}
+
+	fmt.Println("Prediction sets pending:", result.Sets)
+	fmt.Println("Predicted days pending:", result.Points)
+	fmt.Println("Predicted days scored:", result.Scored)
+	return nil
+}
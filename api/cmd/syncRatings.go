@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"api/cache"
+	"api/config"
+	"api/database"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var syncRatingsCmd = &cobra.Command{
+	Use:   "sync-ratings",
+	Short: "Insert only the new recommendations from the Stock API",
+	Long:  `Run sync-ratings to fetch the pages of the Stock API until it reaches the recommendations already ingested, the progress is saved as a checkpoint in the database`,
+	RunE:  syncRatings,
+}
+
+// syncRatings inserts the recommendations newer than the last checkpoint
+// run after the database is filled with fill-db or in a clean database
+func syncRatings(cmd *cobra.Command, args []string) error {
+	db, err := database.GetDB()
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[syncRatings] failed to get database instance")
+		return err
+	}
+
+	analystRatingsService := services.NewAnalystRatingsService(db.DB)
+	tickerService := services.NewTickerService(db.DB, nil, services.NewProviderRegistry(db.DB, nil, config.Providers()))
+	syncService := services.NewRatingsSyncService(db.DB, &analystRatingsService, tickerService)
+
+	// the new recommendations are published to the live stream when redis is available
+	if redis, err := cache.NewReddis(); err == nil {
+		defer redis.Close()
+		syncService.WithPubSub(redis)
+	} else {
+		apilogger.Logger().Warn().Err(err).Msg("[syncRatings] redis unavailable, the new recommendations are not published to the stream")
+	}
+
+	fmt.Println("Start sync-ratings")
+	result, err := syncService.Sync(context.Background())
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[syncRatings] failed to sync recommendations")
+		return err
+	}
+
+	apilogger.Logger().Info().Msg(fmt.Sprintf("Ratings synced: inserted %d, skipped %d", result.Inserted, result.Skipped))
+	if !result.Since.IsZero() {
+		fmt.Println("Resumed from", result.Since.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Println("Pages read:", result.Pages)
+	fmt.Println("Recommendations fetched:", result.Fetched)
+	fmt.Println("Recommendations inserted:", result.Inserted)
+	fmt.Println("Recommendations skipped:", result.Skipped)
+	fmt.Println("Last recommendation:", result.LastTime.Format("2006-01-02 15:04:05"))
+	return nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+type AlertsConfig struct {
+	EvaluateInterval time.Duration
+	WebhookTimeout   time.Duration
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+}
+
+var alertsConfig *AlertsConfig
+
+// Alerts returns the configuration of the alerts
+// EvaluateInterval 0 disables the background evaluator,
+// the emails are disabled without SMTPHost
+func Alerts() *AlertsConfig {
+	if alertsConfig == nil {
+		alertsConfig = &AlertsConfig{
+			EvaluateInterval: getDurationWithDefault("ALERTS_EVALUATE_INTERVAL", 0),
+			WebhookTimeout:   getDurationWithDefault("ALERTS_WEBHOOK_TIMEOUT", 10*time.Second),
+			SMTPHost:         getEnvWithDefault("SMTP_HOST", ""),
+			SMTPPort:         getEnvWithDefault("SMTP_PORT", "587"),
+			SMTPUsername:     getEnvWithDefault("SMTP_USERNAME", ""),
+			SMTPPassword:     getEnvWithDefault("SMTP_PASSWORD", ""),
+			SMTPFrom:         getEnvWithDefault("SMTP_FROM", ""),
+		}
+	}
+
+	return alertsConfig
+}
@@ -0,0 +1,23 @@
+package config
+
+import "strings"
+
+type AuthConfig struct {
+	Enabled   bool
+	JWTSecret string
+}
+
+var authConfig *AuthConfig
+
+// Auth returns the authentication configuration, it is enabled by default
+// when is disabled the credentials are optional, but if sent they are validated
+func Auth() *AuthConfig {
+	if authConfig == nil {
+		authConfig = &AuthConfig{
+			Enabled:   strings.ToLower(getEnvWithDefault("AUTH_ENABLED", "true")) != "false",
+			JWTSecret: getEnvWithDefault("JWT_SECRET", ""),
+		}
+	}
+
+	return authConfig
+}
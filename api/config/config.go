@@ -5,8 +5,10 @@ package config
 import (
 	apilogger "api/logger"
 	"os"
+	"strconv"
 
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -30,3 +32,49 @@ func getEnvWithDefault(key string, defaultValue string) string {
 	}
 	return value
 }
+
+// getDurationWithDefault gets an environment variable as duration (example 30s, 5m, 6h) or returns a default value
+// if the value is not a valid duration returns the default value
+func getDurationWithDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		apilogger.Logger().Warn().Msg("invalid duration for " + key + ", using default value")
+		return defaultValue
+	}
+
+	return duration
+}
+
+// getIntWithDefault gets an environment variable as a positive integer or returns a default value
+// if the value is not a valid positive integer returns the default value
+func getIntWithDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		apilogger.Logger().Warn().Msg("invalid number for " + key + ", using default value")
+		return defaultValue
+	}
+
+	return number
+}
+
+// getListWithDefault returns the comma separated values of the env var in lower case
+func getListWithDefault(key string, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnvWithDefault(key, defaultValue), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
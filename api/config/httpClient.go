@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+type HTTPClientConfig struct {
+	Timeout          time.Duration
+	MaxAttempts      int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	MaxConcurrent    int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+var httpClientConfig *HTTPClientConfig
+
+// HTTPClient returns the configuration of the requests to the external APIs
+// MaxAttempts 1 disables the retries of the 429 and 5xx responses, the delays grow exponentially until RetryMaxDelay,
+// MaxConcurrent is the limit of requests in flight per host and the circuit of a host opens
+// after BreakerThreshold consecutive failures for BreakerCooldown
+func HTTPClient() *HTTPClientConfig {
+	if httpClientConfig == nil {
+		httpClientConfig = &HTTPClientConfig{
+			Timeout:          getDurationWithDefault("HTTP_CLIENT_TIMEOUT", 60*time.Second),
+			MaxAttempts:      getIntWithDefault("HTTP_CLIENT_MAX_ATTEMPTS", 3),
+			RetryBaseDelay:   getDurationWithDefault("HTTP_CLIENT_RETRY_BASE_DELAY", 500*time.Millisecond),
+			RetryMaxDelay:    getDurationWithDefault("HTTP_CLIENT_RETRY_MAX_DELAY", 10*time.Second),
+			MaxConcurrent:    getIntWithDefault("HTTP_CLIENT_MAX_CONCURRENT", 10),
+			BreakerThreshold: getIntWithDefault("HTTP_CLIENT_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getDurationWithDefault("HTTP_CLIENT_BREAKER_COOLDOWN", 30*time.Second),
+		}
+	}
+
+	return httpClientConfig
+}
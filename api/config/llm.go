@@ -0,0 +1,28 @@
+package config
+
+import "strings"
+
+// LLMConfig provider of the advice and the predictions: gemini, openai or fake
+// BaseURL and APIKey are of the openai provider, any OpenAI-compatible server like llama.cpp or Ollama,
+// the key of gemini is GEMINI_API_KEY. Model empty uses the default model of the provider
+type LLMConfig struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	APIKey   string
+}
+
+var llmConfig *LLMConfig
+
+func LLM() *LLMConfig {
+	if llmConfig == nil {
+		llmConfig = &LLMConfig{
+			Provider: strings.ToLower(getEnvWithDefault("LLM_PROVIDER", "gemini")),
+			Model:    getEnvWithDefault("LLM_MODEL", ""),
+			BaseURL:  getEnvWithDefault("LLM_BASE_URL", "http://localhost:11434/v1"),
+			APIKey:   getEnvWithDefault("LLM_API_KEY", ""),
+		}
+	}
+
+	return llmConfig
+}
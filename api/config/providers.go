@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type ProvidersConfig struct {
+	HistoricalPrices []string
+	CompanyData      []string
+	Logos            []string
+	News             []string
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+var providersConfig *ProvidersConfig
+
+// Providers returns the configuration of the market data providers
+// each list is the order of the providers of a service: fmp, finnhub or db (the data stored in the database),
+// a provider is skipped during Cooldown after FailureThreshold consecutive failures
+func Providers() *ProvidersConfig {
+	if providersConfig == nil {
+		providersConfig = &ProvidersConfig{
+			HistoricalPrices: getListWithDefault("PROVIDERS_HISTORICAL_PRICES", "fmp,finnhub,db"),
+			CompanyData:      getListWithDefault("PROVIDERS_COMPANY_DATA", "fmp,finnhub,db"),
+			Logos:            getListWithDefault("PROVIDERS_LOGOS", "fmp,finnhub"),
+			News:             getListWithDefault("PROVIDERS_NEWS", "finnhub"),
+			FailureThreshold: getIntWithDefault("PROVIDERS_FAILURE_THRESHOLD", 3),
+			Cooldown:         getDurationWithDefault("PROVIDERS_COOLDOWN", time.Minute),
+		}
+	}
+
+	return providersConfig
+}
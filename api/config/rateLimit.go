@@ -0,0 +1,25 @@
+package config
+
+import "strings"
+
+// RateLimitConfig limits of the route groups with the format <requests>/<period>, example 10/1m
+// Default applies to all the api routes, AI to the routes that call gemini
+type RateLimitConfig struct {
+	Enabled bool
+	Default string
+	AI      string
+}
+
+var rateLimitConfig *RateLimitConfig
+
+func RateLimit() *RateLimitConfig {
+	if rateLimitConfig == nil {
+		rateLimitConfig = &RateLimitConfig{
+			Enabled: strings.ToLower(getEnvWithDefault("RATE_LIMIT_ENABLED", "true")) == "true",
+			Default: getEnvWithDefault("RATE_LIMIT_DEFAULT", "120/1m"),
+			AI:      getEnvWithDefault("RATE_LIMIT_AI", "10/1m"),
+		}
+	}
+
+	return rateLimitConfig
+}
@@ -0,0 +1,22 @@
+package config
+
+type RiskConfig struct {
+	Benchmark    string
+	LookbackDays int
+}
+
+var riskConfig *RiskConfig
+
+// Risk returns the configuration of the risk of the portfolios
+// Benchmark is the ticker used for the beta, the requests can use other benchmark
+// LookbackDays is the number of calendar days of prices used to calculate the returns
+func Risk() *RiskConfig {
+	if riskConfig == nil {
+		riskConfig = &RiskConfig{
+			Benchmark:    getEnvWithDefault("RISK_BENCHMARK", "SPY"),
+			LookbackDays: getIntWithDefault("RISK_LOOKBACK_DAYS", 365),
+		}
+	}
+
+	return riskConfig
+}
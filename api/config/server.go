@@ -2,12 +2,17 @@ package config
 
 import (
 	"os"
+	"time"
 )
 
 type ServerConfig struct {
-	Port       string
-	ClientHost string
-	Env        string
+	Port            string
+	ClientHost      string
+	Env             string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
 }
 
 var serverConfig *ServerConfig
@@ -24,6 +29,11 @@ func Server() *ServerConfig {
 			Port:       getEnvWithDefault("API_PORT", "8080"),
 			ClientHost: clientHost,
 			Env:        getEnvWithDefault("ENV", "development"),
+			// write timeout must be greater than the timeout of the slow requests (gemini predictions)
+			ReadTimeout:     getDurationWithDefault("SERVER_READ_TIMEOUT", 15*time.Second),
+			WriteTimeout:    getDurationWithDefault("SERVER_WRITE_TIMEOUT", 120*time.Second),
+			IdleTimeout:     getDurationWithDefault("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout: getDurationWithDefault("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 		}
 	}
 
@@ -0,0 +1,30 @@
+package config
+
+import "time"
+
+type StreamConfig struct {
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	MaxTickers        int
+	BufferSize        int
+}
+
+var streamConfig *StreamConfig
+
+// Stream returns the configuration of the live updates of the tickers
+// PollInterval is the interval of the price polling of each subscribed ticker, shared by all the clients
+// HeartbeatInterval is the interval of the heartbeats of the stream and the pings of the websockets
+// MaxTickers is the max tickers of a stream or a websocket connection
+// BufferSize is the number of events buffered per client, the clients that fall behind are disconnected
+func Stream() *StreamConfig {
+	if streamConfig == nil {
+		streamConfig = &StreamConfig{
+			PollInterval:      getDurationWithDefault("STREAM_POLL_INTERVAL", 15*time.Second),
+			HeartbeatInterval: getDurationWithDefault("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+			MaxTickers:        getIntWithDefault("STREAM_MAX_TICKERS", 20),
+			BufferSize:        getIntWithDefault("STREAM_BUFFER_SIZE", 64),
+		}
+	}
+
+	return streamConfig
+}
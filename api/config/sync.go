@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// minLockTTL is the shortest lease of the sync lock, the lease is renewed every third of the ttl
+// and it must outlast the requests and the writes of a sync run between two renewals
+const minLockTTL = time.Minute
+
+type SyncConfig struct {
+	RatingsInterval          time.Duration
+	LockTTL                  time.Duration
+	PredictionsScoreInterval time.Duration
+	BrokerageStatsInterval   time.Duration
+	SnapshotsInterval        time.Duration
+}
+
+var syncConfig *SyncConfig
+
+// Sync returns the configuration of the background ingestion
+// RatingsInterval 0 disables the ratings scheduler
+// PredictionsScoreInterval 0 disables the scoring of the predictions
+// BrokerageStatsInterval 0 disables the refresh of the brokerage stats
+// SnapshotsInterval 0 disables the refresh of the company snapshots of the screener
+// the server does not start with a negative interval or a lock ttl shorter than minLockTTL
+func Sync() *SyncConfig {
+	if syncConfig == nil {
+		syncConfig = &SyncConfig{
+			RatingsInterval:          getDurationWithDefault("RATINGS_SYNC_INTERVAL", 0),
+			LockTTL:                  getDurationWithDefault("RATINGS_SYNC_LOCK_TTL", 10*time.Minute),
+			PredictionsScoreInterval: getDurationWithDefault("PREDICTIONS_SCORE_INTERVAL", 0),
+			BrokerageStatsInterval:   getDurationWithDefault("BROKERAGE_STATS_INTERVAL", 0),
+			SnapshotsInterval:        getDurationWithDefault("COMPANY_SNAPSHOTS_INTERVAL", 0),
+		}
+
+		if err := syncConfig.validate(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return syncConfig
+}
+
+// validate checks the intervals are not negative and the lock ttl is at least minLockTTL
+func (c *SyncConfig) validate() error {
+	intervals := map[string]time.Duration{
+		"RATINGS_SYNC_INTERVAL":      c.RatingsInterval,
+		"PREDICTIONS_SCORE_INTERVAL": c.PredictionsScoreInterval,
+		"BROKERAGE_STATS_INTERVAL":   c.BrokerageStatsInterval,
+		"COMPANY_SNAPSHOTS_INTERVAL": c.SnapshotsInterval,
+	}
+
+	for key, interval := range intervals {
+		if interval < 0 {
+			return fmt.Errorf("invalid %s: %s, use 0 to disable it", key, interval)
+		}
+	}
+
+	if c.LockTTL < minLockTTL {
+		return fmt.Errorf("invalid RATINGS_SYNC_LOCK_TTL: %s, the lease must be at least %s", c.LockTTL, minLockTTL)
+	}
+
+	return nil
+}
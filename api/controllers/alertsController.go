@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	apilogger "api/logger"
+	"api/models"
+	"api/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertsController handles the alerts of the users
+type AlertsController struct {
+	alertService services.AlertService
+}
+
+// NewAlertsController creates a new AlertsController
+func NewAlertsController(alertService services.AlertService) *AlertsController {
+	return &AlertsController{
+		alertService: alertService,
+	}
+}
+
+// ListAlerts retrieves the alerts of the user
+func (c *AlertsController) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	rules, err := c.alertService.GetAlerts(ctxCancel, requestOwner(r))
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[ListAlerts] Failed to retrieve alerts")
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve alerts")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": rules,
+	})
+}
+
+// GetAlert retrieves an alert of the user
+// Path param: id (int)
+func (c *AlertsController) GetAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	rule, err := c.alertService.GetAlert(ctxCancel, requestOwner(r), id)
+	if err != nil {
+		respondAlertError(w, err, "[GetAlert] Failed to retrieve alert")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": rule,
+	})
+}
+
+// CreateAlert creates an alert for the user
+// Body: { "tickerId": string, "type": "price_above", "threshold": float, "channel": "webhook", "target": string }
+// Body: { "tickerId": string, "type": "sentiment", "sentiment": "negative", "channel": "email", "target": string }
+// Body: { "tickerId": string, "type": "recommendation", "action": "downgraded", "channel": "webhook", "target": string }
+func (c *AlertsController) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	var body models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	rule, err := c.alertService.CreateAlert(ctxCancel, requestOwner(r), body)
+	if err != nil {
+		respondAlertError(w, err, "[CreateAlert] Failed to create alert")
+		return
+	}
+
+	// the secret of the webhooks is not returned again
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data":   rule,
+		"secret": rule.Secret,
+	})
+}
+
+// UpdateAlert updates an alert of the user, the fields not sent are not changed
+// Path param: id (int)
+// Body: { "threshold": float, "sentiment": string, "action": string, "channel": string, "target": string, "enabled": bool }
+func (c *AlertsController) UpdateAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var body services.AlertUpdate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	rule, err := c.alertService.UpdateAlert(ctxCancel, requestOwner(r), id, body)
+	if err != nil {
+		respondAlertError(w, err, "[UpdateAlert] Failed to update alert")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": rule,
+	})
+}
+
+// DeleteAlert deletes an alert of the user and its history
+// Path param: id (int)
+func (c *AlertsController) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	if err := c.alertService.DeleteAlert(ctxCancel, requestOwner(r), id); err != nil {
+		respondAlertError(w, err, "[DeleteAlert] Failed to delete alert")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAlertEvents retrieves the history of the firings of an alert of the user, the newest first
+// Path param: id (int)
+// Query params: page (int), size (int)
+func (c *AlertsController) ListAlertEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := parseFilters(r)
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	events, total, err := c.alertService.GetAlertEvents(ctxCancel, requestOwner(r), id, filter)
+	if err != nil {
+		respondAlertError(w, err, "[ListAlertEvents] Failed to retrieve alert events")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  events,
+		"total": total,
+	})
+}
+
+// respondAlertError maps the errors of the alert service to the http status
+func respondAlertError(w http.ResponseWriter, err error, logMessage string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		respondError(w, http.StatusNotFound, "Alert not found")
+		return
+	}
+
+	if errors.Is(err, services.ErrInvalidAlert) || errors.Is(err, services.ErrUnknownTickers) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apilogger.Logger().Error().Err(err).Msg(logMessage)
+	respondError(w, http.StatusInternalServerError, "Failed to process the alert")
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"api/backtest"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// BacktestsController runs the backtests of the recommendation strategies
+type BacktestsController struct {
+	backtestService services.BacktestService
+}
+
+// backtestRequest body to run a backtest
+// from and to are dates with the format YYYY-MM-DD, without from the range starts MaxBacktestDays before to, tickers is optional
+type backtestRequest struct {
+	Strategy backtest.Strategy `json:"strategy"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Tickers  []string          `json:"tickers"`
+}
+
+// NewBacktestsController creates a new BacktestsController
+func NewBacktestsController(backtestService services.BacktestService) *BacktestsController {
+	return &BacktestsController{
+		backtestService: backtestService,
+	}
+}
+
+// RunBacktest simulates a strategy over the recommendations and the historical prices
+// Body: { "strategy": { "type": "action", "action": "upgraded", "brokerage": string, "holdDays": int }, "from": string, "to": string, "tickers": []string }
+// Body: { "strategy": { "type": "sentiment", "window": int, "holdDays": int }, "from": string, "to": string, "tickers": []string }
+func (c *BacktestsController) RunBacktest(w http.ResponseWriter, r *http.Request) {
+	var body backtestRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return
+	}
+
+	from, err := parseOptionalDate(body.From)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid from date format: the format must be YYYY-MM-DD")
+		return
+	}
+
+	to, err := parseOptionalDate(body.To)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid to date format: the format must be YYYY-MM-DD")
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	result, err := c.backtestService.Run(ctxCancel, body.Strategy, from, to, body.Tickers)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBacktest) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[RunBacktest] Failed to run backtest")
+		respondError(w, http.StatusInternalServerError, "Failed to run the backtest")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": result,
+	})
+}
+
+// parseOptionalDate parses a date with the format YYYY-MM-DD, empty is the zero time
+func parseOptionalDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BrokeragesController handles the brokerages and the accuracy of their calls
+type BrokeragesController struct {
+	brokerageService services.BrokerageService
+}
+
+// NewBrokeragesController creates a new BrokeragesController
+func NewBrokeragesController(brokerageService services.BrokerageService) *BrokeragesController {
+	return &BrokeragesController{
+		brokerageService: brokerageService,
+	}
+}
+
+// ListBrokerages retrieves the leaderboard of brokerages with the stats of their calls
+// Query params: page (int), size (int), sort (asc/desc), q (name), orderBy (name, calls, upgrades, upgradeHitRate30, upgradeHitRate90, targetError, lastCallAt)
+func (c *BrokeragesController) ListBrokerages(w http.ResponseWriter, r *http.Request) {
+	filter := parseFilters(r)
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	brokerages, total, err := c.brokerageService.GetBrokerages(ctxCancel, filter)
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[ListBrokerages] Failed to retrieve brokerages")
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve brokerages")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  brokerages,
+		"total": total,
+	})
+}
+
+// GetBrokerage retrieves a brokerage with the stats of its calls
+// Path param: id (int)
+func (c *BrokeragesController) GetBrokerage(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	brokerage, err := c.brokerageService.GetBrokerage(ctxCancel, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Brokerage not found")
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[GetBrokerage] Failed to retrieve brokerage with ID:" + strconv.FormatUint(uint64(id), 10))
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve brokerage")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": brokerage,
+	})
+}
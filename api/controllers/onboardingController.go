@@ -3,11 +3,15 @@ package controllers
 import (
 	apilogger "api/logger"
 	"api/models"
+	"api/models/responses"
 	"api/services"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // controller for onboarding
@@ -15,18 +19,26 @@ type OnboardingController struct {
 	onboardingService services.OnboardingService
 }
 
+// tourRequest body to update the progress of a tour
+type tourRequest struct {
+	Step int  `json:"step"`
+	Done bool `json:"done"`
+}
+
 func NewOnboardingController(onboardingService services.OnboardingService) *OnboardingController {
 	return &OnboardingController{
 		onboardingService: onboardingService,
 	}
 }
 
+// GetOnboarding retrieves the overview tour of the client
+// with the format of the first version of the api
 func (c *OnboardingController) GetOnboarding(w http.ResponseWriter, r *http.Request) {
 
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancelManual()
 
-	onboarding, err := c.onboardingService.GetOnboarding(ctxCancel)
+	onboarding, err := c.onboardingService.GetOnboarding(ctxCancel, requestOwner(r), models.OverviewTour)
 	if err != nil {
 		apilogger.Logger().Error().Err(err)
 		respondError(w, http.StatusInternalServerError, "Failed to retrieve onboarding")
@@ -34,15 +46,17 @@ func (c *OnboardingController) GetOnboarding(w http.ResponseWriter, r *http.Requ
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"data": onboarding,
+		"data": responses.NewOverviewOnboarding(*onboarding),
 	})
 }
 
+// UpdateOnboarding updates the overview tour of the client
+// with the format of the first version of the api
 func (c *OnboardingController) UpdateOnboarding(w http.ResponseWriter, r *http.Request) {
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancelManual()
 
-	var onboarding models.Onboarding
+	var onboarding responses.OverviewOnboarding
 	err := json.NewDecoder(r.Body).Decode(&onboarding)
 	if err != nil {
 		apilogger.Logger().Error().Err(err)
@@ -50,7 +64,7 @@ func (c *OnboardingController) UpdateOnboarding(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	updatedOnboarding, err := c.onboardingService.UpdateOnboarding(ctxCancel, onboarding.OverviewStep, onboarding.OverviewDone)
+	updatedOnboarding, err := c.onboardingService.UpdateOnboarding(ctxCancel, requestOwner(r), models.OverviewTour, onboarding.OverviewStep, onboarding.OverviewDone)
 	if err != nil {
 		apilogger.Logger().Error().Err(err)
 		respondError(w, http.StatusInternalServerError, "Failed to update onboarding")
@@ -58,6 +72,92 @@ func (c *OnboardingController) UpdateOnboarding(w http.ResponseWriter, r *http.R
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"data": updatedOnboarding,
+		"data": responses.NewOverviewOnboarding(*updatedOnboarding),
+	})
+}
+
+// ListTours retrieves the progress of all the tours started by the client
+func (c *OnboardingController) ListTours(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	onboardings, err := c.onboardingService.GetOnboardings(ctxCancel, requestOwner(r))
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[ListTours] Failed to retrieve tours")
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve onboarding")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": onboardings,
 	})
 }
+
+// GetTour retrieves the progress of a tour of the client
+// Path param: tour (string)
+func (c *OnboardingController) GetTour(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	onboarding, err := c.onboardingService.GetOnboarding(ctxCancel, requestOwner(r), chi.URLParam(r, "tour"))
+	if err != nil {
+		respondOnboardingError(w, err, "[GetTour] Failed to retrieve tour")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": onboarding,
+	})
+}
+
+// UpdateTour updates the progress of a tour of the client
+// Path param: tour (string)
+// Body: { "step": int, "done": bool }
+func (c *OnboardingController) UpdateTour(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	var body tourRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return
+	}
+
+	onboarding, err := c.onboardingService.UpdateOnboarding(ctxCancel, requestOwner(r), chi.URLParam(r, "tour"), body.Step, body.Done)
+	if err != nil {
+		respondOnboardingError(w, err, "[UpdateTour] Failed to update tour")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": onboarding,
+	})
+}
+
+// ResetTour restarts a tour of the client from the first step
+// Path param: tour (string)
+func (c *OnboardingController) ResetTour(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	onboarding, err := c.onboardingService.ResetOnboarding(ctxCancel, requestOwner(r), chi.URLParam(r, "tour"))
+	if err != nil {
+		respondOnboardingError(w, err, "[ResetTour] Failed to reset tour")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": onboarding,
+	})
+}
+
+// respondOnboardingError maps the errors of the onboarding service to the http status
+func respondOnboardingError(w http.ResponseWriter, err error, logMessage string) {
+	if errors.Is(err, services.ErrInvalidTour) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apilogger.Logger().Error().Err(err).Msg(logMessage)
+	respondError(w, http.StatusInternalServerError, "Failed to process the onboarding")
+}
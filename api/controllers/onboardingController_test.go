@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"api/auth"
+	"api/models"
+	"api/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Test_ResetTour(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:Test_ResetTour?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	assert.NoError(t, db.AutoMigrate(&models.Onboarding{}))
+
+	service := services.NewOnboardingService(db)
+	_, err = service.UpdateOnboarding(context.Background(), "analyst-1", "portfolio", 4, true)
+	assert.NoError(t, err)
+	_, err = service.UpdateOnboarding(context.Background(), "analyst-2", "portfolio", 3, true)
+	assert.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Post("/tours/{tour}/reset", NewOnboardingController(service).ResetTour)
+
+	testCases := []struct {
+		desc   string
+		tour   string
+		status int
+	}{
+		{desc: "started tour", tour: "portfolio", status: http.StatusOK},
+		{desc: "new tour", tour: "alerts", status: http.StatusOK},
+		{desc: "invalid tour", tour: "Portfolio!", status: http.StatusBadRequest},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/tours/"+tC.tour+"/reset", nil)
+			req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "analyst-1", Method: auth.MethodJWT}))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tC.status, rec.Code)
+			if tC.status != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data models.Onboarding `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "analyst-1", body.Data.ClientID)
+			assert.Equal(t, tC.tour, body.Data.Tour)
+			assert.Equal(t, 1, body.Data.Step)
+			assert.False(t, body.Data.Done)
+		})
+	}
+
+	// the tour of the other client is not reset
+	onboarding, err := service.GetOnboarding(context.Background(), "analyst-2", "portfolio")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, onboarding.Step)
+	assert.True(t, onboarding.Done)
+}
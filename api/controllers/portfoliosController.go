@@ -0,0 +1,329 @@
+package controllers
+
+import (
+	"api/config"
+	apilogger "api/logger"
+	"api/models"
+	"api/models/ratings"
+	"api/portfolio"
+	"api/sanatizer"
+	"api/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PortfoliosController handles the portfolios of the users
+type PortfoliosController struct {
+	portfolioService services.PortfolioService
+}
+
+// portfolioRequest body to create or rename a portfolio
+type portfolioRequest struct {
+	Name string `json:"name"`
+}
+
+// transactionRequest body to add a transaction, the date has the format YYYY-MM-DD
+type transactionRequest struct {
+	TickerID string                 `json:"tickerId"`
+	Type     models.TransactionType `json:"type"`
+	Quantity float64                `json:"quantity"`
+	Price    float64                `json:"price"`
+	Fees     float64                `json:"fees"`
+	Date     string                 `json:"date"`
+}
+
+// NewPortfoliosController creates a new PortfoliosController
+func NewPortfoliosController(portfolioService services.PortfolioService) *PortfoliosController {
+	return &PortfoliosController{
+		portfolioService: portfolioService,
+	}
+}
+
+// ListPortfolios retrieves the portfolios of the user
+func (c *PortfoliosController) ListPortfolios(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	portfolios, err := c.portfolioService.GetPortfolios(ctxCancel, requestOwner(r))
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[ListPortfolios] Failed to retrieve portfolios")
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve portfolios")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": portfolios,
+	})
+}
+
+// GetPortfolio retrieves a portfolio of the user with its transactions
+// Path param: id (int)
+func (c *PortfoliosController) GetPortfolio(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	portfolio, err := c.portfolioService.GetPortfolio(ctxCancel, requestOwner(r), id)
+	if err != nil {
+		respondPortfolioError(w, err, "[GetPortfolio] Failed to retrieve portfolio")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": portfolio,
+	})
+}
+
+// CreatePortfolio creates an empty portfolio for the user
+// Body: { "name": string }
+func (c *PortfoliosController) CreatePortfolio(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodePortfolioRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	portfolio, err := c.portfolioService.CreatePortfolio(ctxCancel, requestOwner(r), body.Name)
+	if err != nil {
+		respondPortfolioError(w, err, "[CreatePortfolio] Failed to create portfolio")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": portfolio,
+	})
+}
+
+// UpdatePortfolio renames a portfolio of the user
+// Path param: id (int)
+// Body: { "name": string }
+func (c *PortfoliosController) UpdatePortfolio(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, ok := decodePortfolioRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	portfolio, err := c.portfolioService.UpdatePortfolio(ctxCancel, requestOwner(r), id, body.Name)
+	if err != nil {
+		respondPortfolioError(w, err, "[UpdatePortfolio] Failed to update portfolio")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": portfolio,
+	})
+}
+
+// DeletePortfolio deletes a portfolio of the user and its transactions
+// Path param: id (int)
+func (c *PortfoliosController) DeletePortfolio(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	if err := c.portfolioService.DeletePortfolio(ctxCancel, requestOwner(r), id); err != nil {
+		respondPortfolioError(w, err, "[DeletePortfolio] Failed to delete portfolio")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPortfolioSummary values the positions of a portfolio of the user with the current prices
+// Path param: id (int)
+// Query params: sentiment (simple/weighted, default simple)
+func (c *PortfoliosController) GetPortfolioSummary(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mode, err := ratings.ParseSentimentMode(r.URL.Query().Get("sentiment"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	summary, err := c.portfolioService.GetSummary(ctxCancel, requestOwner(r), id, mode)
+	if err != nil {
+		respondPortfolioError(w, err, "[GetPortfolioSummary] Failed to calculate the summary")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": summary,
+	})
+}
+
+// GetPortfolioRisk calculates the volatility, beta, correlation and 1-day VaR of the open positions of a portfolio of the user
+// query params: benchmark (ticker, default RISK_BENCHMARK) and confidence (default 0.95)
+func (c *PortfoliosController) GetPortfolioRisk(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	benchmark, confidence, err := parseRiskParams(r, config.Risk().Benchmark)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	risk, err := c.portfolioService.GetRisk(ctxCancel, requestOwner(r), id, benchmark, confidence, config.Risk().LookbackDays)
+	if err != nil {
+		respondPortfolioError(w, err, "[GetPortfolioRisk] Failed to calculate the risk")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": risk,
+	})
+}
+
+// AddPortfolioTransaction adds a buy or a sell to a portfolio of the user
+// Path param: id (int)
+// Body: { "tickerId": string, "type": "buy" | "sell", "quantity": float, "price": float, "fees": float, "date": "YYYY-MM-DD" }
+func (c *PortfoliosController) AddPortfolioTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transaction, err := decodeTransactionRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	created, err := c.portfolioService.AddTransaction(ctxCancel, requestOwner(r), id, transaction)
+	if err != nil {
+		respondPortfolioError(w, err, "[AddPortfolioTransaction] Failed to add transaction")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": created,
+	})
+}
+
+// DeletePortfolioTransaction deletes a transaction of a portfolio of the user
+// Path params: id (int), transactionId (int)
+func (c *PortfoliosController) DeletePortfolioTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transactionID, err := parseIDParam(r, "transactionId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	if err := c.portfolioService.DeleteTransaction(ctxCancel, requestOwner(r), id, transactionID); err != nil {
+		respondPortfolioError(w, err, "[DeletePortfolioTransaction] Failed to delete transaction")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodePortfolioRequest decodes and validates the body with the name of the portfolio
+// responds bad request if the body is invalid
+func decodePortfolioRequest(w http.ResponseWriter, r *http.Request) (portfolioRequest, bool) {
+	var body portfolioRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return body, false
+	}
+
+	body.Name = sanatizer.SanatizerString(strings.TrimSpace(body.Name)).SanatizeHTML().String()
+	if body.Name == "" || len(body.Name) > 100 {
+		respondError(w, http.StatusBadRequest, "The name is required and must have at most 100 characters")
+		return body, false
+	}
+
+	return body, true
+}
+
+// decodeTransactionRequest decodes the body of a transaction, the fields are validated by the service
+func decodeTransactionRequest(r *http.Request) (models.Transaction, error) {
+	var body transactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return models.Transaction{}, errors.New("the body of the request is not valid")
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(body.Date))
+	if err != nil {
+		return models.Transaction{}, errors.New("invalid date format: the format must be YYYY-MM-DD")
+	}
+
+	return models.Transaction{
+		TickerID: body.TickerID,
+		Type:     body.Type,
+		Quantity: body.Quantity,
+		Price:    body.Price,
+		Fees:     body.Fees,
+		Date:     date,
+	}, nil
+}
+
+// respondPortfolioError maps the errors of the portfolio service to the http status
+func respondPortfolioError(w http.ResponseWriter, err error, logMessage string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		respondError(w, http.StatusNotFound, "Portfolio or transaction not found")
+		return
+	}
+
+	if errors.Is(err, services.ErrInvalidTransaction) || errors.Is(err, services.ErrUnknownTickers) ||
+		errors.Is(err, portfolio.ErrInsufficientData) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apilogger.Logger().Error().Err(err).Msg(logMessage)
+	respondError(w, http.StatusInternalServerError, "Failed to process the portfolio")
+}
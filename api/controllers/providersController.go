@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"api/services"
+	"net/http"
+)
+
+// ProvidersController reports the state of the market data providers
+type ProvidersController struct {
+	registry *services.ProviderRegistry
+}
+
+// NewProvidersController creates a new ProvidersController
+func NewProvidersController(registry *services.ProviderRegistry) *ProvidersController {
+	return &ProvidersController{
+		registry: registry,
+	}
+}
+
+// GetHealth retrieves the state of the providers of each service
+func (c *ProvidersController) GetHealth(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": c.registry.Health(),
+	})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	apilogger "api/logger"
+	"api/screener"
+	"api/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ScreenerController handles the screening of the tickers
+type ScreenerController struct {
+	screenerService services.ScreenerService
+}
+
+// NewScreenerController creates a new ScreenerController
+func NewScreenerController(screenerService services.ScreenerService) *ScreenerController {
+	return &ScreenerController{
+		screenerService: screenerService,
+	}
+}
+
+// Screen retrieves the tickers that meet the filter of the body, an empty body returns all the tickers
+// Query params: page (int), size (int), sort (asc/desc), q (ticker or company), orderBy (field of the screener), sentiment (simple/weighted)
+func (c *ScreenerController) Screen(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTickerFilters(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, err := decodeScreenerFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	rows, total, err := c.screenerService.Screen(ctxCancel, body, filter)
+	if err != nil {
+		if errors.Is(err, screener.ErrInvalidFilter) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[Screen] Failed to screen tickers")
+		respondError(w, http.StatusInternalServerError, "Failed to screen tickers")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  rows,
+		"total": total,
+	})
+}
+
+// decodeScreenerFilter decodes the filter of the body, the empty body is the empty filter
+func decodeScreenerFilter(r *http.Request) (screener.Filter, error) {
+	var filter screener.Filter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil && !errors.Is(err, io.EOF) {
+		return filter, errors.New("the body of the request is not valid")
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	apilogger "api/logger"
+	"api/services"
+	"api/stream"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamController sends the live updates of the tickers with server-sent events
+type StreamController struct {
+	hub               *stream.Hub
+	tickerService     services.TickerService
+	heartbeatInterval time.Duration
+	maxTickers        int
+}
+
+// NewStreamController creates a new StreamController
+func NewStreamController(hub *stream.Hub, tickerService services.TickerService, heartbeatInterval time.Duration, maxTickers int) *StreamController {
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = 15 * time.Second
+	}
+
+	return &StreamController{
+		hub:               hub,
+		tickerService:     tickerService,
+		heartbeatInterval: heartbeatInterval,
+		maxTickers:        maxTickers,
+	}
+}
+
+// Stream sends the quote and recommendation events of the tickers until the client disconnects
+// Query params: tickers (comma separated, required), lastEventId (optional)
+// Header: Last-Event-ID, has priority over the query param, the events after the id are sent first
+func (c *StreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	tickers := parseTickersParam(r)
+	if len(tickers) == 0 {
+		respondError(w, http.StatusBadRequest, "The tickers query param is required")
+		return
+	}
+
+	if c.maxTickers > 0 && len(tickers) > c.maxTickers {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d tickers can be streamed", c.maxTickers))
+		return
+	}
+
+	lastEventID := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if lastEventID == "" {
+		lastEventID = strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+	}
+
+	if lastEventID != "" && !stream.ValidID(lastEventID) {
+		respondError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+		return
+	}
+
+	validateCtx, cancelValidate := context.WithTimeout(r.Context(), 60*time.Second)
+	tickers, err := c.tickerService.ValidateTickers(validateCtx, tickers)
+	cancelValidate()
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownTickers) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[Stream] Failed to validate tickers")
+		respondError(w, http.StatusInternalServerError, "Failed to open the stream")
+		return
+	}
+
+	ctx := r.Context()
+	subscriber := c.hub.NewSubscriber()
+	defer c.hub.Remove(subscriber)
+
+	// the subscription starts before the replay, so no event is lost between both
+	if err := c.hub.Subscribe(ctx, subscriber, tickers...); err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[Stream] Failed to subscribe")
+		respondError(w, http.StatusServiceUnavailable, "Failed to open the stream")
+		return
+	}
+
+	var replay []stream.Event
+	if lastEventID != "" {
+		if replay, err = c.hub.Replay(ctx, tickers, lastEventID); err != nil {
+			apilogger.Logger().Error().Err(err).Msg("[Stream] Failed to replay events")
+			respondError(w, http.StatusInternalServerError, "Failed to resume the stream")
+			return
+		}
+	}
+
+	// the stream is long lived, the write deadline of the server does not apply
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range replay {
+		if err := writeServerSentEvent(w, event); err != nil {
+			return
+		}
+	}
+	replayed := stream.NewReplayedIDs(replay)
+
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-subscriber.Done():
+			if err := subscriber.Err(); err != nil {
+				apilogger.Logger().Warn().Err(err).Msg("[Stream] Client disconnected")
+			}
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event := <-subscriber.Events():
+			// the events already sent in the replay of their ticker are skipped
+			if replayed.Duplicate(event) {
+				continue
+			}
+
+			if err := writeServerSentEvent(w, event); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeServerSentEvent writes the event with the server-sent events format
+func writeServerSentEvent(w io.Writer, event stream.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"api/cache"
+	"api/forecast"
 	apilogger "api/logger"
 	"api/models"
+	"api/models/ratings"
 	"api/models/responses"
 	"api/services"
-	"api/services/geminiai"
+	"api/services/llm"
 	"context"
 	"errors"
 	"net/http"
@@ -19,15 +21,20 @@ import (
 
 // TickersController handles stock-related operations
 type TickersController struct {
-	tickerService services.TickerService
-	cache         cache.ICache
+	tickerService     services.TickerService
+	predictionService services.PredictionService
+	llmProvider       llm.LLMProvider
+	cache             cache.ICache
 }
 
 // NewTickersController creates a new tickerController
-func NewTickersController(tickerService services.TickerService, cache cache.ICache) TickersController {
+// llmProvider generates the advice and the predictions, they are stored with the name of the provider as model
+func NewTickersController(tickerService services.TickerService, predictionService services.PredictionService, llmProvider llm.LLMProvider, cache cache.ICache) TickersController {
 	return TickersController{
-		tickerService: tickerService,
-		cache:         cache,
+		tickerService:     tickerService,
+		predictionService: predictionService,
+		llmProvider:       llmProvider,
+		cache:             cache,
 	}
 }
 
@@ -54,9 +61,13 @@ func (c *TickersController) GetTickerLogo(w http.ResponseWriter, r *http.Request
 }
 
 // ListTickers retrieves a paginated list of tickers, with company data and recommendations
-// Query params: page (int), pageSize (int), order (asc/desc)
+// Query params: page (int), pageSize (int), order (asc/desc), sentiment (simple/weighted)
 func (c *TickersController) ListTickers(w http.ResponseWriter, r *http.Request) {
-	filter := parseFilters(r)
+	filter, err := parseTickerFilters(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancelManual()
@@ -75,6 +86,17 @@ func (c *TickersController) ListTickers(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	recomendations := buildRecomendationResponses(ctxCancel, c.tickerService, c.llmProvider, c.cache, tickers)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  recomendations,
+		"total": total,
+	})
+}
+
+// buildRecomendationResponses enriches the tickers with company data and the advice of the last 20 days
+// the data is retrieved concurrently, the failures are logged and the fields left empty
+func buildRecomendationResponses(ctx context.Context, tickerService services.TickerService, llmProvider llm.LLMProvider, c cache.ICache, tickers []models.Ticker) []responses.RecomendationResponse {
 	recomendations := make([]responses.RecomendationResponse, len(tickers))
 
 	for i, ticker := range tickers {
@@ -91,19 +113,19 @@ func (c *TickersController) ListTickers(w http.ResponseWriter, r *http.Request)
 
 		go func(index int, r responses.RecomendationResponse) {
 			defer wg.Done()
-			companyData, err := c.tickerService.GetCompanyData(ctxCancel, string(r.Ticker.ID))
+			companyData, err := tickerService.GetCompanyData(ctx, string(r.Ticker.ID))
 			if err != nil {
-				apilogger.Logger().Error().Err(err).Msg("[ListTickers] Failed to retrieve company data with ID:" + string(r.Ticker.ID))
+				apilogger.Logger().Error().Err(err).Msg("[buildRecomendationResponses] Failed to retrieve company data with ID:" + string(r.Ticker.ID))
 			}
 
-			historicalPrices, err := c.tickerService.GetHistoricalPrices(ctxCancel, string(r.Ticker.ID), from, time.Time{})
+			historicalPrices, err := tickerService.GetHistoricalPrices(ctx, string(r.Ticker.ID), from, time.Time{})
 			if err != nil {
-				apilogger.Logger().Error().Err(err).Msg("[ListTickers] Failed to retrieve historical prices with ID:" + string(r.Ticker.ID))
+				apilogger.Logger().Error().Err(err).Msg("[buildRecomendationResponses] Failed to retrieve historical prices with ID:" + string(r.Ticker.ID))
 			}
 
-			advice, err := geminiai.GenerateAdvice(string(r.Ticker.ID), historicalPrices, 20, c.cache)
+			advice, err := llm.GenerateAdvice(ctx, llmProvider, string(r.Ticker.ID), historicalPrices, 20, c)
 			if err != nil {
-				apilogger.Logger().Error().Err(err).Msg("[ListTickers] Failed to retrieve stock analysis with ID:" + string(r.Ticker.ID))
+				apilogger.Logger().Error().Err(err).Msg("[buildRecomendationResponses] Failed to retrieve stock analysis with ID:" + string(r.Ticker.ID))
 			}
 
 			recomendations[index].CompanyData = companyData
@@ -113,14 +135,12 @@ func (c *TickersController) ListTickers(w http.ResponseWriter, r *http.Request)
 
 	wg.Wait()
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"data":  recomendations,
-		"total": total,
-	})
+	return recomendations
 }
 
 // GetTickerOverview retrieves a single ticker by ID with its recommendations
 // Path param: id (string)
+// Query params: from (date), sentiment (simple/weighted)
 func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Request) {
 	var tickerOverview responses.CompanyOverview
 	id := chi.URLParam(r, "id")
@@ -130,6 +150,12 @@ func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	sentimentMode, err := ratings.ParseSentimentMode(r.URL.Query().Get("sentiment"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancelManual()
 
@@ -138,7 +164,7 @@ func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	ticker, err := c.tickerService.GetTickerByID(ctxCancel, id)
+	ticker, err := c.tickerService.GetTickerByID(ctxCancel, id, sentimentMode)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			respondError(w, http.StatusNotFound, "Ticker not found")
@@ -151,6 +177,7 @@ func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Req
 	}
 
 	tickerOverview.Recommendations = ticker.Recommendations
+	tickerOverview.Sentiment = ticker.SentimentScore
 
 	var wg sync.WaitGroup
 	// Get company data
@@ -179,7 +206,7 @@ func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Req
 
 		var advice string
 
-		advice, err = geminiai.GenerateAdvice(id, historicalPrices, 20, c.cache)
+		advice, err = llm.GenerateAdvice(ctxCancel, c.llmProvider, id, historicalPrices, 20, c.cache)
 		if err != nil {
 			apilogger.Logger().Error().Err(err).Msg("[GetTickerOverview] Failed to retrieve stock analysis with ID:" + id)
 			advice = ""
@@ -209,10 +236,28 @@ func (c *TickersController) GetTickerOverview(w http.ResponseWriter, r *http.Req
 	})
 }
 
-// GetTickerPredictions retrieves 7 days of predictions for a ticker
+// models of the predictions, the statistical models are in the forecast package
+// the predictions of the llm provider are stored with its name, gemini is kept as alias of the requests
+// that used it before the providers were configurable
+const (
+	llmPredictionAlias      = "gemini"
+	fallbackPredictionModel = forecast.Holt
+)
+
+// GetTickerPredictions retrieves 7 to 14 days of predictions for a ticker
+// if the llm provider fails the predictions are generated with the fallback statistical model,
+// the response reports the model that produced the predictions
 // Path param: id (string)
+// Query params: model (the llm provider or its alias gemini, holt, linear), days (int)
 func (c *TickersController) GetTickerPredictions(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	llmModel := c.llmProvider.Name()
+
+	model, days, err := parsePredictionParams(r, llmModel)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var predicts []models.HistoricalPrice = make([]models.HistoricalPrice, 0)
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
@@ -223,7 +268,7 @@ func (c *TickersController) GetTickerPredictions(w http.ResponseWriter, r *http.
 		return
 	}
 
-	_, err := c.tickerService.GetTickerByID(ctxCancel, id)
+	_, err = c.tickerService.GetTickerByID(ctxCancel, id, ratings.SimpleSentimentMode)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			respondError(w, http.StatusNotFound, "Ticker not found")
@@ -236,7 +281,7 @@ func (c *TickersController) GetTickerPredictions(w http.ResponseWriter, r *http.
 	}
 
 	now := time.Now()
-	from := time.Now().AddDate(0, 0, -30)
+	from := time.Now().AddDate(0, 0, -90)
 
 	historicalPrices, err := c.tickerService.GetHistoricalPrices(ctxCancel, id, from, now)
 	if err != nil {
@@ -245,15 +290,73 @@ func (c *TickersController) GetTickerPredictions(w http.ResponseWriter, r *http.
 		return
 	}
 
-	predicts, err = geminiai.GeneratePredict(id, historicalPrices, 20, 7, c.cache)
+	if model == llmModel {
+		predicts, err = llm.GeneratePredict(ctxCancel, c.llmProvider, id, historicalPrices, 20, days, c.cache)
+		if err == nil && len(predicts) == 0 {
+			err = errors.New(llmModel + " returned an empty prediction")
+		}
+
+		if err != nil {
+			apilogger.Logger().Warn().Err(err).Msg("[GetTickerPredictions] Failed to generate predictions with " + llmModel + ", using the model " + fallbackPredictionModel + " with ID:" + id)
+			model = fallbackPredictionModel
+		}
+	}
+
+	if model != llmModel {
+		predicts, err = forecast.Forecast(id, historicalPrices, model, days)
+		if err != nil {
+			apilogger.Logger().Error().Err(err).Msg("[GetTickerPredictions] Failed to forecast with the model " + model + " with ID:" + id)
+			respondError(w, http.StatusInternalServerError, "Failed in generate predictions, try again later")
+			return
+		}
+	}
+
+	// the predictions are stored to score them when the dates pass
+	if err := c.predictionService.SavePredictions(ctxCancel, id, model, historicalPrices, predicts); err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[GetTickerPredictions] Failed to save predictions with ID:" + id)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  predicts,
+		"model": model,
+	})
+}
+
+// GetTickerPredictionsAccuracy retrieves the accuracy of the scored predictions of a ticker per model
+// Path param: id (string)
+// Query params: model (optional, the llm provider or its alias gemini, holt, linear)
+func (c *TickersController) GetTickerPredictionsAccuracy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	model, err := parsePredictionModel(r.URL.Query().Get("model"), c.llmProvider.Name())
 	if err != nil {
-		apilogger.Logger().Error().Err(err).Msg("[GetTickerPredictions] Failed to retrieve stock analysis with ID:" + id)
-		respondError(w, http.StatusInternalServerError, "Failed in generate predictions, try again later")
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	_, err = c.tickerService.GetTickerByID(ctxCancel, id, ratings.SimpleSentimentMode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Ticker not found")
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[GetTickerPredictionsAccuracy] Failed to retrieve ticker with ID:" + id)
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve ticker")
+		return
+	}
+
+	accuracy, err := c.predictionService.GetAccuracy(ctxCancel, id, model)
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[GetTickerPredictionsAccuracy] Failed to retrieve accuracy with ID:" + id)
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve the accuracy of the predictions")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"data": predicts,
+		"data": accuracy,
 	})
 }
 
@@ -289,7 +392,7 @@ func (c *TickersController) GetTickerHistoricalPrices(w http.ResponseWriter, r *
 	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancelManual()
 
-	_, err = c.tickerService.GetTickerByID(ctxCancel, id)
+	_, err = c.tickerService.GetTickerByID(ctxCancel, id, ratings.SimpleSentimentMode)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			respondError(w, http.StatusNotFound, "Ticker not found")
@@ -312,3 +415,47 @@ func (c *TickersController) GetTickerHistoricalPrices(w http.ResponseWriter, r *
 		"data": historicalPrices,
 	})
 }
+
+// GetTickerIndicators retrieves the technical indicators of a ticker
+// Path param: id (string)
+// Query params: from, to (YYYY-MM-DD), indicators (sma,ema,rsi,macd,bollinger,atr,obv) and the periods
+func (c *TickersController) GetTickerIndicators(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	config, err := parseIndicatorsConfig(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	_, err = c.tickerService.GetTickerByID(ctxCancel, id, ratings.SimpleSentimentMode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			respondError(w, http.StatusNotFound, "Ticker not found")
+			return
+		}
+
+		apilogger.Logger().Error().Err(err).Msg("[GetTickerIndicators] Failed to retrieve ticker with ID:" + id)
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve ticker")
+		return
+	}
+
+	tickerIndicators, err := c.tickerService.GetIndicators(ctxCancel, id, from, to, config)
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[GetTickerIndicators] Failed to calculate indicators with ID:" + id)
+		respondError(w, http.StatusInternalServerError, "Failed to calculate indicators")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": tickerIndicators,
+	})
+}
@@ -1,14 +1,22 @@
 package controllers
 
 import (
+	"api/auth"
+	"api/config"
+	"api/forecast"
+	"api/indicators"
 	"api/models"
 	"api/models/filters"
+	"api/models/ratings"
+	"api/sanatizer"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // parseFilters extracts pagination and ordering parameters from query string
@@ -19,6 +27,7 @@ func parseFilters(r *http.Request) filters.Filters {
 	size, _ := strconv.Atoi(query.Get("size"))
 	sortStr := strings.ToLower(query.Get("sort"))
 	queryStr := query.Get("q")
+	orderBy := strings.TrimSpace(query.Get("orderBy"))
 
 	var sort filters.Sort
 	switch sortStr {
@@ -35,7 +44,41 @@ func parseFilters(r *http.Request) filters.Filters {
 		PageSize: size,
 		Sort:     sort,
 		Query:    queryStr,
+		OrderBy:  orderBy,
+	}
+}
+
+// parseTickerFilters extracts the filters of the lists of tickers, with the sentiment mode
+// Query params: sentiment (simple/weighted, default simple)
+func parseTickerFilters(r *http.Request) (filters.Filters, error) {
+	filter := parseFilters(r)
+
+	mode, err := ratings.ParseSentimentMode(r.URL.Query().Get("sentiment"))
+	if err != nil {
+		return filter, err
 	}
+
+	filter.Sentiment = mode
+	return filter, nil
+}
+
+// parseTickersParam extracts the tickers of the comma separated query param tickers
+// the tickers are uppercase and without duplicates
+func parseTickersParam(r *http.Request) []string {
+	var tickers []string
+	seen := make(map[string]bool)
+
+	for _, ticker := range strings.Split(r.URL.Query().Get("tickers"), ",") {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+
+		seen[ticker] = true
+		tickers = append(tickers, ticker)
+	}
+
+	return tickers
 }
 
 // parseDateRange extracts date range parameters from query string
@@ -69,6 +112,169 @@ func parseDateRange(r *http.Request) (time.Time, time.Time, error) {
 	return fromTime, toTime, nil
 }
 
+// ClientIDHeader is the header used to identify the user of the request
+const ClientIDHeader = "X-Client-ID"
+
+// AnonymousClientID is the identifier used when the request has no identity
+const AnonymousClientID = "anonymous"
+
+// requestOwner returns the identifier of the user that made the request
+// the authenticated identity has priority over the header, the header is only trusted when the auth is disabled,
+// with the auth enabled the middleware rejects the requests without identity
+// if the request has no identity and the header is empty returns the anonymous identifier
+func requestOwner(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok && identity.Subject != "" {
+		return identity.Subject
+	}
+
+	if config.Auth().Enabled {
+		return AnonymousClientID
+	}
+
+	clientID := sanatizer.SanatizerString(r.Header.Get(ClientIDHeader)).
+		SanatizedAll().
+		WithMaxLength(200).
+		String()
+
+	if strings.TrimSpace(clientID) == "" {
+		return AnonymousClientID
+	}
+
+	return clientID
+}
+
+// parseIDParam extracts a numeric path param
+func parseIDParam(r *http.Request, key string) (uint, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, key), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid %s: must be a positive number", key)
+	}
+
+	return uint(id), nil
+}
+
+// parseIndicatorsConfig extracts the indicators and their periods from query string
+// Query params: indicators (comma separated), smaPeriod, emaPeriod, rsiPeriod, macdFast, macdSlow,
+// macdSignal, bollingerPeriod, bollingerStdDev, atrPeriod
+// the params not sent use the default values
+func parseIndicatorsConfig(r *http.Request) (indicators.Config, error) {
+	query := r.URL.Query()
+	config := indicators.DefaultConfig()
+
+	if names := strings.TrimSpace(query.Get("indicators")); names != "" {
+		config.Indicators = nil
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				config.Indicators = append(config.Indicators, name)
+			}
+		}
+	}
+
+	periods := map[string]*int{
+		"smaPeriod":       &config.SMAPeriod,
+		"emaPeriod":       &config.EMAPeriod,
+		"rsiPeriod":       &config.RSIPeriod,
+		"macdFast":        &config.MACDFast,
+		"macdSlow":        &config.MACDSlow,
+		"macdSignal":      &config.MACDSignal,
+		"bollingerPeriod": &config.BollingerPeriod,
+		"atrPeriod":       &config.ATRPeriod,
+	}
+
+	for name, period := range periods {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: must be a number", name)
+		}
+		*period = parsed
+	}
+
+	if value := query.Get("bollingerStdDev"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid bollingerStdDev: must be a number")
+		}
+		config.BollingerStdDev = parsed
+	}
+
+	return config, config.Validate()
+}
+
+// parsePredictionParams extracts the model and the days to predict from query string
+// the default model and the alias gemini are the model of the llm provider, llmModel
+func parsePredictionParams(r *http.Request, llmModel string) (string, int, error) {
+	query := r.URL.Query()
+
+	model, err := parsePredictionModel(query.Get("model"), llmModel)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if model == "" {
+		model = llmModel
+	}
+
+	days := forecast.MinDays
+	if value := query.Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < forecast.MinDays || parsed > forecast.MaxDays {
+			return "", 0, fmt.Errorf("invalid days: must be between %d and %d", forecast.MinDays, forecast.MaxDays)
+		}
+		days = parsed
+	}
+
+	return model, days, nil
+}
+
+// parsePredictionModel returns the model of the predictions in lower case, empty if it is not set
+// the alias gemini is the model of the llm provider, llmModel, the name stored with its predictions
+func parsePredictionModel(value string, llmModel string) (string, error) {
+	model := strings.ToLower(strings.TrimSpace(value))
+	llmModel = strings.ToLower(llmModel)
+
+	switch {
+	case model == "" || model == llmModel || forecast.IsModel(model):
+		return model, nil
+	case model == llmPredictionAlias:
+		return llmModel, nil
+	}
+
+	return "", fmt.Errorf("invalid model: the supported models are %s, %s", llmModel, strings.Join(forecast.Models, ", "))
+}
+
+// parseRiskParams returns the benchmark and the confidence of the risk of a portfolio
+// the benchmark is upper-cased and defaults to defaultBenchmark, the confidence defaults to 0.95
+func parseRiskParams(r *http.Request, defaultBenchmark string) (string, float64, error) {
+	query := r.URL.Query()
+
+	benchmark := strings.ToUpper(strings.TrimSpace(query.Get("benchmark")))
+	if benchmark == "" {
+		benchmark = strings.ToUpper(defaultBenchmark)
+	}
+
+	if len(benchmark) > 10 || strings.IndexFunc(benchmark, func(c rune) bool {
+		return (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '-'
+	}) >= 0 {
+		return "", 0, fmt.Errorf("invalid benchmark: %q is not a ticker", benchmark)
+	}
+
+	confidence := 0.95
+	if value := query.Get("confidence"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0.5 || parsed >= 1 {
+			return "", 0, fmt.Errorf("invalid confidence: must be greater than 0.5 and less than 1")
+		}
+		confidence = parsed
+	}
+
+	return benchmark, confidence, nil
+}
+
 // Helper functions
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -1,9 +1,19 @@
 package controllers
 
 import (
+	"api/auth"
+	"api/config"
+	"api/indicators"
+	"api/models"
+	"api/models/ratings"
+	"api/screener"
+	"api/stream"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -81,3 +91,388 @@ func Test_ParseRange(t *testing.T) {
 		})
 	}
 }
+
+func Test_RequestOwner(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		header   string
+		expected string
+	}{
+		{
+			desc:     "empty header",
+			header:   "",
+			expected: AnonymousClientID,
+		},
+		{
+			desc:     "blank header",
+			header:   "   ",
+			expected: AnonymousClientID,
+		},
+		{
+			desc:     "valid header",
+			header:   "analyst-1",
+			expected: "analyst-1",
+		},
+		{
+			desc:     "header with html",
+			header:   "<b>analyst</b>",
+			expected: "&ltb&gtanalyst&lt/b&gt",
+		},
+	}
+	enabled := config.Auth().Enabled
+	config.Auth().Enabled = false
+	defer func() { config.Auth().Enabled = enabled }()
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.Header.Set(ClientIDHeader, tC.header)
+
+			assert.Equal(t, tC.expected, requestOwner(req))
+		})
+	}
+}
+
+func Test_RequestOwnerWithIdentity(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+	req.Header.Set(ClientIDHeader, "spoofed")
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "analyst-1", Method: auth.MethodJWT}))
+
+	assert.Equal(t, "analyst-1", requestOwner(req))
+}
+
+func Test_RequestOwnerWithAuthEnabled(t *testing.T) {
+	enabled := config.Auth().Enabled
+	config.Auth().Enabled = true
+	defer func() { config.Auth().Enabled = enabled }()
+
+	// the header is not trusted, only the authenticated identity
+	req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+	req.Header.Set(ClientIDHeader, "analyst-1")
+	assert.Equal(t, AnonymousClientID, requestOwner(req))
+}
+
+func Test_ParseIndicatorsConfig(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		query              string
+		expectedIndicators []string
+		expectedRSIPeriod  int
+		expectedStdDev     float64
+		hasError           bool
+	}{
+		{
+			desc:               "default config",
+			query:              "",
+			expectedIndicators: indicators.Names,
+			expectedRSIPeriod:  14,
+			expectedStdDev:     2,
+		},
+		{
+			desc:               "selected indicators and periods",
+			query:              "indicators=RSI, bollinger,&rsiPeriod=7&bollingerStdDev=2.5",
+			expectedIndicators: []string{"rsi", "bollinger"},
+			expectedRSIPeriod:  7,
+			expectedStdDev:     2.5,
+		},
+		{
+			desc:     "unknown indicator",
+			query:    "indicators=sma,vwap",
+			hasError: true,
+		},
+		{
+			desc:     "invalid period",
+			query:    "smaPeriod=abc",
+			hasError: true,
+		},
+		{
+			desc:     "invalid macd periods",
+			query:    "macdFast=30&macdSlow=26",
+			hasError: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.URL.RawQuery = tC.query
+
+			config, err := parseIndicatorsConfig(req)
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedIndicators, config.Indicators)
+			assert.Equal(t, tC.expectedRSIPeriod, config.RSIPeriod)
+			assert.Equal(t, tC.expectedStdDev, config.BollingerStdDev)
+		})
+	}
+}
+
+func Test_ParsePredictionParams(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		query         string
+		llmModel      string
+		expectedModel string
+		expectedDays  int
+		hasError      bool
+	}{
+		{desc: "default params", query: "", llmModel: "gemini", expectedModel: "gemini", expectedDays: 7},
+		{desc: "default model of other provider", query: "", llmModel: "openai", expectedModel: "openai", expectedDays: 7},
+		{desc: "gemini alias of the provider", query: "model=Gemini", llmModel: "openai", expectedModel: "openai", expectedDays: 7},
+		{desc: "name of the provider", query: "model=openai&days=10", llmModel: "openai", expectedModel: "openai", expectedDays: 10},
+		{desc: "statistical model", query: "model=Holt&days=14", llmModel: "gemini", expectedModel: "holt", expectedDays: 14},
+		{desc: "linear model", query: "model=linear", llmModel: "gemini", expectedModel: "linear", expectedDays: 7},
+		{desc: "unknown model", query: "model=arima", llmModel: "gemini", hasError: true},
+		{desc: "other provider", query: "model=openai", llmModel: "gemini", hasError: true},
+		{desc: "days under the min", query: "days=3", llmModel: "gemini", hasError: true},
+		{desc: "days over the max", query: "days=15", llmModel: "gemini", hasError: true},
+		{desc: "invalid days", query: "days=abc", llmModel: "gemini", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.URL.RawQuery = tC.query
+
+			model, days, err := parsePredictionParams(req, tC.llmModel)
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedModel, model)
+			assert.Equal(t, tC.expectedDays, days)
+		})
+	}
+}
+
+func Test_ParsePredictionModel(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected string
+		hasError bool
+	}{
+		{desc: "without model", value: "", expected: ""},
+		{desc: "gemini alias", value: " gemini ", expected: "fake"},
+		{desc: "name of the provider", value: "FAKE", expected: "fake"},
+		{desc: "statistical model", value: "holt", expected: "holt"},
+		{desc: "unknown model", value: "arima", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			model, err := parsePredictionModel(tC.value, "fake")
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, model)
+		})
+	}
+}
+
+func Test_ParseTickerFilters(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		query    string
+		expected ratings.SentimentMode
+		hasError bool
+	}{
+		{desc: "default mode", query: "page=2", expected: ratings.SimpleSentimentMode},
+		{desc: "simple mode", query: "sentiment=simple", expected: ratings.SimpleSentimentMode},
+		{desc: "weighted mode", query: "sentiment=Weighted", expected: ratings.WeightedSentimentMode},
+		{desc: "unknown mode", query: "sentiment=average", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.URL.RawQuery = tC.query
+
+			filter, err := parseTickerFilters(req)
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, filter.Sentiment)
+		})
+	}
+}
+
+func Test_ParseTickersParam(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		tickers  string
+		expected []string
+	}{
+		{
+			desc:     "empty param",
+			tickers:  "",
+			expected: nil,
+		},
+		{
+			desc:     "only separators",
+			tickers:  " , ,",
+			expected: nil,
+		},
+		{
+			desc:     "uppercase and trimmed",
+			tickers:  "aapl, msft ",
+			expected: []string{"AAPL", "MSFT"},
+		},
+		{
+			desc:     "duplicated tickers",
+			tickers:  "AAPL,aapl,MSFT,AAPL",
+			expected: []string{"AAPL", "MSFT"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			q := url.Values{}
+			q.Add("tickers", tC.tickers)
+
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.URL.RawQuery = q.Encode()
+
+			assert.Equal(t, tC.expected, parseTickersParam(req))
+		})
+	}
+}
+
+func Test_WriteServerSentEvent(t *testing.T) {
+	var buffer bytes.Buffer
+	event := stream.Event{
+		ID:     "1700000000000-0",
+		Type:   stream.QuoteEvent,
+		Ticker: "AAPL",
+		Data:   json.RawMessage(`{"price":10}`),
+	}
+
+	err := writeServerSentEvent(&buffer, event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id: 1700000000000-0\nevent: quote\ndata: {\"id\":\"1700000000000-0\",\"type\":\"quote\",\"ticker\":\"AAPL\",\"data\":{\"price\":10}}\n\n", buffer.String())
+}
+
+func Test_DecodeTransactionRequest(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		body          string
+		expected      models.Transaction
+		expectedError string
+	}{
+		{
+			desc: "valid transaction",
+			body: `{"tickerId":"aapl","type":"buy","quantity":1.5,"price":100,"fees":1,"date":"2025-01-02"}`,
+			expected: models.Transaction{
+				TickerID: "aapl",
+				Type:     models.BuyTransaction,
+				Quantity: 1.5,
+				Price:    100,
+				Fees:     1,
+				Date:     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			desc:          "invalid body",
+			body:          `{"quantity":"one"}`,
+			expectedError: "the body of the request is not valid",
+		},
+		{
+			desc:          "invalid date",
+			body:          `{"tickerId":"AAPL","type":"sell","quantity":1,"price":100,"date":"02/01/2025"}`,
+			expectedError: "invalid date format: the format must be YYYY-MM-DD",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://localhost:8080", strings.NewReader(tC.body))
+
+			transaction, err := decodeTransactionRequest(req)
+
+			if tC.expectedError != "" {
+				assert.EqualError(t, err, tC.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, transaction)
+		})
+	}
+}
+
+func Test_ParseRiskParams(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		query              string
+		expectedBenchmark  string
+		expectedConfidence float64
+		hasError           bool
+	}{
+		{desc: "default params", query: "", expectedBenchmark: "SPY", expectedConfidence: 0.95},
+		{desc: "custom params", query: "benchmark=qqq&confidence=0.99", expectedBenchmark: "QQQ", expectedConfidence: 0.99},
+		{desc: "invalid benchmark", query: "benchmark=S%26P", hasError: true},
+		{desc: "confidence under the min", query: "confidence=0.5", hasError: true},
+		{desc: "confidence over the max", query: "confidence=1", hasError: true},
+		{desc: "invalid confidence", query: "confidence=high", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://localhost:8080", nil)
+			req.URL.RawQuery = tC.query
+
+			benchmark, confidence, err := parseRiskParams(req, "spy")
+
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedBenchmark, benchmark)
+			assert.InDelta(t, tC.expectedConfidence, confidence, 1e-9)
+		})
+	}
+}
+
+func Test_DecodeScreenerFilter(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		body          string
+		expected      screener.Filter
+		expectedError string
+	}{
+		{desc: "empty body", body: "", expected: screener.Filter{}},
+		{
+			desc:     "condition",
+			body:     `{"field":"price","op":"lt","value":10}`,
+			expected: screener.Filter{Field: "price", Op: screener.LessThan, Value: 10.0},
+		},
+		{desc: "invalid body", body: `{"and":{}}`, expectedError: "the body of the request is not valid"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "http://localhost:8080", strings.NewReader(tC.body))
+
+			filter, err := decodeScreenerFilter(req)
+
+			if tC.expectedError != "" {
+				assert.EqualError(t, err, tC.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, filter)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"api/cache"
+	apilogger "api/logger"
+	"api/models/responses"
+	"api/sanatizer"
+	"api/services"
+	"api/services/llm"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// WatchlistsController handles the watchlists of the users
+type WatchlistsController struct {
+	watchlistService services.WatchlistService
+	tickerService    services.TickerService
+	llmProvider      llm.LLMProvider
+	cache            cache.ICache
+}
+
+// watchlistRequest body to create or update a watchlist
+type watchlistRequest struct {
+	Name    string   `json:"name"`
+	Tickers []string `json:"tickers"`
+}
+
+// NewWatchlistsController creates a new WatchlistsController
+func NewWatchlistsController(watchlistService services.WatchlistService, tickerService services.TickerService, llmProvider llm.LLMProvider, cache cache.ICache) *WatchlistsController {
+	return &WatchlistsController{
+		watchlistService: watchlistService,
+		tickerService:    tickerService,
+		llmProvider:      llmProvider,
+		cache:            cache,
+	}
+}
+
+// ListWatchlists retrieves the watchlists of the user
+func (c *WatchlistsController) ListWatchlists(w http.ResponseWriter, r *http.Request) {
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	watchlists, err := c.watchlistService.GetWatchlists(ctxCancel, requestOwner(r))
+	if err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[ListWatchlists] Failed to retrieve watchlists")
+		respondError(w, http.StatusInternalServerError, "Failed to retrieve watchlists")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": watchlists,
+	})
+}
+
+// GetWatchlist retrieves a watchlist of the user
+// Path param: id (int)
+func (c *WatchlistsController) GetWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	watchlist, err := c.watchlistService.GetWatchlist(ctxCancel, requestOwner(r), id)
+	if err != nil {
+		respondWatchlistError(w, err, "[GetWatchlist] Failed to retrieve watchlist")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": watchlist,
+	})
+}
+
+// CreateWatchlist creates a watchlist for the user
+// Body: { "name": string, "tickers": []string }
+func (c *WatchlistsController) CreateWatchlist(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodeWatchlistRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	watchlist, err := c.watchlistService.CreateWatchlist(ctxCancel, requestOwner(r), body.Name, body.Tickers)
+	if err != nil {
+		respondWatchlistError(w, err, "[CreateWatchlist] Failed to create watchlist")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": watchlist,
+	})
+}
+
+// UpdateWatchlist renames a watchlist of the user
+// Path param: id (int)
+// Body: { "name": string }
+func (c *WatchlistsController) UpdateWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	body, ok := decodeWatchlistRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	watchlist, err := c.watchlistService.UpdateWatchlist(ctxCancel, requestOwner(r), id, body.Name)
+	if err != nil {
+		respondWatchlistError(w, err, "[UpdateWatchlist] Failed to update watchlist")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": watchlist,
+	})
+}
+
+// DeleteWatchlist deletes a watchlist of the user
+// Path param: id (int)
+func (c *WatchlistsController) DeleteWatchlist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	if err := c.watchlistService.DeleteWatchlist(ctxCancel, requestOwner(r), id); err != nil {
+		respondWatchlistError(w, err, "[DeleteWatchlist] Failed to delete watchlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWatchlistTickers retrieves a paginated list of the tickers of a watchlist
+// with company data and recommendations, the same payload of ListTickers
+// Path param: id (int)
+// Query params: page (int), size (int), sort (asc/desc), sentiment (simple/weighted)
+func (c *WatchlistsController) ListWatchlistTickers(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseTickerFilters(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	tickers, total, err := c.watchlistService.GetWatchlistTickers(ctxCancel, requestOwner(r), id, filter)
+	if err != nil {
+		respondWatchlistError(w, err, "[ListWatchlistTickers] Failed to retrieve tickers")
+		return
+	}
+
+	if len(tickers) == 0 {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"data":  []responses.RecomendationResponse{},
+			"total": total,
+		})
+		return
+	}
+
+	recomendations := buildRecomendationResponses(ctxCancel, c.tickerService, c.llmProvider, c.cache, tickers)
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  recomendations,
+		"total": total,
+	})
+}
+
+// AddWatchlistTickers adds tickers to a watchlist of the user
+// Path param: id (int)
+// Body: { "tickers": []string }
+func (c *WatchlistsController) AddWatchlistTickers(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var body watchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Tickers) == 0 {
+		respondError(w, http.StatusBadRequest, "The body must contain at least one ticker")
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	watchlist, err := c.watchlistService.AddTickers(ctxCancel, requestOwner(r), id, body.Tickers)
+	if err != nil {
+		respondWatchlistError(w, err, "[AddWatchlistTickers] Failed to add tickers")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": watchlist,
+	})
+}
+
+// RemoveWatchlistTicker removes a ticker from a watchlist of the user
+// Path params: id (int), ticker (string)
+func (c *WatchlistsController) RemoveWatchlistTicker(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ticker := chi.URLParam(r, "ticker")
+	if ticker == "" {
+		respondError(w, http.StatusBadRequest, "Ticker ID is required")
+		return
+	}
+
+	ctxCancel, cancelManual := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancelManual()
+
+	if err := c.watchlistService.RemoveTicker(ctxCancel, requestOwner(r), id, ticker); err != nil {
+		respondWatchlistError(w, err, "[RemoveWatchlistTicker] Failed to remove ticker")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeWatchlistRequest decodes and validates the body with the name of the watchlist
+// responds bad request if the body is invalid
+func decodeWatchlistRequest(w http.ResponseWriter, r *http.Request) (watchlistRequest, bool) {
+	var body watchlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		respondError(w, http.StatusBadRequest, "The body of the request is not valid")
+		return body, false
+	}
+
+	body.Name = sanatizer.SanatizerString(strings.TrimSpace(body.Name)).SanatizeHTML().String()
+	if body.Name == "" || len(body.Name) > 100 {
+		respondError(w, http.StatusBadRequest, "The name is required and must have at most 100 characters")
+		return body, false
+	}
+
+	return body, true
+}
+
+// respondWatchlistError maps the errors of the watchlist service to the http status
+func respondWatchlistError(w http.ResponseWriter, err error, logMessage string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		respondError(w, http.StatusNotFound, "Watchlist not found")
+		return
+	}
+
+	if errors.Is(err, services.ErrUnknownTickers) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	apilogger.Logger().Error().Err(err).Msg(logMessage)
+	respondError(w, http.StatusInternalServerError, "Failed to process the watchlist")
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"api/cache"
+	"api/config"
+	apilogger "api/logger"
+	"api/services"
+	"api/services/llm"
+	"api/stream"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebsocketController sends the live updates of the tickers with websockets,
+// the client changes the tickers of the connection with the subscribe and unsubscribe messages
+type WebsocketController struct {
+	hub           *stream.Hub
+	tickerService services.TickerService
+	llmProvider   llm.LLMProvider
+	cache         cache.ICache
+	upgrader      websocket.Upgrader
+	options       stream.SessionOptions
+}
+
+// NewWebsocketController creates a new WebsocketController
+// maxSubscriptions is the max tickers per connection, bufferSize the messages buffered per connection
+func NewWebsocketController(hub *stream.Hub, tickerService services.TickerService, llmProvider llm.LLMProvider, cache cache.ICache, pingInterval time.Duration, maxSubscriptions int, bufferSize int) *WebsocketController {
+	c := &WebsocketController{
+		hub:           hub,
+		tickerService: tickerService,
+		llmProvider:   llmProvider,
+		cache:         cache,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkWebsocketOrigin,
+		},
+	}
+
+	c.options = stream.SessionOptions{
+		MaxSubscriptions: maxSubscriptions,
+		BufferSize:       bufferSize,
+		PingInterval:     pingInterval,
+		ValidateTickers:  tickerService.ValidateTickers,
+		Advice:           c.advice,
+	}
+
+	return c
+}
+
+// Connect upgrades the request to a websocket and runs the protocol until the client disconnects
+// Client messages: { "type": "subscribe" | "unsubscribe", "tickers": []string, "id": string } and { "type": "ping", "id": string }
+// Server messages: subscribed, pong, quote, recommendation, advice and error
+func (c *WebsocketController) Connect(w http.ResponseWriter, r *http.Request) {
+	// the upgrader responds the error of the handshake
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		apilogger.Logger().Warn().Err(err).Msg("[Connect] Failed to upgrade the websocket")
+		return
+	}
+
+	if err := stream.Serve(r.Context(), conn, c.hub, c.options); err != nil {
+		apilogger.Logger().Warn().Err(err).Msg("[Connect] Websocket closed")
+	}
+}
+
+// advice generates the advice of the ticker with the prices of the last 20 days, the same of the lists of tickers
+func (c *WebsocketController) advice(ctx context.Context, ticker string) (string, error) {
+	ctxCancel, cancelManual := context.WithTimeout(ctx, 60*time.Second)
+	defer cancelManual()
+
+	historicalPrices, err := c.tickerService.GetHistoricalPrices(ctxCancel, ticker, time.Now().AddDate(0, 0, -20), time.Time{})
+	if err != nil {
+		return "", err
+	}
+
+	return llm.GenerateAdvice(ctxCancel, c.llmProvider, ticker, historicalPrices, 20, c.cache)
+}
+
+// checkWebsocketOrigin accepts the requests without origin, of the same host or of the client of the api
+func checkWebsocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+
+	return strings.EqualFold(strings.TrimRight(origin, "/"), strings.TrimRight(config.Server().ClientHost, "/"))
+}
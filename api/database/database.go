@@ -108,10 +108,60 @@ func migrateModels(db *gorm.DB) error {
 		&models.Ticker{},
 		&models.Recommendation{},
 		&models.Onboarding{},
+		&models.SyncCheckpoint{},
+		&models.SyncLock{},
+		&models.Watchlist{},
+		&models.WatchlistItem{},
+		&models.ApiKey{},
+		&models.PredictionSet{},
+		&models.PredictionPoint{},
+		&models.BrokerageStats{},
+		&models.AlertRule{},
+		&models.AlertEvent{},
+		&models.Portfolio{},
+		&models.Transaction{},
+		&models.CompanySnapshot{},
+		&models.HistoricalPrice{},
+		&models.PriceCoverage{},
 	); err != nil {
 		return fmt.Errorf("failed to migrate models: %w", err)
 	}
 
+	if err := migrateLegacyOnboarding(db); err != nil {
+		return fmt.Errorf("failed to migrate onboarding: %w", err)
+	}
+
+	return nil
+}
+
+// migrateLegacyOnboarding moves the progress of the single row onboarding (overview_step, overview_done)
+// to the overview tour of the anonymous client and drops the old columns
+//
+// the new columns are created by AutoMigrate with their defaults, so the old row is already
+// assigned to the anonymous client and the overview tour
+func migrateLegacyOnboarding(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Onboarding{}, "overview_step") {
+		return nil
+	}
+
+	apilogger.Logger().Info().Msg("Migrating legacy onboarding")
+	err := db.Model(&models.Onboarding{}).
+		Where("1 = 1").
+		Updates(map[string]interface{}{
+			"step": gorm.Expr("COALESCE(overview_step, 1)"),
+			"done": gorm.Expr("COALESCE(overview_done, false)"),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, column := range []string{"overview_step", "overview_done"} {
+		if err := migrator.DropColumn(&models.Onboarding{}, column); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
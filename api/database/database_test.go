@@ -0,0 +1,54 @@
+package database
+
+import (
+	"api/models"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// legacyOnboarding single row onboarding of the first version
+type legacyOnboarding struct {
+	ID           uint `gorm:"primaryKey"`
+	OverviewStep int  `gorm:"default:1"`
+	OverviewDone bool `gorm:"default:false"`
+}
+
+func (legacyOnboarding) TableName() string {
+	return "onboarding"
+}
+
+func TestMigrateLegacyOnboarding(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	// each connection opens a different in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	assert.NoError(t, db.AutoMigrate(&legacyOnboarding{}))
+	assert.NoError(t, db.Create(&legacyOnboarding{ID: 1, OverviewStep: 3, OverviewDone: true}).Error)
+
+	assert.NoError(t, db.AutoMigrate(&models.Onboarding{}))
+	assert.NoError(t, migrateLegacyOnboarding(db))
+
+	var onboardings []models.Onboarding
+	assert.NoError(t, db.Find(&onboardings).Error)
+	if assert.Len(t, onboardings, 1) {
+		assert.Equal(t, uint(1), onboardings[0].ID)
+		assert.Equal(t, "anonymous", onboardings[0].ClientID)
+		assert.Equal(t, models.OverviewTour, onboardings[0].Tour)
+		assert.Equal(t, 3, onboardings[0].Step)
+		assert.True(t, onboardings[0].Done)
+	}
+
+	assert.False(t, db.Migrator().HasColumn(&models.Onboarding{}, "overview_step"))
+	assert.False(t, db.Migrator().HasColumn(&models.Onboarding{}, "overview_done"))
+
+	// the migration runs once
+	assert.NoError(t, migrateLegacyOnboarding(db))
+}
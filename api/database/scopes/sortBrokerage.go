@@ -0,0 +1,32 @@
+package scopes
+
+import (
+	"api/models/filters"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// brokerageOrderColumns fields allowed to order the brokerages, the stats are joined as "Stats"
+var brokerageOrderColumns = map[string]string{
+	"name":             "brokerages.name",
+	"calls":            `"Stats".calls`,
+	"upgrades":         `"Stats".upgrades`,
+	"upgradehitrate30": `"Stats".upgrade_hit_rate30`,
+	"upgradehitrate90": `"Stats".upgrade_hit_rate90`,
+	"targeterror":      `"Stats".target_error`,
+	"lastcallat":       `"Stats".last_call_at`,
+}
+
+// SortBrokerage orders the brokerages by the field, the brokerages without stats are the last
+// if the field is not allowed they are ordered by name
+func SortBrokerage(orderBy string, sort filters.Sort) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		column, ok := brokerageOrderColumns[strings.ToLower(orderBy)]
+		if !ok {
+			column = brokerageOrderColumns["name"]
+		}
+
+		return db.Order(column + " " + sort.String() + " NULLS LAST").Order("brokerages.id asc")
+	}
+}
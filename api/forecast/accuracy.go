@@ -0,0 +1,65 @@
+package forecast
+
+import "math"
+
+// Observation predicted and real close of a day
+// Reference is the real close of the previous day, used to compare the direction
+type Observation struct {
+	Reference float64
+	Predicted float64
+	Actual    float64
+}
+
+// Accuracy errors of the predictions
+// MAPE is a percentage, DirectionalAccuracy is the ratio of predictions
+// that moved in the same direction of the real price
+type Accuracy struct {
+	Points              int     `json:"points"`
+	MAE                 float64 `json:"mae"`
+	MAPE                float64 `json:"mape"`
+	DirectionalAccuracy float64 `json:"directionalAccuracy"`
+}
+
+// Evaluate calculates the mean absolute error, the mean absolute percentage error
+// and the directional accuracy of the observations
+// the observations with actual 0 are not included in the MAPE
+func Evaluate(observations []Observation) Accuracy {
+	accuracy := Accuracy{Points: len(observations)}
+	if len(observations) == 0 {
+		return accuracy
+	}
+
+	absoluteErrors, percentageErrors, hits := 0.0, 0.0, 0
+	percentagePoints := 0
+	for _, o := range observations {
+		absoluteErrors += math.Abs(o.Predicted - o.Actual)
+
+		if o.Actual != 0 {
+			percentageErrors += math.Abs((o.Actual - o.Predicted) / o.Actual)
+			percentagePoints++
+		}
+
+		if sign(o.Predicted-o.Reference) == sign(o.Actual-o.Reference) {
+			hits++
+		}
+	}
+
+	accuracy.MAE = absoluteErrors / float64(len(observations))
+	accuracy.DirectionalAccuracy = float64(hits) / float64(len(observations))
+	if percentagePoints > 0 {
+		accuracy.MAPE = percentageErrors / float64(percentagePoints) * 100
+	}
+
+	return accuracy
+}
+
+func sign(value float64) int {
+	switch {
+	case value > 0:
+		return 1
+	case value < 0:
+		return -1
+	default:
+		return 0
+	}
+}
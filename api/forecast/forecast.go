@@ -0,0 +1,177 @@
+package forecast
+
+// forecast predicts the next trading days of a stock from the trailing history
+// with deterministic statistical models, it does not depend on external services
+
+import (
+	"api/models"
+	"api/models/filters"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// names of the supported models
+const (
+	Holt   = "holt"
+	Linear = "linear"
+)
+
+// Models all the supported models
+var Models = []string{Holt, Linear}
+
+// limits of the days to forecast and the min number of prices of the history
+const (
+	MinDays    = 7
+	MaxDays    = 14
+	MinHistory = 10
+)
+
+var (
+	ErrUnknownModel    = fmt.Errorf("unknown model: the supported models are %s", strings.Join(Models, ", "))
+	ErrNotEnoughPrices = fmt.Errorf("not enough historical prices: at least %d are required", MinHistory)
+)
+
+// IsModel returns true if the model is supported
+func IsModel(model string) bool {
+	for _, m := range Models {
+		if m == model {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Forecast predicts the prices of the next trading days with the model
+// the days are limited between MinDays and MaxDays, the prices can be in any order
+//
+// the close is predicted by the model, the open is the previous close and the
+// high, low and volume are the averages of the history
+func Forecast(symbol string, prices []models.HistoricalPrice, model string, days int) ([]models.HistoricalPrice, error) {
+	if !IsModel(model) {
+		return nil, ErrUnknownModel
+	}
+
+	if days < MinDays {
+		days = MinDays
+	}
+
+	if days > MaxDays {
+		days = MaxDays
+	}
+
+	history := models.SortPrices(prices)
+	if len(history) < MinHistory {
+		return nil, ErrNotEnoughPrices
+	}
+
+	closes := make([]float64, len(history))
+	for i, price := range history {
+		closes[i] = price.Close
+	}
+
+	var predicted []float64
+	switch model {
+	case Holt:
+		predicted = HoltForecast(closes, days)
+		// a falling trend takes the closes under zero, the returns of the linear model keep them positive
+		if !validCloses(predicted) {
+			predicted = LinearForecast(closes, days)
+		}
+	case Linear:
+		predicted = LinearForecast(closes, days)
+	}
+
+	return buildPrices(symbol, history, predicted)
+}
+
+// buildPrices builds the candles of the predicted closes
+func buildPrices(symbol string, history []models.HistoricalPrice, closes []float64) ([]models.HistoricalPrice, error) {
+	last := history[len(history)-1]
+	dates, err := nextTradingDays(last.Date, len(closes))
+	if err != nil {
+		return nil, err
+	}
+
+	spread, volume := averageSpreadAndVolume(history, 20)
+
+	result := make([]models.HistoricalPrice, 0, len(closes))
+	open := last.Close
+	if !validCloses(closes) {
+		return nil, errors.New("the model produced invalid prices")
+	}
+
+	for i, close := range closes {
+		high := math.Max(open, close) + close*spread/2
+		low := math.Max(math.Min(open, close)-close*spread/2, 0)
+
+		result = append(result, models.HistoricalPrice{
+			Symbol:  symbol,
+			Date:    dates[i],
+			Open:    filters.TruncateFloat(open, 2),
+			High:    filters.TruncateFloat(high, 2),
+			Low:     filters.TruncateFloat(low, 2),
+			Close:   filters.TruncateFloat(close, 2),
+			Volume:  filters.TruncateFloat(volume, 0),
+			Change:  filters.TruncateFloat(close-open, 2),
+			ChangeP: filters.TruncateFloat((close-open)/open, 5),
+			Vwap:    filters.TruncateFloat((high+low+close)/3, 4),
+		})
+
+		open = close
+	}
+
+	return result, nil
+}
+
+// validCloses checks the closes are positive numbers
+func validCloses(closes []float64) bool {
+	for _, close := range closes {
+		if close <= 0 || math.IsNaN(close) || math.IsInf(close, 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// averageSpreadAndVolume returns the average of (high - low) / close and of the volume
+// of the last n prices
+func averageSpreadAndVolume(history []models.HistoricalPrice, n int) (float64, float64) {
+	if len(history) > n {
+		history = history[len(history)-n:]
+	}
+
+	spread, volume := 0.0, 0.0
+	for _, price := range history {
+		if price.High > price.Low {
+			spread += (price.High - price.Low) / price.Close
+		}
+		volume += price.Volume
+	}
+
+	return spread / float64(len(history)), volume / float64(len(history))
+}
+
+// nextTradingDays returns the n week days after the date (YYYY-MM-DD)
+func nextTradingDays(date string, n int) ([]string, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date of the last price %s: %w", date, err)
+	}
+
+	dates := make([]string, 0, n)
+	for len(dates) < n {
+		day = day.AddDate(0, 0, 1)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+
+	return dates, nil
+}
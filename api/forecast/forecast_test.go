@@ -0,0 +1,182 @@
+package forecast
+
+import (
+	"api/models"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildHistory builds n prices since 2025-01-06 (monday) with the closes of the function
+// ordered from the newest to the oldest like the financial api
+func buildHistory(n int, closeFn func(i int) float64) []models.HistoricalPrice {
+	prices := make([]models.HistoricalPrice, 0, n)
+	day := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			day = day.AddDate(0, 0, 1)
+		}
+
+		close := closeFn(i)
+		prices = append([]models.HistoricalPrice{{
+			Symbol: "TEST",
+			Date:   day.Format("2006-01-02"),
+			Open:   close,
+			High:   close * 1.01,
+			Low:    close * 0.99,
+			Close:  close,
+			Volume: 1000,
+		}}, prices...)
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return prices
+}
+
+func TestHoltForecast(t *testing.T) {
+	values := make([]float64, 30)
+	for i := range values {
+		values[i] = 10 + 2*float64(i)
+	}
+
+	// a linear series is continued exactly
+	result := HoltForecast(values, 3)
+	assert.InDeltaSlice(t, []float64{70, 72, 74}, result, 1e-9)
+
+	// a constant series stays constant
+	assert.InDeltaSlice(t, []float64{5, 5}, HoltForecast([]float64{5, 5, 5, 5, 5}, 2), 1e-9)
+}
+
+func TestFitHolt(t *testing.T) {
+	// the best factors of a noisy series are inside the grid
+	values := []float64{10, 12, 11, 13, 12, 14, 13, 15, 14, 16, 15, 17}
+	alpha, beta := FitHolt(values)
+
+	assert.GreaterOrEqual(t, alpha, 0.1)
+	assert.LessOrEqual(t, alpha, 0.9)
+	assert.GreaterOrEqual(t, beta, 0.1)
+	assert.LessOrEqual(t, beta, 0.9)
+
+	_, _, best := holt(values, alpha, beta)
+	_, _, other := holt(values, 0.9, 0.9)
+	assert.LessOrEqual(t, best, other)
+}
+
+func TestLinearForecast(t *testing.T) {
+	// a series with a constant growth of 1% is continued with the same growth
+	values := make([]float64, 20)
+	for i := range values {
+		values[i] = 100 * math.Pow(1.01, float64(i))
+	}
+
+	result := LinearForecast(values, 2)
+	assert.InDeltaSlice(t, []float64{100 * math.Pow(1.01, 20), 100 * math.Pow(1.01, 21)}, result, 1e-9)
+}
+
+func TestForecast(t *testing.T) {
+	linearHistory := buildHistory(20, func(i int) float64 { return 100 + float64(i) })
+	fallingHistory := buildHistory(12, func(i int) float64 { return 100 - 8*float64(i) })
+
+	testCases := []struct {
+		desc          string
+		prices        []models.HistoricalPrice
+		model         string
+		days          int
+		expectedDays  int
+		expectedError error
+	}{
+		{desc: "holt", prices: linearHistory, model: Holt, days: 7, expectedDays: 7},
+		{desc: "linear", prices: linearHistory, model: Linear, days: 10, expectedDays: 10},
+		{desc: "days under the min", prices: linearHistory, model: Holt, days: 1, expectedDays: MinDays},
+		{desc: "days over the max", prices: linearHistory, model: Holt, days: 30, expectedDays: MaxDays},
+		{desc: "holt with falling trend", prices: fallingHistory, model: Holt, days: 14, expectedDays: 14},
+		{desc: "unknown model", prices: linearHistory, model: "arima", days: 7, expectedError: ErrUnknownModel},
+		{desc: "not enough prices", prices: linearHistory[:MinHistory-1], model: Holt, days: 7, expectedError: ErrNotEnoughPrices},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			result, err := Forecast("TEST", tC.prices, tC.model, tC.days)
+
+			if tC.expectedError != nil {
+				assert.ErrorIs(t, err, tC.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, result, tC.expectedDays)
+			for _, price := range result {
+				assert.Greater(t, price.Close, 0.0)
+			}
+		})
+	}
+}
+
+func TestForecastPrices(t *testing.T) {
+	// last price on friday 2025-01-31 with close 119
+	history := buildHistory(20, func(i int) float64 { return 100 + float64(i) })
+
+	result, err := Forecast("TEST", history, Holt, 7)
+	assert.NoError(t, err)
+
+	expectedDates := []string{"2025-02-03", "2025-02-04", "2025-02-05", "2025-02-06", "2025-02-07", "2025-02-10", "2025-02-11"}
+	for i, price := range result {
+		close := 120 + float64(i)
+		assert.Equal(t, "TEST", price.Symbol)
+		assert.Equal(t, expectedDates[i], price.Date, fmt.Sprintf("index %d", i))
+		assert.InDelta(t, close, price.Close, 0.01)
+		assert.InDelta(t, close-1, price.Open, 0.01)
+		assert.Greater(t, price.High, price.Close)
+		assert.Less(t, price.Low, price.Open)
+		assert.Equal(t, float64(1000), price.Volume)
+		assert.InDelta(t, 1, price.Change, 0.01)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		observations []Observation
+		expected     Accuracy
+	}{
+		{
+			desc:         "no observations",
+			observations: nil,
+			expected:     Accuracy{},
+		},
+		{
+			desc: "errors and directions",
+			observations: []Observation{
+				{Reference: 100, Predicted: 110, Actual: 105}, // up - up, error 5, 4.7619%
+				{Reference: 105, Predicted: 100, Actual: 110}, // down - up, error 10, 9.0909%
+				{Reference: 110, Predicted: 108, Actual: 100}, // down - down, error 8, 8%
+				{Reference: 100, Predicted: 100, Actual: 100}, // flat - flat, error 0
+			},
+			expected: Accuracy{
+				Points:              4,
+				MAE:                 23.0 / 4,
+				MAPE:                (5.0/105 + 10.0/110 + 8.0/100) / 4 * 100,
+				DirectionalAccuracy: 0.75,
+			},
+		},
+		{
+			desc:         "actual 0 is not included in the MAPE",
+			observations: []Observation{{Reference: 1, Predicted: 1, Actual: 0}, {Reference: 1, Predicted: 2, Actual: 4}},
+			expected:     Accuracy{Points: 2, MAE: 1.5, MAPE: 50, DirectionalAccuracy: 0.5},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			result := Evaluate(tC.observations)
+
+			assert.Equal(t, tC.expected.Points, result.Points)
+			assert.InDelta(t, tC.expected.MAE, result.MAE, 1e-9)
+			assert.InDelta(t, tC.expected.MAPE, result.MAPE, 1e-9)
+			assert.InDelta(t, tC.expected.DirectionalAccuracy, result.DirectionalAccuracy, 1e-9)
+		})
+	}
+}
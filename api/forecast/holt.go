@@ -0,0 +1,63 @@
+package forecast
+
+import "math"
+
+// HoltForecast double exponential smoothing (level and trend) of Holt
+// alpha and beta are chosen with FitHolt
+func HoltForecast(values []float64, horizon int) []float64 {
+	alpha, beta := FitHolt(values)
+	level, trend, _ := holt(values, alpha, beta)
+
+	result := make([]float64, horizon)
+	for h := range result {
+		result[h] = level + float64(h+1)*trend
+	}
+
+	return result
+}
+
+// FitHolt searches in a grid the smoothing factors with the lowest
+// sum of squared errors of the one step ahead forecasts
+func FitHolt(values []float64) (float64, float64) {
+	bestAlpha, bestBeta := 0.5, 0.1
+	bestSSE := math.Inf(1)
+
+	for a := 1; a <= 9; a++ {
+		for b := 1; b <= 9; b++ {
+			alpha, beta := float64(a)/10, float64(b)/10
+
+			_, _, sse := holt(values, alpha, beta)
+			if sse < bestSSE {
+				bestAlpha, bestBeta, bestSSE = alpha, beta, sse
+			}
+		}
+	}
+
+	return bestAlpha, bestBeta
+}
+
+// holt returns the last level and trend and the sum of squared errors
+// the level starts in the first value and the trend in the first difference
+func holt(values []float64, alpha float64, beta float64) (float64, float64, float64) {
+	if len(values) < 2 {
+		if len(values) == 1 {
+			return values[0], 0, 0
+		}
+		return 0, 0, 0
+	}
+
+	level := values[0]
+	trend := values[1] - values[0]
+	sse := 0.0
+
+	for _, value := range values[1:] {
+		predicted := level + trend
+		sse += (value - predicted) * (value - predicted)
+
+		previousLevel := level
+		level = alpha*value + (1-alpha)*(level+trend)
+		trend = beta*(level-previousLevel) + (1-beta)*trend
+	}
+
+	return level, trend, sse
+}
@@ -0,0 +1,49 @@
+package forecast
+
+import "math"
+
+// LinearForecast linear regression of the cumulative log returns over the time
+// the slope is the daily drift, the forecast grows from the last value with that drift
+func LinearForecast(values []float64, horizon int) []float64 {
+	result := make([]float64, horizon)
+	if len(values) == 0 {
+		return result
+	}
+
+	logs := make([]float64, len(values))
+	for i, value := range values {
+		logs[i] = math.Log(value / values[0])
+	}
+
+	slope := regressionSlope(logs)
+	last := values[len(values)-1]
+	for h := range result {
+		result[h] = last * math.Exp(slope*float64(h+1))
+	}
+
+	return result
+}
+
+// regressionSlope slope of the ordinary least squares of the values over their index
+func regressionSlope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+
+	meanX := (n - 1) / 2
+	meanY := 0.0
+	for _, value := range values {
+		meanY += value
+	}
+	meanY /= n
+
+	covariance, variance := 0.0, 0.0
+	for i, value := range values {
+		dx := float64(i) - meanX
+		covariance += dx * (value - meanY)
+		variance += dx * dx
+	}
+
+	return covariance / variance
+}
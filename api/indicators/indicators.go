@@ -0,0 +1,286 @@
+package indicators
+
+// indicators calculates the technical indicators of the historical prices
+// the series are ordered from the oldest to the newest price, the values
+// that can not be calculated yet (warm up of the period) are NaN
+
+import (
+	"api/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// names of the supported indicators
+const (
+	SMA       = "sma"
+	EMA       = "ema"
+	RSI       = "rsi"
+	MACD      = "macd"
+	Bollinger = "bollinger"
+	ATR       = "atr"
+	OBV       = "obv"
+)
+
+// Names all the supported indicators in the default order
+var Names = []string{SMA, EMA, RSI, MACD, Bollinger, ATR, OBV}
+
+// MaxPeriod is the max period allowed in the config
+const MaxPeriod = 200
+
+// Config indicators to calculate and their periods
+type Config struct {
+	Indicators      []string
+	SMAPeriod       int
+	EMAPeriod       int
+	RSIPeriod       int
+	MACDFast        int
+	MACDSlow        int
+	MACDSignal      int
+	BollingerPeriod int
+	BollingerStdDev float64
+	ATRPeriod       int
+}
+
+// DefaultConfig returns all the indicators with the usual periods
+func DefaultConfig() Config {
+	return Config{
+		Indicators:      append([]string(nil), Names...),
+		SMAPeriod:       20,
+		EMAPeriod:       20,
+		RSIPeriod:       14,
+		MACDFast:        12,
+		MACDSlow:        26,
+		MACDSignal:      9,
+		BollingerPeriod: 20,
+		BollingerStdDev: 2,
+		ATRPeriod:       14,
+	}
+}
+
+// Validate checks the names of the indicators and the periods
+func (c Config) Validate() error {
+	if len(c.Indicators) == 0 {
+		return errors.New("at least one indicator is required")
+	}
+
+	for _, name := range c.Indicators {
+		if !isSupported(name) {
+			return fmt.Errorf("unknown indicator %s: the supported indicators are %s", name, strings.Join(Names, ", "))
+		}
+	}
+
+	periods := map[string]int{
+		"smaPeriod":       c.SMAPeriod,
+		"emaPeriod":       c.EMAPeriod,
+		"rsiPeriod":       c.RSIPeriod,
+		"macdFast":        c.MACDFast,
+		"macdSlow":        c.MACDSlow,
+		"macdSignal":      c.MACDSignal,
+		"bollingerPeriod": c.BollingerPeriod,
+		"atrPeriod":       c.ATRPeriod,
+	}
+
+	for name, period := range periods {
+		if period < 1 || period > MaxPeriod {
+			return fmt.Errorf("%s must be between 1 and %d", name, MaxPeriod)
+		}
+	}
+
+	if c.MACDFast >= c.MACDSlow {
+		return errors.New("macdFast must be lower than macdSlow")
+	}
+
+	if c.BollingerStdDev <= 0 || c.BollingerStdDev > 5 {
+		return errors.New("bollingerStdDev must be greater than 0 and at most 5")
+	}
+
+	return nil
+}
+
+// Lookback returns the number of prices needed before the first value of the range
+// so all the selected indicators have values since the first date
+func (c Config) Lookback() int {
+	lookback := 0
+	for _, name := range c.Indicators {
+		var bars int
+		switch name {
+		case SMA:
+			bars = c.SMAPeriod
+		case EMA:
+			bars = c.EMAPeriod * 2
+		case RSI:
+			bars = c.RSIPeriod*2 + 1
+		case MACD:
+			bars = c.MACDSlow*2 + c.MACDSignal
+		case Bollinger:
+			bars = c.BollingerPeriod
+		case ATR:
+			bars = c.ATRPeriod*2 + 1
+		}
+
+		if bars > lookback {
+			lookback = bars
+		}
+	}
+
+	return lookback
+}
+
+// Key returns an unique representation of the config, used as cache key
+func (c Config) Key() string {
+	names := append([]string(nil), c.Indicators...)
+	sort.Strings(names)
+
+	return fmt.Sprintf("%s:%d:%d:%d:%d-%d-%d:%d-%g:%d",
+		strings.Join(names, ","),
+		c.SMAPeriod, c.EMAPeriod, c.RSIPeriod,
+		c.MACDFast, c.MACDSlow, c.MACDSignal,
+		c.BollingerPeriod, c.BollingerStdDev,
+		c.ATRPeriod,
+	)
+}
+
+func isSupported(name string) bool {
+	for _, supported := range Names {
+		if name == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Point value of an indicator in a date
+type Point struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// Indicator result of an indicator, the indicators with more than one line
+// (MACD, Bollinger Bands) have a series per line
+type Indicator struct {
+	Params map[string]float64 `json:"params"`
+	Series map[string][]Point `json:"series"`
+}
+
+// Since returns the indicator only with the points since the date (YYYY-MM-DD)
+func (i Indicator) Since(date string) Indicator {
+	result := Indicator{
+		Params: i.Params,
+		Series: make(map[string][]Point, len(i.Series)),
+	}
+
+	for name, points := range i.Series {
+		start := sort.Search(len(points), func(j int) bool { return points[j].Date >= date })
+		result.Series[name] = points[start:]
+	}
+
+	return result
+}
+
+// Compute calculates the indicators of the config over the prices
+// the prices can be in any order, they are sorted by date
+func Compute(prices []models.HistoricalPrice, config Config) map[string]Indicator {
+	sorted := append([]models.HistoricalPrice(nil), prices...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+
+	dates := make([]string, len(sorted))
+	high := make([]float64, len(sorted))
+	low := make([]float64, len(sorted))
+	closes := make([]float64, len(sorted))
+	volume := make([]float64, len(sorted))
+	for i, price := range sorted {
+		dates[i] = price.Date
+		high[i] = price.High
+		low[i] = price.Low
+		closes[i] = price.Close
+		volume[i] = price.Volume
+	}
+
+	result := make(map[string]Indicator, len(config.Indicators))
+	for _, name := range config.Indicators {
+		switch name {
+		case SMA:
+			result[name] = Indicator{
+				Params: map[string]float64{"period": float64(config.SMAPeriod)},
+				Series: map[string][]Point{"sma": toPoints(dates, SimpleMovingAverage(closes, config.SMAPeriod))},
+			}
+		case EMA:
+			result[name] = Indicator{
+				Params: map[string]float64{"period": float64(config.EMAPeriod)},
+				Series: map[string][]Point{"ema": toPoints(dates, ExponentialMovingAverage(closes, config.EMAPeriod))},
+			}
+		case RSI:
+			result[name] = Indicator{
+				Params: map[string]float64{"period": float64(config.RSIPeriod)},
+				Series: map[string][]Point{"rsi": toPoints(dates, RelativeStrengthIndex(closes, config.RSIPeriod))},
+			}
+		case MACD:
+			macd, signal, histogram := MovingAverageConvergenceDivergence(closes, config.MACDFast, config.MACDSlow, config.MACDSignal)
+			result[name] = Indicator{
+				Params: map[string]float64{
+					"fast":   float64(config.MACDFast),
+					"slow":   float64(config.MACDSlow),
+					"signal": float64(config.MACDSignal),
+				},
+				Series: map[string][]Point{
+					"macd":      toPoints(dates, macd),
+					"signal":    toPoints(dates, signal),
+					"histogram": toPoints(dates, histogram),
+				},
+			}
+		case Bollinger:
+			middle, upper, lower := BollingerBands(closes, config.BollingerPeriod, config.BollingerStdDev)
+			result[name] = Indicator{
+				Params: map[string]float64{
+					"period": float64(config.BollingerPeriod),
+					"stdDev": config.BollingerStdDev,
+				},
+				Series: map[string][]Point{
+					"middle": toPoints(dates, middle),
+					"upper":  toPoints(dates, upper),
+					"lower":  toPoints(dates, lower),
+				},
+			}
+		case ATR:
+			result[name] = Indicator{
+				Params: map[string]float64{"period": float64(config.ATRPeriod)},
+				Series: map[string][]Point{"atr": toPoints(dates, AverageTrueRange(high, low, closes, config.ATRPeriod))},
+			}
+		case OBV:
+			result[name] = Indicator{
+				Params: map[string]float64{},
+				Series: map[string][]Point{"obv": toPoints(dates, OnBalanceVolume(closes, volume))},
+			}
+		}
+	}
+
+	return result
+}
+
+// toPoints joins the dates with the values, the NaN values are skipped
+func toPoints(dates []string, values []float64) []Point {
+	points := make([]Point, 0, len(values))
+	for i, value := range values {
+		if math.IsNaN(value) {
+			continue
+		}
+
+		points = append(points, Point{Date: dates[i], Value: value})
+	}
+
+	return points
+}
+
+// nanSlice returns a slice of n NaN values
+func nanSlice(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+
+	return values
+}
@@ -0,0 +1,190 @@
+package indicators
+
+import (
+	"api/models"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// closes of the RSI example of Wilder in "New Concepts in Technical Trading Systems"
+var wilderCloses = []float64{
+	44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+	45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+}
+
+// assertSeries compares the series, NaN is expected where the value is not calculated yet
+func assertSeries(t *testing.T, expected []float64, actual []float64) {
+	t.Helper()
+
+	if !assert.Len(t, actual, len(expected)) {
+		return
+	}
+
+	for i := range expected {
+		if math.IsNaN(expected[i]) {
+			assert.True(t, math.IsNaN(actual[i]), "index %d: expected NaN, got %f", i, actual[i])
+			continue
+		}
+
+		assert.InDelta(t, expected[i], actual[i], 1e-6, "index %d", i)
+	}
+}
+
+var nan = math.NaN()
+
+func TestSimpleMovingAverage(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		values   []float64
+		period   int
+		expected []float64
+	}{
+		{desc: "period 3", values: []float64{1, 2, 3, 4, 5, 6}, period: 3, expected: []float64{nan, nan, 2, 3, 4, 5}},
+		{desc: "period 1", values: []float64{1, 2}, period: 1, expected: []float64{1, 2}},
+		{desc: "not enough values", values: []float64{1, 2}, period: 3, expected: []float64{nan, nan}},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assertSeries(t, tC.expected, SimpleMovingAverage(tC.values, tC.period))
+		})
+	}
+}
+
+func TestExponentialMovingAverage(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		values   []float64
+		period   int
+		expected []float64
+	}{
+		// alpha 0.5, seed the sma of 2, 4, 6
+		{desc: "period 3", values: []float64{2, 4, 6, 8, 12}, period: 3, expected: []float64{nan, nan, 4, 6, 9}},
+		{desc: "skip leading NaN", values: []float64{nan, 2, 4, 6, 8}, period: 3, expected: []float64{nan, nan, nan, 4, 6}},
+		{desc: "not enough values", values: []float64{1}, period: 2, expected: []float64{nan}},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assertSeries(t, tC.expected, ExponentialMovingAverage(tC.values, tC.period))
+		})
+	}
+}
+
+func TestRelativeStrengthIndex(t *testing.T) {
+	expected := nanSlice(len(wilderCloses))
+	// reference values of TA-Lib
+	copy(expected[14:], []float64{70.46413502109705, 66.24961855355505, 66.48094183471265, 69.34685316290866, 66.29471265892624, 57.91502067008556})
+
+	assertSeries(t, expected, RelativeStrengthIndex(wilderCloses, 14))
+
+	assertSeries(t, []float64{nan, nan, 100, 100}, RelativeStrengthIndex([]float64{1, 2, 3, 4}, 2))
+	assertSeries(t, []float64{nan, 50, 50}, RelativeStrengthIndex([]float64{1, 1, 1}, 1))
+}
+
+func TestMovingAverageConvergenceDivergence(t *testing.T) {
+	values := []float64{2, 4, 6, 8, 12, 10}
+
+	// fast ema(2): -, 3, 5, 7, 10.333333, 10.111111
+	// slow ema(3): -, -, 4, 6, 9, 9.5
+	// macd: -, -, 1, 1, 1.333333, 0.611111
+	// signal ema(2) of macd: -, -, -, 1, 1.222222, 0.814815
+	macd, signal, histogram := MovingAverageConvergenceDivergence(values, 2, 3, 2)
+
+	assertSeries(t, []float64{nan, nan, 1, 1, 4.0 / 3, 11.0 / 18}, macd)
+	assertSeries(t, []float64{nan, nan, nan, 1, 11.0 / 9, 22.0 / 27}, signal)
+	assertSeries(t, []float64{nan, nan, nan, 0, 1.0/3 - 2.0/9, 11.0/18 - 22.0/27}, histogram)
+}
+
+func TestBollingerBands(t *testing.T) {
+	// values of period 4: 2, 4, 4, 4 -> mean 3.5, population std dev 0.866025
+	// values of period 4: 4, 4, 4, 5 -> mean 4.25, population std dev 0.433013
+	middle, upper, lower := BollingerBands([]float64{2, 4, 4, 4, 5}, 4, 2)
+
+	assertSeries(t, []float64{nan, nan, nan, 3.5, 4.25}, middle)
+	assertSeries(t, []float64{nan, nan, nan, 3.5 + 2*math.Sqrt(0.75), 4.25 + 2*math.Sqrt(0.1875)}, upper)
+	assertSeries(t, []float64{nan, nan, nan, 3.5 - 2*math.Sqrt(0.75), 4.25 - 2*math.Sqrt(0.1875)}, lower)
+}
+
+func TestAverageTrueRange(t *testing.T) {
+	high := []float64{10, 11, 12, 11}
+	low := []float64{8, 9, 10, 7}
+	closes := []float64{9, 10, 11, 8}
+
+	// true ranges: 2, 2, 2, 4
+	// atr(2): -, 2, 2, 3
+	assertSeries(t, []float64{nan, 2, 2, 3}, AverageTrueRange(high, low, closes, 2))
+
+	// gap up: true range is high - previous close
+	assertSeries(t, []float64{2, 6}, AverageTrueRange([]float64{10, 16}, []float64{8, 14}, []float64{10, 15}, 1))
+}
+
+func TestOnBalanceVolume(t *testing.T) {
+	closes := []float64{10, 11, 11, 9, 12}
+	volume := []float64{100, 200, 300, 400, 500}
+
+	assertSeries(t, []float64{0, 200, 200, -200, 300}, OnBalanceVolume(closes, volume))
+}
+
+func TestCompute(t *testing.T) {
+	// prices ordered from the newest to the oldest like the financial api
+	prices := []models.HistoricalPrice{
+		{Date: "2025-01-06", Close: 12, High: 12, Low: 11, Volume: 10},
+		{Date: "2025-01-03", Close: 8, High: 9, Low: 7, Volume: 10},
+		{Date: "2025-01-02", Close: 6, High: 7, Low: 5, Volume: 10},
+		{Date: "2025-01-01", Close: 4, High: 5, Low: 3, Volume: 10},
+		{Date: "2024-12-31", Close: 2, High: 3, Low: 1, Volume: 10},
+	}
+
+	config := DefaultConfig()
+	config.Indicators = []string{SMA, OBV}
+	config.SMAPeriod = 3
+
+	result := Compute(prices, config)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, map[string]float64{"period": 3}, result[SMA].Params)
+	assert.Equal(t, []Point{
+		{Date: "2025-01-02", Value: 4},
+		{Date: "2025-01-03", Value: 6},
+		{Date: "2025-01-06", Value: 26.0 / 3},
+	}, result[SMA].Series["sma"])
+
+	since := result[OBV].Since("2025-01-03")
+	assert.Equal(t, []Point{
+		{Date: "2025-01-03", Value: 30},
+		{Date: "2025-01-06", Value: 40},
+	}, since.Series["obv"])
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		modify   func(c *Config)
+		hasError bool
+	}{
+		{desc: "default", modify: func(c *Config) {}},
+		{desc: "no indicators", modify: func(c *Config) { c.Indicators = nil }, hasError: true},
+		{desc: "unknown indicator", modify: func(c *Config) { c.Indicators = []string{"vwap"} }, hasError: true},
+		{desc: "period 0", modify: func(c *Config) { c.RSIPeriod = 0 }, hasError: true},
+		{desc: "period over the max", modify: func(c *Config) { c.SMAPeriod = MaxPeriod + 1 }, hasError: true},
+		{desc: "fast over slow", modify: func(c *Config) { c.MACDFast = 30 }, hasError: true},
+		{desc: "invalid std dev", modify: func(c *Config) { c.BollingerStdDev = 0 }, hasError: true},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			config := DefaultConfig()
+			tC.modify(&config)
+
+			err := config.Validate()
+			if tC.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package indicators
+
+import "math"
+
+// RelativeStrengthIndex RSI of Wilder, between 0 and 100
+// the first value is in the index period
+func RelativeStrengthIndex(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period < 1 || len(values) <= period {
+		return result
+	}
+
+	gains := make([]float64, len(values))
+	losses := make([]float64, len(values))
+	for i := 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gains[i] = change
+		} else {
+			losses[i] = -change
+		}
+	}
+
+	avgGains := wilderAverage(gains, period, 1)
+	avgLosses := wilderAverage(losses, period, 1)
+	for i := period; i < len(values); i++ {
+		switch {
+		case avgLosses[i] == 0 && avgGains[i] == 0:
+			result[i] = 50
+		case avgLosses[i] == 0:
+			result[i] = 100
+		default:
+			result[i] = 100 - 100/(1+avgGains[i]/avgLosses[i])
+		}
+	}
+
+	return result
+}
+
+// MovingAverageConvergenceDivergence MACD line (fast EMA - slow EMA),
+// signal line (EMA of the MACD line) and histogram (MACD - signal)
+func MovingAverageConvergenceDivergence(values []float64, fast int, slow int, signal int) ([]float64, []float64, []float64) {
+	fastEMA := ExponentialMovingAverage(values, fast)
+	slowEMA := ExponentialMovingAverage(values, slow)
+
+	macd := nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(fastEMA[i]) && !math.IsNaN(slowEMA[i]) {
+			macd[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+
+	signalLine := ExponentialMovingAverage(macd, signal)
+
+	histogram := nanSlice(len(values))
+	for i := range values {
+		if !math.IsNaN(signalLine[i]) {
+			histogram[i] = macd[i] - signalLine[i]
+		}
+	}
+
+	return macd, signalLine, histogram
+}
@@ -0,0 +1,81 @@
+package indicators
+
+import "math"
+
+// SimpleMovingAverage average of the last period values
+func SimpleMovingAverage(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period < 1 || len(values) < period {
+		return result
+	}
+
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= period {
+			sum -= values[i-period]
+		}
+
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+
+	return result
+}
+
+// ExponentialMovingAverage moving average with weight 2/(period+1) for the last value
+// the first value is the simple moving average of the first period values
+// the NaN values at the start of the input are skipped
+func ExponentialMovingAverage(values []float64, period int) []float64 {
+	result := nanSlice(len(values))
+	if period < 1 {
+		return result
+	}
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+
+	if len(values)-start < period {
+		return result
+	}
+
+	sum := 0.0
+	for _, value := range values[start : start+period] {
+		sum += value
+	}
+
+	seed := start + period - 1
+	result[seed] = sum / float64(period)
+
+	alpha := 2 / float64(period+1)
+	for i := seed + 1; i < len(values); i++ {
+		result[i] = alpha*values[i] + (1-alpha)*result[i-1]
+	}
+
+	return result
+}
+
+// wilderAverage smoothed moving average of Wilder used by RSI and ATR
+// the first value is the simple average of the values from start to start+period-1
+func wilderAverage(values []float64, period int, start int) []float64 {
+	result := nanSlice(len(values))
+	if period < 1 || len(values)-start < period {
+		return result
+	}
+
+	sum := 0.0
+	for _, value := range values[start : start+period] {
+		sum += value
+	}
+
+	seed := start + period - 1
+	result[seed] = sum / float64(period)
+	for i := seed + 1; i < len(values); i++ {
+		result[i] = (result[i-1]*float64(period-1) + values[i]) / float64(period)
+	}
+
+	return result
+}
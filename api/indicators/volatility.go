@@ -0,0 +1,43 @@
+package indicators
+
+import "math"
+
+// BollingerBands middle band (SMA), upper and lower bands at stdDev
+// population standard deviations of the last period values
+func BollingerBands(values []float64, period int, stdDev float64) ([]float64, []float64, []float64) {
+	middle := SimpleMovingAverage(values, period)
+	upper := nanSlice(len(values))
+	lower := nanSlice(len(values))
+
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			continue
+		}
+
+		variance := 0.0
+		for _, value := range values[i-period+1 : i+1] {
+			variance += (value - middle[i]) * (value - middle[i])
+		}
+		deviation := math.Sqrt(variance / float64(period))
+
+		upper[i] = middle[i] + stdDev*deviation
+		lower[i] = middle[i] - stdDev*deviation
+	}
+
+	return middle, upper, lower
+}
+
+// AverageTrueRange ATR of Wilder, the true range of the first price is high - low
+// the first value is the average of the first period true ranges
+func AverageTrueRange(high []float64, low []float64, closes []float64, period int) []float64 {
+	trueRanges := make([]float64, len(closes))
+	for i := range closes {
+		trueRanges[i] = high[i] - low[i]
+		if i > 0 {
+			trueRanges[i] = math.Max(trueRanges[i], math.Abs(high[i]-closes[i-1]))
+			trueRanges[i] = math.Max(trueRanges[i], math.Abs(low[i]-closes[i-1]))
+		}
+	}
+
+	return wilderAverage(trueRanges, period, 0)
+}
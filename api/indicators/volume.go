@@ -0,0 +1,19 @@
+package indicators
+
+// OnBalanceVolume cumulative volume, added when the close goes up
+// and subtracted when it goes down, starts in 0
+func OnBalanceVolume(closes []float64, volume []float64) []float64 {
+	result := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		switch {
+		case closes[i] > closes[i-1]:
+			result[i] = result[i-1] + volume[i]
+		case closes[i] < closes[i-1]:
+			result[i] = result[i-1] - volume[i]
+		default:
+			result[i] = result[i-1]
+		}
+	}
+
+	return result
+}
@@ -28,13 +28,12 @@ func main() {
 		panic(fmt.Errorf("error getting cache db: %v", err))
 	}
 
-	defer db.Close()
-	defer cache.Close()
-
 	// initial fill database with initial data with fill-db command if the arguments are more than 1
 	err = cmd.NewCmd().Execute()
 	if err != nil {
 		apilogger.Logger().Err(err).Msg("Error executing command")
+		db.Close()
+		cache.Close()
 		panic(err)
 	}
 
@@ -44,6 +43,7 @@ func main() {
 		cache,
 	)
 
+	// the server closes the database and the cache when it stops
 	server := server.NewServer(configServer)
 	err = server.Start()
 	if err != nil {
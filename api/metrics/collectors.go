@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// collectors of the api, the labels must have a bounded number of values
+// they are registered in the default registry of prometheus, with the go and process collectors
+
+// DefaultBuckets buckets in seconds for the latencies of the requests
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total of HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of the HTTP requests by method and chi route pattern.",
+		Buckets: DefaultBuckets,
+	}, []string{"method", "route"})
+
+	CacheRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Total of cache lookups by key prefix and result (hit, miss, shared, error, canceled).",
+	}, []string{"cache", "result"})
+
+	UpstreamRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_requests_total",
+		Help: "Total of requests to external APIs by host and status code, status is error when the request failed and circuit_open when it was not sent.",
+	}, []string{"host", "status"})
+
+	UpstreamRetriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upstream_retries_total",
+		Help: "Total of retries of the requests to external APIs by host.",
+	}, []string{"host"})
+
+	UpstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upstream_request_duration_seconds",
+		Help:    "Latency of the requests to external APIs by host.",
+		Buckets: DefaultBuckets,
+	}, []string{"host"})
+
+	ProviderRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "provider_requests_total",
+		Help: "Total of calls to the market data providers by service, provider and result (success, error, not_found, skipped).",
+	}, []string{"service", "provider", "result"})
+
+	LLMRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_requests_total",
+		Help: "Total of calls to the LLM provider by provider, operation and result (success, error).",
+	}, []string{"provider", "operation", "result"})
+
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llm_request_duration_seconds",
+		Help:    "Latency of the calls to the LLM provider by provider and operation.",
+		Buckets: DefaultBuckets,
+	}, []string{"provider", "operation"})
+)
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	HTTPRequestsTotal.WithLabelValues("GET", `/a"b`, "200").Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := recorder.Body.String()
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.Contains(t, body, "# TYPE http_requests_total counter")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/a\"b",status="200"} 1`)
+	assert.Contains(t, body, "# TYPE go_goroutines gauge")
+	assert.Contains(t, body, "# TYPE process_cpu_seconds_total counter")
+}
+
+func TestCollectorsLint(t *testing.T) {
+	CacheRequestsTotal.WithLabelValues("tickers", "hit").Inc()
+	LLMRequestDuration.WithLabelValues("fake", "advice").Observe(0.2)
+
+	problems, err := testutil.GatherAndLint(prometheus.DefaultGatherer, "cache_requests_total", "llm_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
@@ -0,0 +1,12 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler returns the http handler of the metrics endpoint with the collectors of the default registry
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
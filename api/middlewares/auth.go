@@ -0,0 +1,154 @@
+package middlewares
+
+import (
+	"api/auth"
+	apilogger "api/logger"
+	"api/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ApiKeyAuthenticator resolves the identity of an api key
+type ApiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (auth.Identity, error)
+}
+
+// AuthOptions configuration of the authentication middleware
+// Required rejects the requests without credentials, PublicPaths are never authenticated
+type AuthOptions struct {
+	Required    bool
+	JWTSecret   []byte
+	PublicPaths []string
+}
+
+// Authenticate validates the api key or the HS256 JWT of the request
+// and stores the identity of the caller in the request context
+//
+// the credentials are read from the Authorization header (Bearer) or the X-API-Key header, see QueryToken for the streams,
+// invalid credentials are always rejected, missing credentials only when are required,
+// when the api keys can not be checked the request fails with 503
+func Authenticate(apiKeys ApiKeyAuthenticator, options AuthOptions) func(http.Handler) http.Handler {
+	publicPaths := make(map[string]bool, len(options.PublicPaths))
+	for _, path := range options.PublicPaths {
+		publicPaths[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			credential := readCredential(r)
+			if credential == "" {
+				if options.Required {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+					respondError(w, http.StatusUnauthorized, "Authentication required")
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			identity, err := authenticate(r.Context(), apiKeys, options.JWTSecret, credential)
+			if err != nil && auth.IsApiKey(credential) && apiKeys != nil && !errors.Is(err, auth.ErrInvalidApiKey) {
+				// the key could not be checked, it is not a problem of the credentials
+				apilogger.Logger().Error().Err(err).Msg("[Authenticate] failed to authenticate the api key")
+				respondError(w, http.StatusServiceUnavailable, "Authentication unavailable, try again later")
+				return
+			}
+
+			if err != nil {
+				apilogger.Logger().Debug().Err(err).Msg("[Authenticate] invalid credentials")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				respondError(w, http.StatusUnauthorized, "Invalid credentials")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// QueryTokenParam query parameter with the credential of the paths that browsers open without headers
+const QueryTokenParam = "access_token"
+
+// QueryToken moves the credential of the access_token query parameter to the Authorization header in the paths,
+// the EventSource and the WebSocket of the browsers can not send headers.
+// the parameter is removed from the url, so it must run before the logger to keep the credential out of the logs
+func QueryToken(paths ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		allowed[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if !query.Has(QueryTokenParam) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := strings.TrimSpace(query.Get(QueryTokenParam))
+			query.Del(QueryTokenParam)
+
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = query.Encode()
+			r.RequestURI = r.URL.RequestURI()
+			if allowed[r.URL.Path] && token != "" && readCredential(r) == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authenticate resolves the identity of an api key or a JWT
+func authenticate(ctx context.Context, apiKeys ApiKeyAuthenticator, secret []byte, credential string) (auth.Identity, error) {
+	if auth.IsApiKey(credential) {
+		if apiKeys == nil {
+			return auth.Identity{}, errors.New("api keys are not supported")
+		}
+
+		return apiKeys.Authenticate(ctx, credential)
+	}
+
+	claims, err := auth.ParseHS256(credential, secret, time.Now())
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	return auth.Identity{
+		Subject: claims.Subject,
+		Method:  auth.MethodJWT,
+	}, nil
+}
+
+// readCredential returns the bearer token or the api key of the request
+func readCredential(r *http.Request) string {
+	if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+		return apiKey
+	}
+
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+
+	return ""
+}
+
+// respondError sends a JSON error response with the format used by the controllers
+func respondError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.NewResponseError(message))
+}
@@ -0,0 +1,188 @@
+package middlewares
+
+import (
+	"api/auth"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeApiKeys struct {
+	keys map[string]string
+}
+
+func (f fakeApiKeys) Authenticate(ctx context.Context, rawKey string) (auth.Identity, error) {
+	if rawKey == "sv_unavailable" {
+		return auth.Identity{}, errors.New("connection refused")
+	}
+
+	subject, ok := f.keys[rawKey]
+	if !ok {
+		return auth.Identity{}, auth.ErrInvalidApiKey
+	}
+
+	return auth.Identity{Subject: subject, Method: auth.MethodApiKey}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("secret")
+	token, _ := auth.SignHS256(auth.Claims{Subject: "jwt-user", ExpiresAt: time.Now().Add(time.Hour).Unix()}, secret)
+	apiKeys := fakeApiKeys{keys: map[string]string{"sv_valid": "key-user"}}
+
+	testCases := []struct {
+		desc            string
+		required        bool
+		path            string
+		headers         map[string]string
+		expectedStatus  int
+		expectedSubject string
+	}{
+		{desc: "public path without credentials", required: true, path: "/health", expectedStatus: http.StatusOK},
+		{desc: "missing credentials required", required: true, path: "/api/v1/tickers", expectedStatus: http.StatusUnauthorized},
+		{desc: "missing credentials optional", required: false, path: "/api/v1/tickers", expectedStatus: http.StatusOK},
+		{
+			desc:            "valid api key in header",
+			required:        true,
+			path:            "/api/v1/tickers",
+			headers:         map[string]string{"X-API-Key": "sv_valid"},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "key-user",
+		},
+		{
+			desc:            "valid api key as bearer",
+			required:        true,
+			path:            "/api/v1/tickers",
+			headers:         map[string]string{"Authorization": "Bearer sv_valid"},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "key-user",
+		},
+		{
+			desc:            "valid jwt",
+			required:        true,
+			path:            "/api/v1/tickers",
+			headers:         map[string]string{"Authorization": "Bearer " + token},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "jwt-user",
+		},
+		{
+			desc:           "invalid api key is rejected even if optional",
+			required:       false,
+			path:           "/api/v1/tickers",
+			headers:        map[string]string{"X-API-Key": "sv_invalid"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:           "api keys unavailable",
+			required:       true,
+			path:           "/api/v1/tickers",
+			headers:        map[string]string{"X-API-Key": "sv_unavailable"},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:           "invalid jwt",
+			required:       true,
+			path:           "/api/v1/tickers",
+			headers:        map[string]string{"Authorization": "Bearer a.b.c"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var subject string
+			handler := Authenticate(apiKeys, AuthOptions{
+				Required:    tC.required,
+				JWTSecret:   secret,
+				PublicPaths: []string{"/health"},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ := auth.FromContext(r.Context())
+				subject = identity.Subject
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tC.path, nil)
+			for key, value := range tC.headers {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tC.expectedStatus, rec.Code)
+			assert.Equal(t, tC.expectedSubject, subject)
+		})
+	}
+}
+
+func TestQueryToken(t *testing.T) {
+	apiKeys := fakeApiKeys{keys: map[string]string{"sv_valid": "key-user", "sv_header": "header-user"}}
+
+	testCases := []struct {
+		desc            string
+		target          string
+		headers         map[string]string
+		expectedStatus  int
+		expectedSubject string
+		expectedURI     string
+	}{
+		{
+			desc:            "token of the stream",
+			target:          "/api/v1/stream?tickers=AAPL&access_token=sv_valid",
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "key-user",
+			expectedURI:     "/api/v1/stream?tickers=AAPL",
+		},
+		{
+			desc:           "token out of the streams is ignored",
+			target:         "/api/v1/tickers?access_token=sv_valid",
+			expectedStatus: http.StatusUnauthorized,
+			expectedURI:    "/api/v1/tickers",
+		},
+		{
+			desc:            "the header has priority",
+			target:          "/api/v1/ws?access_token=sv_valid",
+			headers:         map[string]string{"X-API-Key": "sv_header"},
+			expectedStatus:  http.StatusOK,
+			expectedSubject: "header-user",
+			expectedURI:     "/api/v1/ws",
+		},
+		{
+			desc:           "invalid token",
+			target:         "/api/v1/ws?access_token=sv_invalid",
+			expectedStatus: http.StatusUnauthorized,
+			expectedURI:    "/api/v1/ws",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			var subject, uri string
+			authenticated := Authenticate(apiKeys, AuthOptions{Required: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, _ := auth.FromContext(r.Context())
+				subject = identity.Subject
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			// the logger runs after QueryToken and prints the RequestURI
+			handler := QueryToken("/api/v1/stream", "/api/v1/ws")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				uri = r.RequestURI
+				authenticated.ServeHTTP(w, r)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tC.target, nil)
+			for key, value := range tC.headers {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tC.expectedStatus, rec.Code)
+			assert.Equal(t, tC.expectedSubject, subject)
+			assert.Equal(t, tC.expectedURI, uri)
+		})
+	}
+}
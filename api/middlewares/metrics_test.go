@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"api/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(Metrics)
+	router.Get("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	router.Get("/metrics-test/{id}/empty", func(w http.ResponseWriter, r *http.Request) {})
+
+	testCases := []struct {
+		desc   string
+		path   string
+		route  string
+		status string
+	}{
+		{desc: "route pattern", path: "/metrics-test/AAPL", route: "/metrics-test/{id}", status: "201"},
+		{desc: "implicit status", path: "/metrics-test/AAPL/empty", route: "/metrics-test/{id}/empty", status: "200"},
+		{desc: "not found", path: "/metrics-unknown", route: "not_found", status: "404"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			before := testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, tC.route, tC.status))
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tC.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, tC.route, tC.status)))
+		})
+	}
+}
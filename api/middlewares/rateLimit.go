@@ -0,0 +1,132 @@
+package middlewares
+
+import (
+	"api/auth"
+	"api/cache"
+	apilogger "api/logger"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitGroup is a group of routes that share the same limit
+// Patterns are matched with path.Match, example /api/v1/tickers/*/predictions
+type RateLimitGroup struct {
+	Name     string
+	Patterns []string
+	Limit    cache.RateLimit
+}
+
+// RateLimitOptions configuration of the rate limit middleware
+// the first group that matches the path is used, if none matches the Default limit is used
+// ExcludedPaths are never limited
+type RateLimitOptions struct {
+	Groups        []RateLimitGroup
+	Default       cache.RateLimit
+	ExcludedPaths []string
+}
+
+// RateLimit limits the requests of each client per route group
+// the client is the authenticated identity or the ip of the request
+//
+// over the limit responds 429 with Retry-After, all the responses have the X-RateLimit-* headers
+// if the limiter fails the request is allowed
+func RateLimit(limiter cache.IRateLimiter, options RateLimitOptions) func(http.Handler) http.Handler {
+	excluded := make(map[string]bool, len(options.ExcludedPaths))
+	for _, p := range options.ExcludedPaths {
+		excluded[p] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || excluded[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			groupName, limit := matchGroup(options, r.URL.Path)
+			if limit.Limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := fmt.Sprintf("ratelimit:%s:%s", groupName, clientKey(r))
+			result, err := limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				apilogger.Logger().Err(err).Msg("[RateLimit] failed to check the limit, request allowed")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				respondError(w, http.StatusTooManyRequests, "Too many requests, try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ParseRateLimit parses a limit with the format <requests>/<period>, example 10/1m or 100/1h
+// a limit of 0 disables the limit
+func ParseRateLimit(value string) (cache.RateLimit, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return cache.RateLimit{}, fmt.Errorf("invalid rate limit %q: the format must be <requests>/<period>", value)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit < 0 {
+		return cache.RateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", value)
+	}
+
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return cache.RateLimit{}, fmt.Errorf("invalid rate limit %q: period must be a duration like 1m", value)
+	}
+
+	return cache.RateLimit{Limit: limit, Period: period}, nil
+}
+
+// matchGroup returns the group of the path
+func matchGroup(options RateLimitOptions, urlPath string) (string, cache.RateLimit) {
+	for _, group := range options.Groups {
+		for _, pattern := range group.Patterns {
+			if ok, _ := path.Match(pattern, urlPath); ok {
+				return group.Name, group.Limit
+			}
+		}
+	}
+
+	return "default", options.Default
+}
+
+// clientKey identifies the client by the authenticated identity or the ip
+func clientKey(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok && identity.Subject != "" {
+		return "id:" + identity.Subject
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+
+	return "ip:" + ip
+}
+
+// ceilSeconds rounds up the duration to seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,134 @@
+package middlewares
+
+import (
+	"api/auth"
+	"api/cache"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLimiter counts the requests per key without refill
+type fakeLimiter struct {
+	counts map[string]int
+	err    error
+}
+
+func (f *fakeLimiter) Allow(ctx context.Context, key string, limit cache.RateLimit) (cache.RateLimitResult, error) {
+	if f.err != nil {
+		return cache.RateLimitResult{}, f.err
+	}
+
+	f.counts[key]++
+	remaining := limit.Limit - f.counts[key]
+	if remaining < 0 {
+		return cache.RateLimitResult{Allowed: false, Limit: limit.Limit, RetryAfter: 1500 * time.Millisecond, ResetAfter: limit.Period}, nil
+	}
+
+	return cache.RateLimitResult{Allowed: true, Limit: limit.Limit, Remaining: remaining, ResetAfter: limit.Period}, nil
+}
+
+func TestParseRateLimit(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected cache.RateLimit
+		hasError bool
+	}{
+		{value: "10/1m", expected: cache.RateLimit{Limit: 10, Period: time.Minute}},
+		{value: " 100/1h ", expected: cache.RateLimit{Limit: 100, Period: time.Hour}},
+		{value: "0/1s", expected: cache.RateLimit{Limit: 0, Period: time.Second}},
+		{value: "10", hasError: true},
+		{value: "a/1m", hasError: true},
+		{value: "10/minute", hasError: true},
+		{value: "-1/1m", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.value, func(t *testing.T) {
+			limit, err := ParseRateLimit(tC.value)
+			if tC.hasError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, limit)
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	options := RateLimitOptions{
+		Groups: []RateLimitGroup{
+			{Name: "ai", Patterns: []string{"/api/v1/tickers/*/predictions"}, Limit: cache.RateLimit{Limit: 1, Period: time.Minute}},
+		},
+		Default:       cache.RateLimit{Limit: 2, Period: time.Minute},
+		ExcludedPaths: []string{"/health"},
+	}
+
+	serve := func(handler http.Handler, path string, remoteAddr string, identity *auth.Identity) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if identity != nil {
+			req = req.WithContext(auth.WithIdentity(req.Context(), *identity))
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("limit per group and client", func(t *testing.T) {
+		handler := RateLimit(&fakeLimiter{counts: map[string]int{}}, options)(ok)
+
+		rec := serve(handler, "/api/v1/tickers/AAPL/predictions", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", rec.Header().Get("X-RateLimit-Reset"))
+
+		rec = serve(handler, "/api/v1/tickers/MSFT/predictions", "10.0.0.1:4321", nil)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+		// other client has its own bucket
+		rec = serve(handler, "/api/v1/tickers/AAPL/predictions", "10.0.0.2:1234", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// other group has its own bucket
+		rec = serve(handler, "/api/v1/tickers/AAPL/logo", "10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+	})
+
+	t.Run("identity has priority over the ip", func(t *testing.T) {
+		handler := RateLimit(&fakeLimiter{counts: map[string]int{}}, options)(ok)
+		identity := &auth.Identity{Subject: "analyst-1"}
+
+		assert.Equal(t, http.StatusOK, serve(handler, "/api/v1/tickers/AAPL/predictions", "10.0.0.1:1", identity).Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(handler, "/api/v1/tickers/AAPL/predictions", "10.0.0.2:1", identity).Code)
+	})
+
+	t.Run("excluded paths are not limited", func(t *testing.T) {
+		limiter := &fakeLimiter{counts: map[string]int{}}
+		handler := RateLimit(limiter, options)(ok)
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serve(handler, "/health", "10.0.0.1:1", nil).Code)
+		}
+		assert.Empty(t, limiter.counts)
+	})
+
+	t.Run("limiter errors allow the request", func(t *testing.T) {
+		handler := RateLimit(&fakeLimiter{err: errors.New("redis down")}, options)(ok)
+		assert.Equal(t, http.StatusOK, serve(handler, "/api/v1/tickers", "10.0.0.1:1", nil).Code)
+	})
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"api/models/ratings"
+	"time"
+)
+
+// AlertType condition that fires an alert
+type AlertType string
+
+const (
+	// PriceAboveAlert fires when the price crosses above the threshold
+	PriceAboveAlert AlertType = "price_above"
+	// PriceBelowAlert fires when the price crosses below the threshold
+	PriceBelowAlert AlertType = "price_below"
+	// ChangePercentAlert fires when the absolute daily change percentage reaches the threshold
+	ChangePercentAlert AlertType = "change_percent"
+	// SentimentAlert fires when the sentiment of the ratings turns to the sentiment of the rule
+	SentimentAlert AlertType = "sentiment"
+	// RecommendationAlert fires for each new recommendation, optionally only with the action of the rule
+	RecommendationAlert AlertType = "recommendation"
+)
+
+// AlertTypes supported types of alerts
+var AlertTypes = []AlertType{PriceAboveAlert, PriceBelowAlert, ChangePercentAlert, SentimentAlert, RecommendationAlert}
+
+// IsValid checks the type is supported
+func (t AlertType) IsValid() bool {
+	for _, alertType := range AlertTypes {
+		if t == alertType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AlertChannel sink that delivers the notifications of an alert
+type AlertChannel string
+
+const (
+	// WebhookChannel posts the notification signed with HMAC to the url of the target
+	WebhookChannel AlertChannel = "webhook"
+	// EmailChannel sends the notification by SMTP to the address of the target
+	EmailChannel AlertChannel = "email"
+)
+
+// IsValid checks the channel is supported
+func (c AlertChannel) IsValid() bool {
+	return c == WebhookChannel || c == EmailChannel
+}
+
+// AlertRule represents a condition over a ticker saved by a user
+//
+// the price, change and sentiment rules fire only when the condition changes from not met to met,
+// Triggered stores if the condition was met in the last evaluation.
+// the recommendation rules fire for the recommendations with ID greater than LastRecommendationID,
+// Secret signs the webhooks of the rule and is only returned when the rule is created
+type AlertRule struct {
+	ID                   uint              `gorm:"primaryKey" json:"id"`
+	Owner                string            `gorm:"not null;type:varchar(200);index:idx_alert_rule_owner" json:"owner"`
+	TickerID             string            `gorm:"not null;type:varchar(5)" json:"tickerId"`
+	Type                 AlertType         `gorm:"not null;type:varchar(20)" json:"type"`
+	Threshold            float64           `json:"threshold"`
+	Sentiment            ratings.Sentiment `gorm:"type:varchar(10)" json:"sentiment,omitempty"`
+	Action               Action            `gorm:"type:varchar(20)" json:"action,omitempty"`
+	Channel              AlertChannel      `gorm:"not null;type:varchar(10)" json:"channel"`
+	Target               string            `gorm:"not null;type:varchar(500)" json:"target"`
+	Secret               string            `gorm:"type:varchar(64)" json:"-"`
+	Enabled              bool              `gorm:"not null;index:idx_alert_rule_enabled" json:"enabled"`
+	Triggered            bool              `gorm:"not null;default:false" json:"triggered"`
+	LastRecommendationID uint              `json:"-"`
+	LastEvaluatedAt      *time.Time        `json:"lastEvaluatedAt"`
+	LastFiredAt          *time.Time        `json:"lastFiredAt"`
+	CreatedAt            time.Time         `json:"createdAt"`
+	UpdatedAt            time.Time         `json:"updatedAt"`
+}
+
+// TableName specifies the table name for AlertRule
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// AlertEvent represents a firing of an alert rule and the result of its delivery
+// Error is the reason of the failed deliveries
+type AlertEvent struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	AlertRuleID uint         `gorm:"not null;index:idx_alert_event_rule" json:"alertRuleId"`
+	TickerID    string       `gorm:"not null;type:varchar(5)" json:"tickerId"`
+	Type        AlertType    `gorm:"not null;type:varchar(20)" json:"type"`
+	Message     string       `gorm:"not null;type:varchar(500)" json:"message"`
+	Value       float64      `json:"value"`
+	Channel     AlertChannel `gorm:"not null;type:varchar(10)" json:"channel"`
+	Delivered   bool         `gorm:"not null;default:false" json:"delivered"`
+	Error       string       `gorm:"type:varchar(500)" json:"error,omitempty"`
+	FiredAt     time.Time    `gorm:"not null" json:"firedAt"`
+}
+
+// TableName specifies the table name for AlertEvent
+func (AlertEvent) TableName() string {
+	return "alert_events"
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ApiKey represents a credential to access the api
+//
+// only the sha256 of the key is stored, Prefix is kept to identify the key in the listings
+type ApiKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"not null;type:varchar(100)" json:"name"`
+	Subject    string     `gorm:"not null;type:varchar(200);index:idx_api_key_subject" json:"subject"`
+	Prefix     string     `gorm:"not null;type:varchar(20)" json:"prefix"`
+	Hash       string     `gorm:"not null;type:varchar(64);uniqueIndex:idx_api_key_hash" json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// TableName specifies the table name for ApiKey
+func (ApiKey) TableName() string {
+	return "api_keys"
+}
@@ -5,6 +5,7 @@ type Brokerage struct {
 	ID              uint             `gorm:"primaryKey" json:"id"`
 	Name            string           `json:"name" gorm:"type:varchar(200);unique"`
 	Recommendations []Recommendation `gorm:"foreignKey:BrokerageID;references:ID" json:"recommendations,omitempty"`
+	Stats           *BrokerageStats  `gorm:"foreignKey:BrokerageID;references:ID" json:"stats,omitempty"`
 }
 
 // TableName specifies the table name for Brokerage
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// BrokerageStats outcome of the calls of a brokerage, calculated by the analytics package
+//
+// the hit rates are the ratio of upgrades followed by a gain after 30 and 90 days,
+// TargetError is the mean absolute percentage error of the targets against the close after 90 days.
+// The rates are nil while no call can be evaluated
+type BrokerageStats struct {
+	BrokerageID      uint      `gorm:"primaryKey" json:"brokerageId"`
+	Calls            int       `gorm:"not null;default:0" json:"calls"`
+	Upgrades         int       `gorm:"not null;default:0" json:"upgrades"`
+	Downgrades       int       `gorm:"not null;default:0" json:"downgrades"`
+	Upgrades30       int       `gorm:"not null;default:0" json:"upgrades30"`
+	Gains30          int       `gorm:"not null;default:0" json:"gains30"`
+	UpgradeHitRate30 *float64  `json:"upgradeHitRate30"`
+	Upgrades90       int       `gorm:"not null;default:0" json:"upgrades90"`
+	Gains90          int       `gorm:"not null;default:0" json:"gains90"`
+	UpgradeHitRate90 *float64  `json:"upgradeHitRate90"`
+	TargetsEvaluated int       `gorm:"not null;default:0" json:"targetsEvaluated"`
+	TargetError      *float64  `json:"targetError"`
+	LastCallAt       time.Time `json:"lastCallAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for BrokerageStats
+func (BrokerageStats) TableName() string {
+	return "brokerage_stats"
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// CompanySnapshot copy of the company data of a ticker used by the screener,
+// the company data of the financial API is not stored so the snapshots are refreshed in background
+type CompanySnapshot struct {
+	TickerID  string    `gorm:"primaryKey;type:varchar(5)" json:"tickerId"`
+	Sector    string    `gorm:"index:idx_snapshot_sector;type:varchar(100)" json:"sector"`
+	Industry  string    `gorm:"type:varchar(200)" json:"industry"`
+	Country   string    `gorm:"type:varchar(50)" json:"country"`
+	MarketCap float64   `json:"marketCap"`
+	Beta      float64   `json:"beta"`
+	Price     float64   `json:"price"`
+	Volume    float64   `json:"volume"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for CompanySnapshot
+func (CompanySnapshot) TableName() string {
+	return "company_snapshots"
+}
+
+// NewCompanySnapshot creates the snapshot of the company data of a ticker
+func NewCompanySnapshot(tickerID string, data CompanyData) CompanySnapshot {
+	return CompanySnapshot{
+		TickerID:  tickerID,
+		Sector:    data.Sector,
+		Industry:  data.Industry,
+		Country:   data.Country,
+		MarketCap: data.MarketCap,
+		Beta:      data.Beta,
+		Price:     data.Price,
+		Volume:    data.Volume,
+	}
+}
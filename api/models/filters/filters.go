@@ -2,14 +2,20 @@ package filters
 
 import (
 	"api/config"
+	"api/models/ratings"
 	"api/sanatizer"
 )
 
+// Filters pagination and ordering of the lists
+// OrderBy is the field to order, each list validates its own fields
+// Sentiment is the mode of the sentiment of the lists of tickers
 type Filters struct {
-	Query    string
-	Page     int
-	PageSize int
-	Sort     Sort
+	Query     string
+	Page      int
+	PageSize  int
+	Sort      Sort
+	OrderBy   string
+	Sentiment ratings.SentimentMode
 }
 
 func (f *Filters) Normalize() {
@@ -29,6 +35,10 @@ func (f *Filters) Normalize() {
 		f.Sort = ASC
 	}
 
+	if !f.Sentiment.IsValid() {
+		f.Sentiment = ratings.SimpleSentimentMode
+	}
+
 	if f.Query == "" {
 		f.Query = ""
 	}
@@ -1,9 +1,15 @@
 package models
 
+import (
+	"sort"
+	"time"
+)
+
 // HistoricalPrice represents company historical price of stock from a company
+// the end of day prices are stored in the historical_prices table keyed by ticker and date (YYYY-MM-DD)
 type HistoricalPrice struct {
-	Symbol  string  `json:"symbol"`
-	Date    string  `json:"date"`
+	Symbol  string  `json:"symbol" gorm:"column:ticker;primaryKey;type:varchar(10)"`
+	Date    string  `json:"date" gorm:"primaryKey;type:varchar(10)"`
 	Open    float64 `json:"open"`
 	High    float64 `json:"high"`
 	Low     float64 `json:"low"`
@@ -13,3 +19,35 @@ type HistoricalPrice struct {
 	ChangeP float64 `json:"changePercent"`
 	Vwap    float64 `json:"vwap"`
 }
+
+// TableName specifies the table name for HistoricalPrice
+func (HistoricalPrice) TableName() string {
+	return "historical_prices"
+}
+
+// SortPrices returns the prices with close ordered from the oldest to the newest
+func SortPrices(prices []HistoricalPrice) []HistoricalPrice {
+	sorted := make([]HistoricalPrice, 0, len(prices))
+	for _, price := range prices {
+		if price.Close > 0 {
+			sorted = append(sorted, price)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+	return sorted
+}
+
+// PriceCoverage range of dates (YYYY-MM-DD) of a ticker already fetched from the financial API,
+// the dates without price in the range are holidays or weekends and are not fetched again
+type PriceCoverage struct {
+	Ticker    string    `gorm:"primaryKey;type:varchar(10)" json:"ticker"`
+	FirstDate string    `gorm:"not null;type:varchar(10)" json:"firstDate"`
+	LastDate  string    `gorm:"not null;type:varchar(10)" json:"lastDate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TableName specifies the table name for PriceCoverage
+func (PriceCoverage) TableName() string {
+	return "historical_price_coverage"
+}
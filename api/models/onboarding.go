@@ -1,13 +1,21 @@
 package models
 
+import "time"
+
+// OverviewTour is the name of the tour of the overview page
+const OverviewTour = "overview"
+
 // Onboarding struct
 //
 // This struct represents the onboarding table in the database
-// used to determine what process show to user
+// used to determine what process show to user, each client has a row per tour
 type Onboarding struct {
-	ID           uint `json:"id" gorm:"primaryKey"`
-	OverviewStep int  `json:"overviewStep" gorm:"default:1"`
-	OverviewDone bool `json:"overviewDone" gorm:"default:false"`
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ClientID  string    `json:"clientId" gorm:"type:varchar(200);not null;default:'anonymous';uniqueIndex:idx_onboarding_client_tour"`
+	Tour      string    `json:"tour" gorm:"type:varchar(50);not null;default:'overview';uniqueIndex:idx_onboarding_client_tour"`
+	Step      int       `json:"step" gorm:"not null;default:1"`
+	Done      bool      `json:"done" gorm:"not null;default:false"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 func (Onboarding) TableName() string {
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// TransactionType side of a transaction of a portfolio
+type TransactionType string
+
+const (
+	// BuyTransaction adds a lot to the position of the ticker
+	BuyTransaction TransactionType = "buy"
+	// SellTransaction closes the oldest lots of the position of the ticker
+	SellTransaction TransactionType = "sell"
+)
+
+// IsValid checks the type is supported
+func (t TransactionType) IsValid() bool {
+	return t == BuyTransaction || t == SellTransaction
+}
+
+// Portfolio represents the holdings of a user, the positions are calculated from its transactions
+type Portfolio struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	Owner        string        `gorm:"not null;type:varchar(200);index:idx_portfolio_owner" json:"owner"`
+	Name         string        `gorm:"not null;type:varchar(100)" json:"name"`
+	Transactions []Transaction `gorm:"foreignKey:PortfolioID;references:ID" json:"transactions"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+// TableName specifies the table name for Portfolio
+func (Portfolio) TableName() string {
+	return "portfolios"
+}
+
+// Transaction represents a buy or a sell of a ticker in a portfolio
+// Price is per share and Fees is the total of the transaction
+type Transaction struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	PortfolioID uint            `gorm:"not null;index:idx_transaction_portfolio" json:"portfolioId"`
+	TickerID    string          `gorm:"not null;type:varchar(5)" json:"tickerId"`
+	Ticker      *Ticker         `gorm:"foreignKey:TickerID;references:ID" json:"ticker,omitempty"`
+	Type        TransactionType `gorm:"not null;type:varchar(10)" json:"type"`
+	Quantity    float64         `gorm:"not null" json:"quantity"`
+	Price       float64         `gorm:"not null" json:"price"`
+	Fees        float64         `gorm:"not null;default:0" json:"fees"`
+	Date        time.Time       `gorm:"not null" json:"date"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// TableName specifies the table name for Transaction
+func (Transaction) TableName() string {
+	return "portfolio_transactions"
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// PredictionSet represents the predictions of a ticker generated by a model
+//
+// BaseDate and BaseClose are the last real price known when the predictions were generated,
+// used as reference of the direction of the first predicted day.
+// only one set is stored per ticker, model, day of generation and horizon
+type PredictionSet struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	TickerID    string            `gorm:"not null;type:varchar(5);uniqueIndex:idx_prediction_set" json:"tickerId"`
+	Model       string            `gorm:"not null;type:varchar(20);uniqueIndex:idx_prediction_set" json:"model"`
+	GeneratedOn string            `gorm:"not null;type:varchar(10);uniqueIndex:idx_prediction_set" json:"generatedOn"`
+	Horizon     int               `gorm:"not null;uniqueIndex:idx_prediction_set" json:"horizon"`
+	GeneratedAt time.Time         `gorm:"not null" json:"generatedAt"`
+	BaseDate    string            `gorm:"type:varchar(10)" json:"baseDate"`
+	BaseClose   float64           `json:"baseClose"`
+	Points      []PredictionPoint `gorm:"foreignKey:PredictionSetID;references:ID" json:"points,omitempty"`
+}
+
+// TableName specifies the table name for PredictionSet
+func (PredictionSet) TableName() string {
+	return "prediction_sets"
+}
+
+// PredictionPoint represents the predicted close of a day
+//
+// Scored is true when the date passed and the real close was searched,
+// ActualClose is nil if there was no trading that day
+type PredictionPoint struct {
+	ID              uint     `gorm:"primaryKey" json:"id"`
+	PredictionSetID uint     `gorm:"not null;index:idx_prediction_point_set" json:"predictionSetId"`
+	Date            string   `gorm:"not null;type:varchar(10)" json:"date"`
+	PredictedClose  float64  `json:"predictedClose"`
+	ActualClose     *float64 `json:"actualClose"`
+	Scored          bool     `gorm:"not null;default:false;index:idx_prediction_point_scored" json:"scored"`
+}
+
+// TableName specifies the table name for PredictionPoint
+func (PredictionPoint) TableName() string {
+	return "prediction_points"
+}
@@ -0,0 +1,36 @@
+package ratings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SentimentMode selects how the ratings of a ticker are combined in the sentiment
+type SentimentMode string
+
+const (
+	// SimpleSentimentMode counts each rating with the same weight
+	SimpleSentimentMode SentimentMode = "simple"
+	// WeightedSentimentMode weights each rating by its recency and the reliability of the brokerage
+	WeightedSentimentMode SentimentMode = "weighted"
+)
+
+// IsValid checks the mode is supported
+func (m SentimentMode) IsValid() bool {
+	return m == SimpleSentimentMode || m == WeightedSentimentMode
+}
+
+// ParseSentimentMode parses the mode, an empty value is the simple mode
+func ParseSentimentMode(value string) (SentimentMode, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return SimpleSentimentMode, nil
+	}
+
+	mode := SentimentMode(value)
+	if !mode.IsValid() {
+		return "", fmt.Errorf("invalid sentiment: the supported modes are %s and %s", SimpleSentimentMode, WeightedSentimentMode)
+	}
+
+	return mode, nil
+}
@@ -0,0 +1,11 @@
+package responses
+
+import "api/indicators"
+
+// TickerIndicators technical indicators of a ticker in a range of dates
+type TickerIndicators struct {
+	Symbol     string                          `json:"symbol"`
+	From       string                          `json:"from,omitempty"`
+	To         string                          `json:"to,omitempty"`
+	Indicators map[string]indicators.Indicator `json:"indicators"`
+}
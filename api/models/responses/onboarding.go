@@ -0,0 +1,19 @@
+package responses
+
+import "api/models"
+
+// OverviewOnboarding is the format of the overview tour used by the first version of the api
+type OverviewOnboarding struct {
+	ID           uint `json:"id"`
+	OverviewStep int  `json:"overviewStep"`
+	OverviewDone bool `json:"overviewDone"`
+}
+
+// NewOverviewOnboarding converts the overview tour to the format of the first version of the api
+func NewOverviewOnboarding(onboarding models.Onboarding) OverviewOnboarding {
+	return OverviewOnboarding{
+		ID:           onboarding.ID,
+		OverviewStep: onboarding.Step,
+		OverviewDone: onboarding.Done,
+	}
+}
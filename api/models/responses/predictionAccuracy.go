@@ -0,0 +1,10 @@
+package responses
+
+import "api/forecast"
+
+// PredictionAccuracy accuracy of the scored predictions of a model
+type PredictionAccuracy struct {
+	Model string `json:"model"`
+	Sets  int    `json:"sets"`
+	forecast.Accuracy
+}
@@ -2,6 +2,7 @@ package responses
 
 import (
 	"api/models"
+	"api/models/ratings"
 )
 
 type RecomendationResponse struct {
@@ -16,4 +17,5 @@ type CompanyOverview struct {
 	HistoricalPrices []models.HistoricalPrice `json:"historicalPrices"`
 	CompanyNews      []models.CompanyNew      `json:"companyNews"`
 	Advice           string                   `json:"advice"`
+	Sentiment        ratings.SentimentScore   `json:"sentiment"`
 }
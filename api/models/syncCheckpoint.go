@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SyncCheckpoint stores the progress of an incremental ingestion
+//
+// Name identifies the source being synced, Cursor is the last page token
+// read from the source and LastTime the newest recommendation time ingested
+type SyncCheckpoint struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(100)"`
+	Cursor    string    `json:"cursor" gorm:"type:varchar(200)"`
+	LastTime  time.Time `json:"lastTime"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (SyncCheckpoint) TableName() string {
+	return "sync_checkpoints"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SyncLock is a lease used to avoid running the same job in several replicas
+//
+// the lock is held by Owner until ExpiresAt, after that any replica can take it
+type SyncLock struct {
+	Name      string    `json:"name" gorm:"primaryKey;type:varchar(100)"`
+	Owner     string    `json:"owner" gorm:"type:varchar(200);not null"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null"`
+}
+
+func (SyncLock) TableName() string {
+	return "sync_locks"
+}
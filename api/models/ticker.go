@@ -11,6 +11,8 @@ type Ticker struct {
 	Company         string            `gorm:"not null;index:idx_ticker_company;type:varchar(200)" json:"company"`
 	Recommendations []Recommendation  `gorm:"foreignKey:TickerID;references:ID" json:"recommendations,omitempty"`
 	Sentiment       ratings.Sentiment `json:"sentiment" gorm:"-"`
+	// SentimentScore detail of the sentiment, calculated with the mode of the request
+	SentimentScore ratings.SentimentScore `json:"sentimentScore" gorm:"-"`
 }
 
 type TickerID string
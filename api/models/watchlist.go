@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Watchlist represents a list of tickers saved by a user
+type Watchlist struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Owner     string          `gorm:"not null;type:varchar(200);index:idx_watchlist_owner" json:"owner"`
+	Name      string          `gorm:"not null;type:varchar(100)" json:"name"`
+	Items     []WatchlistItem `gorm:"foreignKey:WatchlistID;references:ID" json:"items"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+// TableName specifies the table name for Watchlist
+func (Watchlist) TableName() string {
+	return "watchlists"
+}
+
+// WatchlistItem represents a ticker saved in a watchlist
+type WatchlistItem struct {
+	WatchlistID uint      `gorm:"primaryKey" json:"watchlistId"`
+	TickerID    string    `gorm:"primaryKey;type:varchar(5)" json:"tickerId"`
+	Ticker      *Ticker   `gorm:"foreignKey:TickerID;references:ID" json:"ticker,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// TableName specifies the table name for WatchlistItem
+func (WatchlistItem) TableName() string {
+	return "watchlist_items"
+}
@@ -0,0 +1,262 @@
+package portfolio
+
+// portfolio calculates the positions of a portfolio from its transactions
+// the sells close the oldest lots first (FIFO), the fees are part of the cost of the lots
+// and reduce the proceeds of the sells
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientQuantity is returned when a sell has more shares than the open lots of the ticker
+var ErrInsufficientQuantity = errors.New("insufficient quantity")
+
+// UnknownSector is the sector of the tickers without company data
+const UnknownSector = "Unknown"
+
+// epsilon tolerance of the quantities, the fractional shares accumulate rounding errors
+const epsilon = 1e-9
+
+// Lot is an open part of a buy
+// Cost is per share and includes the fees of the buy
+type Lot struct {
+	TransactionID uint      `json:"transactionId"`
+	Quantity      float64   `json:"quantity"`
+	Price         float64   `json:"price"`
+	Cost          float64   `json:"cost"`
+	Date          time.Time `json:"date"`
+}
+
+// Quote is the current data of a ticker used to value the positions
+type Quote struct {
+	Price  float64
+	Sector string
+}
+
+// Position is the holding of a ticker
+// the market values and the unrealized P&L are nil while the ticker has no price
+// the closed positions have quantity 0 and keep the realized P&L
+type Position struct {
+	Ticker                  string                 `json:"ticker"`
+	Sector                  string                 `json:"sector"`
+	Quantity                float64                `json:"quantity"`
+	CostBasis               float64                `json:"costBasis"`
+	AverageCost             float64                `json:"averageCost"`
+	Price                   *float64               `json:"price"`
+	MarketValue             *float64               `json:"marketValue"`
+	UnrealizedPnL           *float64               `json:"unrealizedPnl"`
+	UnrealizedPnLPercentage *float64               `json:"unrealizedPnlPercentage"`
+	RealizedPnL             float64                `json:"realizedPnl"`
+	Fees                    float64                `json:"fees"`
+	Weight                  *float64               `json:"weight"`
+	Lots                    []Lot                  `json:"lots"`
+	Sentiment               ratings.SentimentScore `json:"sentiment"`
+}
+
+// Allocation is the part of the market value of a sector
+type Allocation struct {
+	Sector      string  `json:"sector"`
+	MarketValue float64 `json:"marketValue"`
+	Weight      float64 `json:"weight"`
+}
+
+// Summary totals of a portfolio
+// the totals of market value and unrealized P&L only include the positions with price,
+// Unpriced lists the open positions without price
+type Summary struct {
+	Positions     []Position   `json:"positions"`
+	MarketValue   float64      `json:"marketValue"`
+	CostBasis     float64      `json:"costBasis"`
+	UnrealizedPnL float64      `json:"unrealizedPnl"`
+	RealizedPnL   float64      `json:"realizedPnl"`
+	Fees          float64      `json:"fees"`
+	Allocation    []Allocation `json:"allocation"`
+	Unpriced      []string     `json:"unpriced"`
+}
+
+// SortTransactions sorts the transactions in the order they are applied, by date and then by id
+func SortTransactions(transactions []models.Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].Date.Before(transactions[j].Date)
+		}
+		return transactions[i].ID < transactions[j].ID
+	})
+}
+
+// BuildPositions applies the transactions in order and returns the position of each ticker, sorted by ticker
+// returns ErrInsufficientQuantity if a sell has more shares than the open lots
+func BuildPositions(transactions []models.Transaction) ([]Position, error) {
+	sorted := make([]models.Transaction, len(transactions))
+	copy(sorted, transactions)
+	SortTransactions(sorted)
+
+	positions := make(map[string]*Position)
+	for _, transaction := range sorted {
+		ticker := strings.ToUpper(transaction.TickerID)
+		position, ok := positions[ticker]
+		if !ok {
+			position = &Position{Ticker: ticker, Lots: []Lot{}}
+			positions[ticker] = position
+		}
+
+		position.Fees += transaction.Fees
+
+		switch transaction.Type {
+		case models.BuyTransaction:
+			position.Lots = append(position.Lots, Lot{
+				TransactionID: transaction.ID,
+				Quantity:      transaction.Quantity,
+				Price:         transaction.Price,
+				Cost:          (transaction.Quantity*transaction.Price + transaction.Fees) / transaction.Quantity,
+				Date:          transaction.Date,
+			})
+		case models.SellTransaction:
+			if err := closeLots(position, transaction); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid transaction type %q", transaction.Type)
+		}
+	}
+
+	result := make([]Position, 0, len(positions))
+	for _, position := range positions {
+		for _, lot := range position.Lots {
+			position.Quantity += lot.Quantity
+			position.CostBasis += lot.Quantity * lot.Cost
+		}
+
+		if position.Quantity > epsilon {
+			position.AverageCost = position.CostBasis / position.Quantity
+		} else {
+			position.Quantity = 0
+			position.CostBasis = 0
+		}
+
+		result = append(result, *position)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Ticker < result[j].Ticker
+	})
+
+	return result, nil
+}
+
+// closeLots closes the oldest lots of the position with the shares of the sell and adds the realized P&L
+func closeLots(position *Position, transaction models.Transaction) error {
+	var available float64
+	for _, lot := range position.Lots {
+		available += lot.Quantity
+	}
+
+	if transaction.Quantity > available+epsilon {
+		return fmt.Errorf("%w: sell of %g %s on %s with %g shares", ErrInsufficientQuantity,
+			transaction.Quantity, position.Ticker, transaction.Date.Format("2006-01-02"), available)
+	}
+
+	proceeds := transaction.Quantity*transaction.Price - transaction.Fees
+	remaining := transaction.Quantity
+	var cost float64
+
+	for remaining > epsilon && len(position.Lots) > 0 {
+		lot := &position.Lots[0]
+		closed := math.Min(lot.Quantity, remaining)
+
+		cost += closed * lot.Cost
+		lot.Quantity -= closed
+		remaining -= closed
+
+		if lot.Quantity <= epsilon {
+			position.Lots = position.Lots[1:]
+		}
+	}
+
+	position.RealizedPnL += proceeds - cost
+	return nil
+}
+
+// Summarize values the positions with the quotes and calculates the totals and the allocation by sector
+// the positions without quote keep the values nil and are listed in Unpriced
+func Summarize(positions []Position, quotes map[string]Quote) Summary {
+	summary := Summary{
+		Positions:  positions,
+		Allocation: []Allocation{},
+		Unpriced:   []string{},
+	}
+
+	sectors := make(map[string]float64)
+	for i := range summary.Positions {
+		position := &summary.Positions[i]
+		summary.CostBasis += position.CostBasis
+		summary.RealizedPnL += position.RealizedPnL
+		summary.Fees += position.Fees
+
+		quote, ok := quotes[position.Ticker]
+		position.Sector = UnknownSector
+		if ok && strings.TrimSpace(quote.Sector) != "" {
+			position.Sector = quote.Sector
+		}
+
+		if position.Quantity == 0 {
+			continue
+		}
+
+		if !ok || quote.Price <= 0 {
+			summary.Unpriced = append(summary.Unpriced, position.Ticker)
+			continue
+		}
+
+		price := quote.Price
+		marketValue := position.Quantity * price
+		unrealized := marketValue - position.CostBasis
+
+		position.Price = &price
+		position.MarketValue = &marketValue
+		position.UnrealizedPnL = &unrealized
+		if position.CostBasis > 0 {
+			percentage := unrealized / position.CostBasis * 100
+			position.UnrealizedPnLPercentage = &percentage
+		}
+
+		summary.MarketValue += marketValue
+		summary.UnrealizedPnL += unrealized
+		sectors[position.Sector] += marketValue
+	}
+
+	if summary.MarketValue <= 0 {
+		return summary
+	}
+
+	for i := range summary.Positions {
+		if value := summary.Positions[i].MarketValue; value != nil {
+			weight := *value / summary.MarketValue
+			summary.Positions[i].Weight = &weight
+		}
+	}
+
+	for sector, value := range sectors {
+		summary.Allocation = append(summary.Allocation, Allocation{
+			Sector:      sector,
+			MarketValue: value,
+			Weight:      value / summary.MarketValue,
+		})
+	}
+
+	sort.Slice(summary.Allocation, func(i, j int) bool {
+		if summary.Allocation[i].MarketValue != summary.Allocation[j].MarketValue {
+			return summary.Allocation[i].MarketValue > summary.Allocation[j].MarketValue
+		}
+		return summary.Allocation[i].Sector < summary.Allocation[j].Sector
+	})
+
+	return summary
+}
@@ -0,0 +1,165 @@
+package portfolio
+
+import (
+	"api/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+func buy(id uint, ticker string, quantity, price, fees float64, day string) models.Transaction {
+	return models.Transaction{ID: id, TickerID: ticker, Type: models.BuyTransaction, Quantity: quantity, Price: price, Fees: fees, Date: date(day)}
+}
+
+func sell(id uint, ticker string, quantity, price, fees float64, day string) models.Transaction {
+	return models.Transaction{ID: id, TickerID: ticker, Type: models.SellTransaction, Quantity: quantity, Price: price, Fees: fees, Date: date(day)}
+}
+
+func TestBuildPositionsFIFO(t *testing.T) {
+	transactions := []models.Transaction{
+		// out of order, the sell is applied after both buys
+		sell(3, "AAPL", 15, 130, 5, "2025-03-01"),
+		buy(1, "AAPL", 10, 100, 10, "2025-01-01"),
+		buy(2, "aapl", 10, 120, 0, "2025-02-01"),
+	}
+
+	positions, err := BuildPositions(transactions)
+
+	assert.NoError(t, err)
+	if !assert.Len(t, positions, 1) {
+		return
+	}
+
+	position := positions[0]
+	assert.Equal(t, "AAPL", position.Ticker)
+	assert.InDelta(t, 5, position.Quantity, 1e-9)
+	// the first lot is closed, 5 shares of the second lot remain
+	assert.Len(t, position.Lots, 1)
+	assert.Equal(t, uint(2), position.Lots[0].TransactionID)
+	assert.InDelta(t, 600, position.CostBasis, 1e-9)
+	assert.InDelta(t, 120, position.AverageCost, 1e-9)
+	// proceeds 15*130-5 = 1945, cost 10*101 + 5*120 = 1610
+	assert.InDelta(t, 335, position.RealizedPnL, 1e-9)
+	assert.InDelta(t, 15, position.Fees, 1e-9)
+}
+
+func TestBuildPositionsSameDayByID(t *testing.T) {
+	transactions := []models.Transaction{
+		sell(2, "AAPL", 10, 110, 0, "2025-01-01"),
+		buy(1, "AAPL", 10, 100, 0, "2025-01-01"),
+	}
+
+	positions, err := BuildPositions(transactions)
+
+	assert.NoError(t, err)
+	assert.Len(t, positions, 1)
+	assert.Equal(t, 0.0, positions[0].Quantity)
+	assert.Empty(t, positions[0].Lots)
+	assert.InDelta(t, 100, positions[0].RealizedPnL, 1e-9)
+}
+
+func TestBuildPositionsInsufficientQuantity(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		transactions []models.Transaction
+	}{
+		{
+			desc:         "sell without buys",
+			transactions: []models.Transaction{sell(1, "AAPL", 1, 100, 0, "2025-01-01")},
+		},
+		{
+			desc: "sell before the buy",
+			transactions: []models.Transaction{
+				buy(2, "AAPL", 10, 100, 0, "2025-02-01"),
+				sell(1, "AAPL", 5, 100, 0, "2025-01-01"),
+			},
+		},
+		{
+			desc: "sell more than the lots",
+			transactions: []models.Transaction{
+				buy(1, "AAPL", 10, 100, 0, "2025-01-01"),
+				sell(2, "AAPL", 10.5, 100, 0, "2025-02-01"),
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := BuildPositions(tC.transactions)
+
+			assert.ErrorIs(t, err, ErrInsufficientQuantity)
+		})
+	}
+}
+
+func TestBuildPositionsFractionalShares(t *testing.T) {
+	transactions := []models.Transaction{
+		buy(1, "AAPL", 0.1, 100, 0, "2025-01-01"),
+		buy(2, "AAPL", 0.2, 100, 0, "2025-01-02"),
+		sell(3, "AAPL", 0.3, 100, 0, "2025-01-03"),
+	}
+
+	positions, err := BuildPositions(transactions)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, positions[0].Quantity)
+	assert.Empty(t, positions[0].Lots)
+}
+
+func TestSummarize(t *testing.T) {
+	positions, err := BuildPositions([]models.Transaction{
+		buy(1, "AAPL", 10, 100, 0, "2025-01-01"),
+		buy(2, "MSFT", 5, 200, 0, "2025-01-01"),
+		buy(3, "JPM", 10, 50, 0, "2025-01-01"),
+		buy(4, "TSLA", 1, 100, 0, "2025-01-01"),
+		buy(5, "NVDA", 1, 100, 0, "2025-01-01"),
+		sell(6, "NVDA", 1, 150, 0, "2025-02-01"),
+	})
+	assert.NoError(t, err)
+
+	summary := Summarize(positions, map[string]Quote{
+		"AAPL": {Price: 150, Sector: "Technology"},
+		"MSFT": {Price: 100, Sector: "Technology"},
+		"JPM":  {Price: 100},
+	})
+
+	// 1500 + 500 + 1000, TSLA has no price
+	assert.InDelta(t, 3000, summary.MarketValue, 1e-9)
+	assert.InDelta(t, 2600, summary.CostBasis, 1e-9)
+	assert.InDelta(t, 500, summary.UnrealizedPnL, 1e-9)
+	assert.InDelta(t, 50, summary.RealizedPnL, 1e-9)
+	assert.Equal(t, []string{"TSLA"}, summary.Unpriced)
+
+	assert.Equal(t, []Allocation{
+		{Sector: "Technology", MarketValue: 2000, Weight: 2000.0 / 3000},
+		{Sector: UnknownSector, MarketValue: 1000, Weight: 1000.0 / 3000},
+	}, summary.Allocation)
+
+	for _, position := range summary.Positions {
+		switch position.Ticker {
+		case "AAPL":
+			assert.InDelta(t, 500, *position.UnrealizedPnL, 1e-9)
+			assert.InDelta(t, 50, *position.UnrealizedPnLPercentage, 1e-9)
+			assert.InDelta(t, 0.5, *position.Weight, 1e-9)
+		case "TSLA":
+			assert.Nil(t, position.MarketValue)
+			assert.Nil(t, position.Weight)
+		case "NVDA":
+			assert.Equal(t, 0.0, position.Quantity)
+			assert.Nil(t, position.MarketValue)
+		}
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	summary := Summarize([]Position{}, nil)
+
+	assert.Equal(t, 0.0, summary.MarketValue)
+	assert.Empty(t, summary.Allocation)
+	assert.Empty(t, summary.Unpriced)
+}
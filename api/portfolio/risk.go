@@ -0,0 +1,330 @@
+package portfolio
+
+import (
+	"api/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrInsufficientData is returned when the prices of the positions have less returns than MinObservations
+var ErrInsufficientData = errors.New("insufficient price data")
+
+// TradingDays number of trading days of a year, used to annualize the volatility
+const TradingDays = 252
+
+// MinObservations min daily returns shared by all the positions to calculate the risk
+const MinObservations = 20
+
+// PositionRisk risk of a position, Volatility is the annualized standard deviation of the daily returns
+// Beta is nil if the benchmark has no prices or does not move
+type PositionRisk struct {
+	Ticker     string   `json:"ticker"`
+	Weight     float64  `json:"weight"`
+	Volatility float64  `json:"volatility"`
+	Beta       *float64 `json:"beta"`
+}
+
+// Correlation pairwise correlation of the daily returns, Matrix[i][j] is the correlation of Tickers[i] and Tickers[j]
+type Correlation struct {
+	Tickers []string    `json:"tickers"`
+	Matrix  [][]float64 `json:"matrix"`
+}
+
+// ValueAtRisk 1-day loss of the portfolio not exceeded with the confidence, as fraction of the value and amount
+// CVaR is the average loss of the days beyond the VaR
+type ValueAtRisk struct {
+	VaR        float64 `json:"var"`
+	CVaR       float64 `json:"cvar"`
+	VaRAmount  float64 `json:"varAmount"`
+	CVaRAmount float64 `json:"cvarAmount"`
+}
+
+// Risk of a portfolio calculated with the daily returns of the dates shared by all the positions
+// the weights are the values of the positions with the last close
+type Risk struct {
+	Benchmark    string         `json:"benchmark"`
+	Confidence   float64        `json:"confidence"`
+	Observations int            `json:"observations"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Value        float64        `json:"value"`
+	Volatility   float64        `json:"volatility"`
+	Beta         *float64       `json:"beta"`
+	Positions    []PositionRisk `json:"positions"`
+	Correlation  Correlation    `json:"correlation"`
+	Historical   ValueAtRisk    `json:"historical"`
+	Parametric   ValueAtRisk    `json:"parametric"`
+	Missing      []string       `json:"missing"`
+}
+
+// CalculateRisk calculates the risk of the holdings (quantity by ticker) with the historical prices by ticker
+// the tickers without prices are listed in Missing, the benchmark is optional in prices
+// returns ErrInsufficientData if the positions share less than MinObservations returns
+func CalculateRisk(holdings map[string]float64, prices map[string][]models.HistoricalPrice, benchmark string, confidence float64) (Risk, error) {
+	benchmark = strings.ToUpper(benchmark)
+	risk := Risk{
+		Benchmark:  benchmark,
+		Confidence: confidence,
+		Positions:  []PositionRisk{},
+		Correlation: Correlation{
+			Tickers: []string{},
+			Matrix:  [][]float64{},
+		},
+		Missing: []string{},
+	}
+
+	if confidence <= 0 || confidence >= 1 {
+		return risk, fmt.Errorf("invalid confidence %g: must be between 0 and 1", confidence)
+	}
+
+	quantities := make(map[string]float64, len(holdings))
+	for ticker, quantity := range holdings {
+		if quantity > 0 {
+			quantities[strings.ToUpper(ticker)] += quantity
+		}
+	}
+
+	closes := make(map[string]map[string]float64)
+	var tickers []string
+	for ticker := range quantities {
+		series := closesByDate(prices[ticker])
+		if len(series) < 2 {
+			risk.Missing = append(risk.Missing, ticker)
+			continue
+		}
+
+		closes[ticker] = series
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+	sort.Strings(risk.Missing)
+
+	if len(tickers) == 0 {
+		return risk, ErrInsufficientData
+	}
+
+	benchmarkCloses := closesByDate(prices[benchmark])
+	dates := sharedDates(closes, benchmarkCloses)
+	if len(dates)-1 < MinObservations {
+		// the benchmark does not share enough dates, the risk is calculated without beta
+		benchmarkCloses = nil
+		dates = sharedDates(closes, nil)
+	}
+
+	if len(dates)-1 < MinObservations {
+		return risk, fmt.Errorf("%w: %d daily returns, at least %d are needed", ErrInsufficientData, max(len(dates)-1, 0), MinObservations)
+	}
+
+	returns := make(map[string][]float64, len(tickers))
+	for _, ticker := range tickers {
+		returns[ticker] = dailyReturns(closes[ticker], dates)
+	}
+
+	var benchmarkReturns []float64
+	if benchmarkCloses != nil {
+		benchmarkReturns = dailyReturns(benchmarkCloses, dates)
+	}
+
+	last := dates[len(dates)-1]
+	values := make(map[string]float64, len(tickers))
+	for _, ticker := range tickers {
+		values[ticker] = quantities[ticker] * closes[ticker][last]
+		risk.Value += values[ticker]
+	}
+
+	portfolioReturns := make([]float64, len(dates)-1)
+	for _, ticker := range tickers {
+		weight := values[ticker] / risk.Value
+		for t, value := range returns[ticker] {
+			portfolioReturns[t] += weight * value
+		}
+
+		risk.Positions = append(risk.Positions, PositionRisk{
+			Ticker:     ticker,
+			Weight:     weight,
+			Volatility: stdDev(returns[ticker]) * math.Sqrt(TradingDays),
+			Beta:       beta(returns[ticker], benchmarkReturns),
+		})
+	}
+
+	risk.Observations = len(portfolioReturns)
+	risk.From = dates[0]
+	risk.To = last
+	risk.Volatility = stdDev(portfolioReturns) * math.Sqrt(TradingDays)
+	risk.Beta = beta(portfolioReturns, benchmarkReturns)
+	risk.Correlation = correlationMatrix(tickers, returns)
+	risk.Historical = historicalVaR(portfolioReturns, confidence, risk.Value)
+	risk.Parametric = parametricVaR(portfolioReturns, confidence, risk.Value)
+
+	return risk, nil
+}
+
+// closesByDate returns the positive closes of the prices by date
+func closesByDate(prices []models.HistoricalPrice) map[string]float64 {
+	closes := make(map[string]float64, len(prices))
+	for _, price := range prices {
+		if price.Close > 0 {
+			closes[price.Date] = price.Close
+		}
+	}
+
+	return closes
+}
+
+// sharedDates returns the dates with close in all the series sorted, the benchmark is optional
+func sharedDates(closes map[string]map[string]float64, benchmark map[string]float64) []string {
+	var dates []string
+	first := true
+	for _, series := range closes {
+		if first {
+			for date := range series {
+				dates = append(dates, date)
+			}
+			first = false
+			continue
+		}
+
+		dates = filterDates(dates, series)
+	}
+
+	if benchmark != nil {
+		dates = filterDates(dates, benchmark)
+	}
+
+	sort.Strings(dates)
+	return dates
+}
+
+// filterDates keeps the dates with close in the series
+func filterDates(dates []string, series map[string]float64) []string {
+	filtered := dates[:0]
+	for _, date := range dates {
+		if _, ok := series[date]; ok {
+			filtered = append(filtered, date)
+		}
+	}
+
+	return filtered
+}
+
+// dailyReturns returns the simple returns between the consecutive dates
+func dailyReturns(closes map[string]float64, dates []string) []float64 {
+	returns := make([]float64, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		returns[i-1] = closes[dates[i]]/closes[dates[i-1]] - 1
+	}
+
+	return returns
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum / float64(len(values))
+}
+
+// covariance sample covariance of two series of the same length
+func covariance(a []float64, b []float64) float64 {
+	if len(a) < 2 {
+		return 0
+	}
+
+	meanA, meanB := mean(a), mean(b)
+	var sum float64
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+
+	return sum / float64(len(a)-1)
+}
+
+// stdDev sample standard deviation
+func stdDev(values []float64) float64 {
+	return math.Sqrt(covariance(values, values))
+}
+
+// beta of the returns against the benchmark, nil without benchmark or if it does not move
+func beta(returns []float64, benchmark []float64) *float64 {
+	if len(benchmark) != len(returns) {
+		return nil
+	}
+
+	variance := covariance(benchmark, benchmark)
+	if variance == 0 {
+		return nil
+	}
+
+	value := covariance(returns, benchmark) / variance
+	return &value
+}
+
+// correlationMatrix pairwise correlation of the returns, the pairs with a series that does not move are 0
+func correlationMatrix(tickers []string, returns map[string][]float64) Correlation {
+	matrix := make([][]float64, len(tickers))
+	for i, a := range tickers {
+		matrix[i] = make([]float64, len(tickers))
+		for j, b := range tickers {
+			if i == j {
+				matrix[i][j] = 1
+				continue
+			}
+
+			deviation := stdDev(returns[a]) * stdDev(returns[b])
+			if deviation == 0 {
+				continue
+			}
+			matrix[i][j] = covariance(returns[a], returns[b]) / deviation
+		}
+	}
+
+	return Correlation{Tickers: tickers, Matrix: matrix}
+}
+
+// historicalVaR is the loss of the worst returns of the tail of 1-confidence,
+// the tail has at least one return, CVaR is the average loss of the tail
+func historicalVaR(returns []float64, confidence float64, value float64) ValueAtRisk {
+	sorted := make([]float64, len(returns))
+	copy(sorted, returns)
+	sort.Float64s(sorted)
+
+	tail := int(math.Floor((1 - confidence) * float64(len(sorted))))
+	if tail < 1 {
+		tail = 1
+	}
+
+	valueAtRisk := math.Max(-sorted[tail-1], 0)
+	conditional := math.Max(-mean(sorted[:tail]), 0)
+
+	return ValueAtRisk{
+		VaR:        valueAtRisk,
+		CVaR:       conditional,
+		VaRAmount:  valueAtRisk * value,
+		CVaRAmount: conditional * value,
+	}
+}
+
+// parametricVaR is the loss of the normal distribution with the mean and the deviation of the returns
+func parametricVaR(returns []float64, confidence float64, value float64) ValueAtRisk {
+	mu := mean(returns)
+	sigma := stdDev(returns)
+
+	// z is the quantile of 1-confidence of the standard normal distribution (negative)
+	z := math.Sqrt2 * math.Erfinv(2*(1-confidence)-1)
+	density := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+
+	valueAtRisk := math.Max(-(mu + z*sigma), 0)
+	conditional := math.Max(-(mu - sigma*density/(1-confidence)), 0)
+
+	return ValueAtRisk{
+		VaR:        valueAtRisk,
+		CVaR:       conditional,
+		VaRAmount:  valueAtRisk * value,
+		CVaRAmount: conditional * value,
+	}
+}
@@ -0,0 +1,146 @@
+package portfolio
+
+import (
+	"api/models"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkReturns fixed daily returns of the benchmark, 20 returns
+var benchmarkReturns = []float64{
+	0.01, -0.02, 0.015, -0.005, 0.012, -0.008, 0.004, -0.017, 0.02, 0.003,
+	-0.011, 0.006, 0.009, -0.004, -0.013, 0.018, 0.002, -0.006, 0.007, -0.001,
+}
+
+// series builds the prices from the close of the first day and the daily returns scaled by factor
+func series(first float64, returns []float64, factor float64) []models.HistoricalPrice {
+	prices := []models.HistoricalPrice{{Date: date("2025-01-01").Format("2006-01-02"), Close: first}}
+	close := first
+	for i, value := range returns {
+		close *= 1 + value*factor
+		prices = append(prices, models.HistoricalPrice{
+			Date:  date("2025-01-01").AddDate(0, 0, i+1).Format("2006-01-02"),
+			Close: close,
+		})
+	}
+
+	return prices
+}
+
+func TestCalculateRisk(t *testing.T) {
+	prices := map[string][]models.HistoricalPrice{
+		"SPY":  series(500, benchmarkReturns, 1),
+		"AAPL": series(100, benchmarkReturns, 2),
+		"MSFT": series(200, benchmarkReturns, -1),
+	}
+
+	risk, err := CalculateRisk(map[string]float64{"AAPL": 10, "msft": 5, "TSLA": 3, "NVDA": 0}, prices, "spy", 0.95)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SPY", risk.Benchmark)
+	assert.Equal(t, 20, risk.Observations)
+	assert.Equal(t, "2025-01-01", risk.From)
+	assert.Equal(t, "2025-01-21", risk.To)
+	assert.Equal(t, []string{"TSLA"}, risk.Missing)
+
+	lastAAPL := prices["AAPL"][20].Close * 10
+	lastMSFT := prices["MSFT"][20].Close * 5
+	assert.InDelta(t, lastAAPL+lastMSFT, risk.Value, 1e-9)
+
+	weightAAPL := lastAAPL / risk.Value
+	weightMSFT := lastMSFT / risk.Value
+	benchmarkVolatility := stdDev(benchmarkReturns) * math.Sqrt(TradingDays)
+
+	if assert.Len(t, risk.Positions, 2) {
+		assert.Equal(t, "AAPL", risk.Positions[0].Ticker)
+		assert.InDelta(t, weightAAPL, risk.Positions[0].Weight, 1e-9)
+		assert.InDelta(t, 2*benchmarkVolatility, risk.Positions[0].Volatility, 1e-9)
+		assert.InDelta(t, 2, *risk.Positions[0].Beta, 1e-9)
+
+		assert.Equal(t, "MSFT", risk.Positions[1].Ticker)
+		assert.InDelta(t, benchmarkVolatility, risk.Positions[1].Volatility, 1e-9)
+		assert.InDelta(t, -1, *risk.Positions[1].Beta, 1e-9)
+	}
+
+	// the returns of the portfolio are the returns of the benchmark scaled by the beta
+	portfolioBeta := 2*weightAAPL - weightMSFT
+	assert.InDelta(t, portfolioBeta, *risk.Beta, 1e-9)
+	assert.InDelta(t, math.Abs(portfolioBeta)*benchmarkVolatility, risk.Volatility, 1e-9)
+
+	assert.Equal(t, []string{"AAPL", "MSFT"}, risk.Correlation.Tickers)
+	assert.InDelta(t, 1, risk.Correlation.Matrix[0][0], 1e-9)
+	assert.InDelta(t, -1, risk.Correlation.Matrix[0][1], 1e-9)
+	assert.InDelta(t, -1, risk.Correlation.Matrix[1][0], 1e-9)
+
+	// with 20 returns the tail of 5% is the worst return
+	var worst float64
+	var returns []float64
+	for _, value := range benchmarkReturns {
+		returns = append(returns, portfolioBeta*value)
+		worst = math.Min(worst, portfolioBeta*value)
+	}
+	assert.InDelta(t, -worst, risk.Historical.VaR, 1e-9)
+	assert.InDelta(t, -worst, risk.Historical.CVaR, 1e-9)
+	assert.InDelta(t, -worst*risk.Value, risk.Historical.VaRAmount, 1e-9)
+
+	mu, sigma := mean(returns), stdDev(returns)
+	assert.InDelta(t, -(mu - 1.6448536269514722*sigma), risk.Parametric.VaR, 1e-9)
+	assert.InDelta(t, -(mu - sigma*0.10313564037537128/0.05), risk.Parametric.CVaR, 1e-9)
+	assert.Greater(t, risk.Parametric.CVaR, risk.Parametric.VaR)
+}
+
+func TestCalculateRiskWithoutBenchmark(t *testing.T) {
+	prices := map[string][]models.HistoricalPrice{
+		"AAPL": series(100, benchmarkReturns, 1),
+	}
+
+	risk, err := CalculateRisk(map[string]float64{"AAPL": 1}, prices, "SPY", 0.99)
+
+	assert.NoError(t, err)
+	assert.Nil(t, risk.Beta)
+	assert.Nil(t, risk.Positions[0].Beta)
+	assert.InDelta(t, 1, risk.Positions[0].Weight, 1e-9)
+}
+
+func TestCalculateRiskFlatPrices(t *testing.T) {
+	flat := make([]float64, len(benchmarkReturns))
+	prices := map[string][]models.HistoricalPrice{
+		"SPY":  series(500, flat, 1),
+		"AAPL": series(100, benchmarkReturns, 1),
+		"BOND": series(100, flat, 1),
+	}
+
+	risk, err := CalculateRisk(map[string]float64{"AAPL": 1, "BOND": 1}, prices, "SPY", 0.95)
+
+	assert.NoError(t, err)
+	assert.Nil(t, risk.Beta)
+	assert.Equal(t, 0.0, risk.Correlation.Matrix[0][1])
+	assert.Equal(t, 0.0, risk.Positions[1].Volatility)
+}
+
+func TestCalculateRiskErrors(t *testing.T) {
+	short := series(100, benchmarkReturns[:10], 1)
+
+	testCases := []struct {
+		desc       string
+		holdings   map[string]float64
+		confidence float64
+		isData     bool
+	}{
+		{desc: "invalid confidence", holdings: map[string]float64{"AAPL": 1}, confidence: 1},
+		{desc: "without positions", holdings: map[string]float64{}, confidence: 0.95, isData: true},
+		{desc: "without prices", holdings: map[string]float64{"TSLA": 1}, confidence: 0.95, isData: true},
+		{desc: "few returns", holdings: map[string]float64{"AAPL": 1}, confidence: 0.95, isData: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := CalculateRisk(tC.holdings, map[string][]models.HistoricalPrice{"AAPL": short}, "SPY", tC.confidence)
+
+			assert.Error(t, err)
+			assert.Equal(t, tC.isData, errors.Is(err, ErrInsufficientData))
+		})
+	}
+}
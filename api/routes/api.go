@@ -1,20 +1,38 @@
 package routes
 
 import (
+	"api/alerts"
+	appconfig "api/config"
 	"api/controllers"
 	"api/models"
 	"api/services"
+	"api/services/llm"
+	"api/stream"
+	"log"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func SetupRoutes(router *chi.Mux, config *models.ServerConfig) {
+// SetupRoutes configures the routes of the api, the stream is only available with a hub
+// the services share the providers of the registry
+func SetupRoutes(router *chi.Mux, config *models.ServerConfig, registry *services.ProviderRegistry, hub *stream.Hub) {
 	// Initialize services
-	tickerService := services.NewTickerService(config.DB, config.Cache)
+	tickerService := services.NewTickerService(config.DB, config.Cache, registry)
+	llmProvider, err := llm.NewProvider(appconfig.LLM())
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Initialize controllers
-	tickersController := controllers.NewTickersController(tickerService, config.Cache)
+	tickersController := controllers.NewTickersController(tickerService, services.NewPredictionService(config.DB, tickerService), llmProvider, config.Cache)
 	onboardingController := controllers.NewOnboardingController(services.NewOnboardingService(config.DB))
+	watchlistsController := controllers.NewWatchlistsController(services.NewWatchlistService(config.DB), tickerService, llmProvider, config.Cache)
+	backtestsController := controllers.NewBacktestsController(services.NewBacktestService(config.DB, tickerService))
+	brokeragesController := controllers.NewBrokeragesController(services.NewBrokerageService(config.DB, tickerService))
+	alertsController := controllers.NewAlertsController(services.NewAlertService(config.DB, tickerService, alerts.DefaultSinks()))
+	portfoliosController := controllers.NewPortfoliosController(services.NewPortfolioService(config.DB, tickerService, tickerService, config.Cache))
+	screenerController := controllers.NewScreenerController(services.NewScreenerService(config.DB, tickerService))
+	providersController := controllers.NewProvidersController(registry)
 	// API v1 routes
 	router.Route("/api/v1", func(r chi.Router) {
 		// Tickers routes
@@ -22,15 +40,80 @@ func SetupRoutes(router *chi.Mux, config *models.ServerConfig) {
 		r.Route("/tickers", func(r chi.Router) {
 			r.Get("/", tickersController.ListTickers)
 			r.Get("/{id}/historical", tickersController.GetTickerHistoricalPrices)
+			r.Get("/{id}/indicators", tickersController.GetTickerIndicators)
 			r.Get("/{id}/logo", tickersController.GetTickerLogo)
 			r.Get("/{id}/overview", tickersController.GetTickerOverview)
 			r.Get("/{id}/predictions", tickersController.GetTickerPredictions)
+			r.Get("/{id}/predictions/accuracy", tickersController.GetTickerPredictionsAccuracy)
 		})
 
+		// Watchlists routes
+		r.Route("/watchlists", func(r chi.Router) {
+			r.Get("/", watchlistsController.ListWatchlists)
+			r.Post("/", watchlistsController.CreateWatchlist)
+			r.Get("/{id}", watchlistsController.GetWatchlist)
+			r.Patch("/{id}", watchlistsController.UpdateWatchlist)
+			r.Delete("/{id}", watchlistsController.DeleteWatchlist)
+			r.Get("/{id}/tickers", watchlistsController.ListWatchlistTickers)
+			r.Post("/{id}/tickers", watchlistsController.AddWatchlistTickers)
+			r.Delete("/{id}/tickers/{ticker}", watchlistsController.RemoveWatchlistTicker)
+		})
+
+		// Portfolios routes
+		r.Route("/portfolios", func(r chi.Router) {
+			r.Get("/", portfoliosController.ListPortfolios)
+			r.Post("/", portfoliosController.CreatePortfolio)
+			r.Get("/{id}", portfoliosController.GetPortfolio)
+			r.Patch("/{id}", portfoliosController.UpdatePortfolio)
+			r.Delete("/{id}", portfoliosController.DeletePortfolio)
+			r.Get("/{id}/summary", portfoliosController.GetPortfolioSummary)
+			r.Get("/{id}/risk", portfoliosController.GetPortfolioRisk)
+			r.Post("/{id}/transactions", portfoliosController.AddPortfolioTransaction)
+			r.Delete("/{id}/transactions/{transactionId}", portfoliosController.DeletePortfolioTransaction)
+		})
+
+		// Alerts routes
+		r.Route("/alerts", func(r chi.Router) {
+			r.Get("/", alertsController.ListAlerts)
+			r.Post("/", alertsController.CreateAlert)
+			r.Get("/{id}", alertsController.GetAlert)
+			r.Patch("/{id}", alertsController.UpdateAlert)
+			r.Delete("/{id}", alertsController.DeleteAlert)
+			r.Get("/{id}/events", alertsController.ListAlertEvents)
+		})
+
+		// Brokerages routes
+		r.Route("/brokerages", func(r chi.Router) {
+			r.Get("/", brokeragesController.ListBrokerages)
+			r.Get("/{id}", brokeragesController.GetBrokerage)
+		})
+
+		// Stream routes, server-sent events and websockets
+		if hub != nil {
+			streamController := controllers.NewStreamController(hub, tickerService, appconfig.Stream().HeartbeatInterval, appconfig.Stream().MaxTickers)
+			r.Get("/stream", streamController.Stream)
+
+			websocketController := controllers.NewWebsocketController(hub, tickerService, llmProvider, config.Cache, appconfig.Stream().HeartbeatInterval, appconfig.Stream().MaxTickers, appconfig.Stream().BufferSize)
+			r.Get("/ws", websocketController.Connect)
+		}
+
+		// Screener routes
+		r.Post("/screener", screenerController.Screen)
+
+		// Backtests routes
+		r.Post("/backtests", backtestsController.RunBacktest)
+
+		// Providers routes
+		r.Get("/providers/health", providersController.GetHealth)
+
 		// Onboarding routes
 		r.Route("/onboarding", func(r chi.Router) {
 			r.Get("/", onboardingController.GetOnboarding)
 			r.Patch("/", onboardingController.UpdateOnboarding)
+			r.Get("/tours", onboardingController.ListTours)
+			r.Get("/tours/{tour}", onboardingController.GetTour)
+			r.Patch("/tours/{tour}", onboardingController.UpdateTour)
+			r.Post("/tours/{tour}/reset", onboardingController.ResetTour)
 		})
 	})
 
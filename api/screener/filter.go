@@ -0,0 +1,260 @@
+package screener
+
+// screener filters the tickers with a tree of conditions over their company data,
+// the sentiment of their ratings and their recommendations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFilter is returned when the tree of the filter is not valid
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// MaxDepth max nesting of the groups of a filter
+const MaxDepth = 5
+
+// MaxConditions max conditions of a filter
+const MaxConditions = 50
+
+// Operator compares the value of a field with the value of the condition
+type Operator string
+
+const (
+	Equal          Operator = "eq"
+	NotEqual       Operator = "neq"
+	GreaterThan    Operator = "gt"
+	GreaterOrEqual Operator = "gte"
+	LessThan       Operator = "lt"
+	LessOrEqual    Operator = "lte"
+	In             Operator = "in"
+	Contains       Operator = "contains"
+)
+
+// Filter is a node of the tree, a group with And or Or, or a condition with Field, Op and Value
+// the empty filter matches all the tickers
+type Filter struct {
+	And   []Filter    `json:"and,omitempty"`
+	Or    []Filter    `json:"or,omitempty"`
+	Field string      `json:"field,omitempty"`
+	Op    Operator    `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// operators supported by each kind of field
+var operators = map[fieldKind][]Operator{
+	numberField: {Equal, NotEqual, GreaterThan, GreaterOrEqual, LessThan, LessOrEqual, In},
+	textField:   {Equal, NotEqual, In, Contains},
+	listField:   {Contains, In},
+}
+
+// IsEmpty checks the filter has no condition
+func (f Filter) IsEmpty() bool {
+	return len(f.And) == 0 && len(f.Or) == 0 && f.Field == ""
+}
+
+// Validate checks the fields, the operators and the values of the conditions and the size of the tree
+func (f Filter) Validate() error {
+	if f.IsEmpty() {
+		return nil
+	}
+
+	conditions := 0
+	return f.validate("filter", 1, &conditions)
+}
+
+func (f Filter) validate(path string, depth int, conditions *int) error {
+	if depth > MaxDepth {
+		return fmt.Errorf("%w: %s: the groups can be nested at most %d levels", ErrInvalidFilter, path, MaxDepth)
+	}
+
+	groups := 0
+	if len(f.And) > 0 {
+		groups++
+	}
+	if len(f.Or) > 0 {
+		groups++
+	}
+	if f.Field != "" {
+		groups++
+	}
+
+	if groups != 1 {
+		return fmt.Errorf("%w: %s: must have one of and, or or field", ErrInvalidFilter, path)
+	}
+
+	for i, child := range f.And {
+		if err := child.validate(fmt.Sprintf("%s.and[%d]", path, i), depth+1, conditions); err != nil {
+			return err
+		}
+	}
+
+	for i, child := range f.Or {
+		if err := child.validate(fmt.Sprintf("%s.or[%d]", path, i), depth+1, conditions); err != nil {
+			return err
+		}
+	}
+
+	if f.Field == "" {
+		return nil
+	}
+
+	*conditions++
+	if *conditions > MaxConditions {
+		return fmt.Errorf("%w: at most %d conditions are allowed", ErrInvalidFilter, MaxConditions)
+	}
+
+	return f.validateCondition(path)
+}
+
+// validateCondition checks the operator is supported by the kind of the field and the type of the value
+func (f Filter) validateCondition(path string) error {
+	kind, ok := fields[f.Field]
+	if !ok {
+		return fmt.Errorf("%w: %s: unknown field %q, the fields are %s", ErrInvalidFilter, path, f.Field, strings.Join(Fields(), ", "))
+	}
+
+	supported := false
+	for _, op := range operators[kind] {
+		supported = supported || op == f.Op
+	}
+
+	if !supported {
+		return fmt.Errorf("%w: %s: the operator %q is not supported by %s", ErrInvalidFilter, path, f.Op, f.Field)
+	}
+
+	values := []interface{}{f.Value}
+	if f.Op == In {
+		list, ok := f.Value.([]interface{})
+		if !ok || len(list) == 0 {
+			return fmt.Errorf("%w: %s: the value of %s must be a non empty list", ErrInvalidFilter, path, In)
+		}
+		values = list
+	}
+
+	for _, value := range values {
+		if kind == numberField {
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%w: %s: the value of %s must be a number", ErrInvalidFilter, path, f.Field)
+			}
+			continue
+		}
+
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%w: %s: the value of %s must be a string", ErrInvalidFilter, path, f.Field)
+		}
+	}
+
+	return nil
+}
+
+// Match checks the row meets the filter, the filter must be valid
+// a condition over a field without value (e.g. a ticker without company data) is not met
+func (f Filter) Match(row Row) bool {
+	if len(f.And) > 0 {
+		for _, child := range f.And {
+			if !child.Match(row) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(f.Or) > 0 {
+		for _, child := range f.Or {
+			if child.Match(row) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if f.Field == "" {
+		return true
+	}
+
+	switch fields[f.Field] {
+	case numberField:
+		value, ok := row.number(f.Field)
+		return ok && matchNumber(f.Op, value, f.Value)
+	case textField:
+		value, ok := row.text(f.Field)
+		return ok && matchText(f.Op, value, f.Value)
+	case listField:
+		return matchList(f.Op, row.list(f.Field), f.Value)
+	}
+
+	return false
+}
+
+func matchNumber(op Operator, value float64, expected interface{}) bool {
+	if op == In {
+		for _, item := range expected.([]interface{}) {
+			if value == item.(float64) {
+				return true
+			}
+		}
+		return false
+	}
+
+	number := expected.(float64)
+	switch op {
+	case Equal:
+		return value == number
+	case NotEqual:
+		return value != number
+	case GreaterThan:
+		return value > number
+	case GreaterOrEqual:
+		return value >= number
+	case LessThan:
+		return value < number
+	case LessOrEqual:
+		return value <= number
+	}
+
+	return false
+}
+
+// matchText compares the texts without case
+func matchText(op Operator, value string, expected interface{}) bool {
+	if op == In {
+		for _, item := range expected.([]interface{}) {
+			if strings.EqualFold(value, item.(string)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	text := expected.(string)
+	switch op {
+	case Equal:
+		return strings.EqualFold(value, text)
+	case NotEqual:
+		return !strings.EqualFold(value, text)
+	case Contains:
+		return strings.Contains(strings.ToLower(value), strings.ToLower(text))
+	}
+
+	return false
+}
+
+// matchList checks the list has the value (contains) or any of the values (in)
+func matchList(op Operator, values []string, expected interface{}) bool {
+	candidates := []interface{}{expected}
+	if op == In {
+		candidates = expected.([]interface{})
+	}
+
+	for _, candidate := range candidates {
+		for _, value := range values {
+			if strings.EqualFold(value, candidate.(string)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,93 @@
+package screener
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFilter(t *testing.T, body string) Filter {
+	t.Helper()
+
+	var filter Filter
+	assert.NoError(t, json.Unmarshal([]byte(body), &filter))
+	return filter
+}
+
+func TestFilterValidate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		body     string
+		hasError bool
+	}{
+		{desc: "empty filter", body: `{}`},
+		{desc: "condition", body: `{"field":"marketCap","op":"gte","value":1000000000}`},
+		{desc: "nested groups", body: `{"and":[{"field":"sector","op":"in","value":["Technology","Energy"]},{"or":[{"field":"score","op":"gt","value":0.5},{"field":"recentActions","op":"contains","value":"upgraded"}]}]}`},
+		{desc: "unknown field", body: `{"field":"pe","op":"gt","value":10}`, hasError: true},
+		{desc: "unsupported operator", body: `{"field":"sector","op":"gt","value":"Technology"}`, hasError: true},
+		{desc: "number as text", body: `{"field":"price","op":"lt","value":"10"}`, hasError: true},
+		{desc: "text as number", body: `{"field":"country","op":"eq","value":1}`, hasError: true},
+		{desc: "empty in", body: `{"field":"sector","op":"in","value":[]}`, hasError: true},
+		{desc: "group and field", body: `{"field":"price","op":"lt","value":10,"and":[{"field":"beta","op":"lt","value":1}]}`, hasError: true},
+		{desc: "too deep", body: `{"and":[{"and":[{"and":[{"and":[{"and":[{"field":"beta","op":"lt","value":1}]}]}]}]}]}`, hasError: true},
+		{desc: "too many conditions", body: `{"or":[` + strings.Repeat(`{"field":"beta","op":"lt","value":1},`, MaxConditions) + `{"field":"beta","op":"lt","value":1}]}`, hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			err := parseFilter(t, tC.body).Validate()
+			if tC.hasError {
+				assert.True(t, errors.Is(err, ErrInvalidFilter), err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	price, marketCap, upside := 50.0, 2e9, 20.0
+	row := Row{
+		Ticker:          "ACME",
+		Company:         "Acme Corp",
+		Sector:          "Technology",
+		Country:         "US",
+		Price:           &price,
+		MarketCap:       &marketCap,
+		Sentiment:       "positive",
+		Score:           0.6,
+		Recommendations: 5,
+		TargetUpside:    &upside,
+		LastAction:      "upgraded",
+		RecentActions:   []string{"target raised", "upgraded"},
+	}
+
+	testCases := []struct {
+		desc     string
+		body     string
+		expected bool
+	}{
+		{desc: "empty filter", body: `{}`, expected: true},
+		{desc: "number", body: `{"field":"marketCap","op":"gte","value":1000000000}`, expected: true},
+		{desc: "number not met", body: `{"field":"price","op":"gt","value":50}`, expected: false},
+		{desc: "text without case", body: `{"field":"sector","op":"eq","value":"technology"}`, expected: true},
+		{desc: "text in", body: `{"field":"country","op":"in","value":["CA","us"]}`, expected: true},
+		{desc: "text contains", body: `{"field":"company","op":"contains","value":"acme"}`, expected: true},
+		{desc: "list contains", body: `{"field":"recentActions","op":"contains","value":"Upgraded"}`, expected: true},
+		{desc: "list in", body: `{"field":"recentActions","op":"in","value":["downgraded","initiated"]}`, expected: false},
+		{desc: "without value", body: `{"field":"beta","op":"lt","value":1}`, expected: false},
+		{desc: "empty text", body: `{"field":"industry","op":"neq","value":"Software"}`, expected: false},
+		{desc: "and", body: `{"and":[{"field":"score","op":"gt","value":0.5},{"field":"targetUpside","op":"gte","value":25}]}`, expected: false},
+		{desc: "or", body: `{"or":[{"field":"score","op":"lt","value":0},{"field":"targetUpside","op":"gte","value":15}]}`, expected: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := parseFilter(t, tC.body)
+			assert.NoError(t, filter.Validate())
+			assert.Equal(t, tC.expected, filter.Match(row))
+		})
+	}
+}
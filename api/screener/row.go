@@ -0,0 +1,268 @@
+package screener
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecentDays days of the recommendations included in the recent actions
+const RecentDays = 30
+
+type fieldKind int
+
+const (
+	numberField fieldKind = iota
+	textField
+	listField
+)
+
+// fields of the rows that can be filtered
+var fields = map[string]fieldKind{
+	"ticker":          textField,
+	"company":         textField,
+	"sector":          textField,
+	"industry":        textField,
+	"country":         textField,
+	"marketCap":       numberField,
+	"beta":            numberField,
+	"price":           numberField,
+	"volume":          numberField,
+	"sentiment":       textField,
+	"score":           numberField,
+	"recommendations": numberField,
+	"positiveCount":   numberField,
+	"neutralCount":    numberField,
+	"negativeCount":   numberField,
+	"targetUpside":    numberField,
+	"lastAction":      textField,
+	"recentActions":   listField,
+}
+
+// Fields returns the fields that can be filtered sorted by name
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsSortable checks the rows can be ordered by the field, the lists are not sortable
+func IsSortable(field string) bool {
+	kind, ok := fields[field]
+	return ok && kind != listField
+}
+
+// Row is a ticker with the values of the fields of the screener
+// the values of the company data are nil or empty while the ticker has no snapshot,
+// TargetUpside is the percentage from the price to the target of the latest recommendation with target
+type Row struct {
+	Ticker          string            `json:"ticker"`
+	Company         string            `json:"company"`
+	Sector          string            `json:"sector"`
+	Industry        string            `json:"industry"`
+	Country         string            `json:"country"`
+	MarketCap       *float64          `json:"marketCap"`
+	Beta            *float64          `json:"beta"`
+	Price           *float64          `json:"price"`
+	Volume          *float64          `json:"volume"`
+	Sentiment       ratings.Sentiment `json:"sentiment"`
+	Score           float64           `json:"score"`
+	Recommendations int               `json:"recommendations"`
+	PositiveCount   int               `json:"positiveCount"`
+	NeutralCount    int               `json:"neutralCount"`
+	NegativeCount   int               `json:"negativeCount"`
+	TargetTo        *float64          `json:"targetTo"`
+	TargetUpside    *float64          `json:"targetUpside"`
+	LastAction      string            `json:"lastAction"`
+	LastActionAt    *time.Time        `json:"lastActionAt"`
+	RecentActions   []string          `json:"recentActions"`
+	SnapshotAt      *time.Time        `json:"snapshotAt"`
+}
+
+// NewRow creates the row of a ticker with its recommendations and its sentiment already calculated
+// snapshot is nil if the ticker has no company data, the recent actions are the actions of the last RecentDays before now
+func NewRow(ticker models.Ticker, snapshot *models.CompanySnapshot, now time.Time) Row {
+	row := Row{
+		Ticker:          ticker.ID.String(),
+		Company:         ticker.Company,
+		Sentiment:       ticker.SentimentScore.Sentiment,
+		Score:           ticker.SentimentScore.Score,
+		Recommendations: len(ticker.Recommendations),
+		PositiveCount:   ticker.SentimentScore.PositiveCount,
+		NeutralCount:    ticker.SentimentScore.NeutralCount,
+		NegativeCount:   ticker.SentimentScore.NegativeCount,
+		RecentActions:   []string{},
+	}
+
+	if row.Sentiment == "" {
+		row.Sentiment = ratings.NeutralSentiment
+	}
+
+	if snapshot != nil {
+		row.Sector = snapshot.Sector
+		row.Industry = snapshot.Industry
+		row.Country = snapshot.Country
+		row.MarketCap = positive(snapshot.MarketCap)
+		row.Beta = &snapshot.Beta
+		row.Price = positive(snapshot.Price)
+		row.Volume = &snapshot.Volume
+		updatedAt := snapshot.UpdatedAt
+		row.SnapshotAt = &updatedAt
+	}
+
+	recent := make(map[string]bool)
+	var latest, latestTarget *models.Recommendation
+	for i := range ticker.Recommendations {
+		recommendation := &ticker.Recommendations[i]
+		if latest == nil || recommendation.Time.After(latest.Time) {
+			latest = recommendation
+		}
+
+		if recommendation.TargetTo > 0 && (latestTarget == nil || recommendation.Time.After(latestTarget.Time)) {
+			latestTarget = recommendation
+		}
+
+		if action := string(recommendation.Action.Normalize()); action != "" && !recommendation.Time.Before(now.AddDate(0, 0, -RecentDays)) {
+			recent[action] = true
+		}
+	}
+
+	if latest != nil {
+		row.LastAction = string(latest.Action.Normalize())
+		lastActionAt := latest.Time
+		row.LastActionAt = &lastActionAt
+	}
+
+	if latestTarget != nil {
+		target := latestTarget.TargetTo
+		row.TargetTo = &target
+		if row.Price != nil {
+			upside := (target/(*row.Price) - 1) * 100
+			row.TargetUpside = &upside
+		}
+	}
+
+	for action := range recent {
+		row.RecentActions = append(row.RecentActions, action)
+	}
+	sort.Strings(row.RecentActions)
+
+	return row
+}
+
+func positive(value float64) *float64 {
+	if value <= 0 {
+		return nil
+	}
+
+	return &value
+}
+
+// number returns the value of a numeric field, false if the row has no value
+func (r Row) number(field string) (float64, bool) {
+	var value *float64
+	switch field {
+	case "marketCap":
+		value = r.MarketCap
+	case "beta":
+		value = r.Beta
+	case "price":
+		value = r.Price
+	case "volume":
+		value = r.Volume
+	case "targetUpside":
+		value = r.TargetUpside
+	case "score":
+		return r.Score, true
+	case "recommendations":
+		return float64(r.Recommendations), true
+	case "positiveCount":
+		return float64(r.PositiveCount), true
+	case "neutralCount":
+		return float64(r.NeutralCount), true
+	case "negativeCount":
+		return float64(r.NegativeCount), true
+	}
+
+	if value == nil {
+		return 0, false
+	}
+
+	return *value, true
+}
+
+// text returns the value of a text field, false if the row has no value
+func (r Row) text(field string) (string, bool) {
+	var value string
+	switch field {
+	case "ticker":
+		value = r.Ticker
+	case "company":
+		value = r.Company
+	case "sector":
+		value = r.Sector
+	case "industry":
+		value = r.Industry
+	case "country":
+		value = r.Country
+	case "sentiment":
+		value = string(r.Sentiment)
+	case "lastAction":
+		value = r.LastAction
+	}
+
+	return value, strings.TrimSpace(value) != ""
+}
+
+func (r Row) list(field string) []string {
+	if field == "recentActions" {
+		return r.RecentActions
+	}
+
+	return nil
+}
+
+// SortRows orders the rows by the field, the rows without value are last in both directions
+// and the ties are ordered by ticker
+func SortRows(rows []Row, field string, descending bool) {
+	kind := fields[field]
+	sort.SliceStable(rows, func(i, j int) bool {
+		var compare int
+		var okI, okJ bool
+
+		if kind == numberField {
+			var a, b float64
+			a, okI = rows[i].number(field)
+			b, okJ = rows[j].number(field)
+			if a < b {
+				compare = -1
+			} else if a > b {
+				compare = 1
+			}
+		} else {
+			var a, b string
+			a, okI = rows[i].text(field)
+			b, okJ = rows[j].text(field)
+			compare = strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		}
+
+		if okI != okJ {
+			return okI
+		}
+
+		if compare == 0 || !okI {
+			return rows[i].Ticker < rows[j].Ticker
+		}
+
+		if descending {
+			return compare > 0
+		}
+		return compare < 0
+	})
+}
@@ -0,0 +1,79 @@
+package screener
+
+import (
+	"api/models"
+	"api/models/ratings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRow(t *testing.T) {
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	ticker := models.Ticker{
+		ID:      "acme",
+		Company: "Acme Corp",
+		Recommendations: []models.Recommendation{
+			{Action: "target raised by", TargetTo: 60, Time: now.AddDate(0, 0, -10)},
+			{Action: "upgraded by", Time: now.AddDate(0, 0, -2)},
+			{Action: "downgraded by", TargetTo: 40, Time: now.AddDate(0, 0, -90)},
+		},
+		SentimentScore: ratings.SentimentScore{Sentiment: ratings.PositiveSentiment, PositiveCount: 2, NeutralCount: 1, Score: 0.66},
+	}
+	snapshot := &models.CompanySnapshot{TickerID: "ACME", Sector: "Technology", Price: 50, MarketCap: 2e9, Beta: 1.2, UpdatedAt: now}
+
+	row := NewRow(ticker, snapshot, now)
+
+	assert.Equal(t, "ACME", row.Ticker)
+	assert.Equal(t, "Technology", row.Sector)
+	assert.Equal(t, 3, row.Recommendations)
+	assert.Equal(t, 2, row.PositiveCount)
+	assert.Equal(t, "upgraded", row.LastAction)
+	assert.Equal(t, []string{"target raised", "upgraded"}, row.RecentActions)
+	assert.InDelta(t, 60, *row.TargetTo, 1e-9)
+	assert.InDelta(t, 20, *row.TargetUpside, 1e-9)
+	assert.InDelta(t, 1.2, *row.Beta, 1e-9)
+}
+
+func TestNewRowWithoutSnapshot(t *testing.T) {
+	ticker := models.Ticker{
+		ID:              "ACME",
+		Recommendations: []models.Recommendation{{Action: "target set by", TargetTo: 60, Time: time.Now()}},
+	}
+
+	row := NewRow(ticker, nil, time.Now())
+
+	assert.Nil(t, row.Price)
+	assert.Nil(t, row.TargetUpside)
+	assert.InDelta(t, 60, *row.TargetTo, 1e-9)
+	assert.Equal(t, ratings.NeutralSentiment, row.Sentiment)
+}
+
+func TestSortRows(t *testing.T) {
+	low, high := 10.0, 90.0
+	rows := []Row{
+		{Ticker: "C"},
+		{Ticker: "B", Price: &high},
+		{Ticker: "A", Price: &low},
+		{Ticker: "D", Price: &high},
+	}
+
+	SortRows(rows, "price", true)
+	assert.Equal(t, []string{"B", "D", "A", "C"}, tickers(rows))
+
+	SortRows(rows, "price", false)
+	assert.Equal(t, []string{"A", "B", "D", "C"}, tickers(rows))
+
+	SortRows(rows, "ticker", true)
+	assert.Equal(t, []string{"D", "C", "B", "A"}, tickers(rows))
+}
+
+func tickers(rows []Row) []string {
+	result := make([]string, len(rows))
+	for i, row := range rows {
+		result[i] = row.Ticker
+	}
+
+	return result
+}
@@ -0,0 +1,162 @@
+package screener
+
+import (
+	"fmt"
+	"strings"
+)
+
+// column of a field evaluated by the database, the tickers are joined with their company snapshots
+// positive marks the fields without value when they are not positive, like NewRow
+type column struct {
+	name     string
+	positive bool
+}
+
+// columns of the fields of the tickers and the company snapshots, the other fields are calculated
+// with the recommendations after the query
+var columns = map[string]column{
+	"ticker":    {name: "tickers.id"},
+	"company":   {name: "tickers.company"},
+	"sector":    {name: "company_snapshots.sector"},
+	"industry":  {name: "company_snapshots.industry"},
+	"country":   {name: "company_snapshots.country"},
+	"marketCap": {name: "company_snapshots.market_cap", positive: true},
+	"beta":      {name: "company_snapshots.beta"},
+	"price":     {name: "company_snapshots.price", positive: true},
+	"volume":    {name: "company_snapshots.volume"},
+}
+
+// comparisons of the operators of the numbers
+var comparisons = map[Operator]string{
+	Equal:          "=",
+	NotEqual:       "<>",
+	GreaterThan:    ">",
+	GreaterOrEqual: ">=",
+	LessThan:       "<",
+	LessOrEqual:    "<=",
+}
+
+// SQL returns the condition of the filter over the tickers joined with their company snapshots, the filter must be valid
+// the conditions of the calculated fields are left out, exact is false when the rows must be matched again with Match
+func (f Filter) SQL() (condition string, args []interface{}, exact bool) {
+	if f.IsEmpty() {
+		return "", nil, true
+	}
+
+	return f.sql()
+}
+
+func (f Filter) sql() (string, []interface{}, bool) {
+	if len(f.And) > 0 || len(f.Or) > 0 {
+		children, separator := f.And, " AND "
+		if len(f.Or) > 0 {
+			children, separator = f.Or, " OR "
+		}
+
+		var conditions []string
+		var args []interface{}
+		exact := true
+		for _, child := range children {
+			condition, childArgs, childExact := child.sql()
+			exact = exact && childExact
+
+			if condition == "" {
+				// any row can meet the calculated child and so the whole group
+				if len(f.Or) > 0 {
+					return "", nil, false
+				}
+				continue
+			}
+
+			conditions = append(conditions, condition)
+			args = append(args, childArgs...)
+		}
+
+		if len(conditions) == 0 {
+			return "", nil, exact
+		}
+
+		return "(" + strings.Join(conditions, separator) + ")", args, exact
+	}
+
+	column, ok := columns[f.Field]
+	if !ok {
+		return "", nil, false
+	}
+
+	if fields[f.Field] == numberField {
+		return column.numberSQL(f.Op, f.Value)
+	}
+
+	return column.textSQL(f.Op, f.Value)
+}
+
+// numberSQL compares the column, the missing snapshots are NULL and do not meet any comparison
+func (c column) numberSQL(op Operator, value interface{}) (string, []interface{}, bool) {
+	condition := fmt.Sprintf("%s %s ?", c.name, comparisons[op])
+	if op == In {
+		condition = c.name + " IN ?"
+	}
+
+	if c.positive {
+		condition = fmt.Sprintf("(%s > 0 AND %s)", c.name, condition)
+	}
+
+	return condition, []interface{}{value}, true
+}
+
+// textSQL compares the column without case, the empty texts have no value like in Match
+func (c column) textSQL(op Operator, value interface{}) (string, []interface{}, bool) {
+	var condition string
+	var arg interface{}
+
+	switch op {
+	case Equal:
+		condition, arg = "LOWER(%s) = ?", strings.ToLower(value.(string))
+	case NotEqual:
+		condition, arg = "LOWER(%s) <> ?", strings.ToLower(value.(string))
+	case Contains:
+		condition, arg = `LOWER(%s) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(value.(string)))+"%"
+	case In:
+		values := make([]string, 0, len(value.([]interface{})))
+		for _, item := range value.([]interface{}) {
+			values = append(values, strings.ToLower(item.(string)))
+		}
+		condition, arg = "LOWER(%s) IN ?", values
+	default:
+		return "", nil, false
+	}
+
+	return fmt.Sprintf("(TRIM(%s) <> '' AND "+condition+")", c.name, c.name), []interface{}{arg}, true
+}
+
+// escapeLike escapes the wildcards of LIKE in the text
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// OrderSQL returns the order of the rows by the field like SortRows, false if the field is calculated
+// the rows without value are last in both directions and the ties are ordered by ticker
+func OrderSQL(field string, descending bool) (string, bool) {
+	column, ok := columns[field]
+	if !ok {
+		return "", false
+	}
+
+	present := "TRIM(" + column.name + ") <> ''"
+	value := "LOWER(" + column.name + ")"
+	if fields[field] == numberField {
+		present = column.name + " IS NOT NULL"
+		if column.positive {
+			present = column.name + " > 0"
+		}
+		value = column.name
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+
+	return fmt.Sprintf("CASE WHEN %s THEN 0 ELSE 1 END, CASE WHEN %s THEN %s END %s, tickers.id ASC", present, present, value, direction), true
+}
@@ -0,0 +1,97 @@
+package screener
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSQL(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		body      string
+		condition string
+		args      []interface{}
+		exact     bool
+	}{
+		{desc: "empty filter", body: `{}`, exact: true},
+		{
+			desc:      "number",
+			body:      `{"field":"beta","op":"lt","value":1.5}`,
+			condition: "company_snapshots.beta < ?",
+			args:      []interface{}{1.5},
+			exact:     true,
+		},
+		{
+			desc:      "positive number",
+			body:      `{"field":"marketCap","op":"gte","value":1000000000}`,
+			condition: "(company_snapshots.market_cap > 0 AND company_snapshots.market_cap >= ?)",
+			args:      []interface{}{1e9},
+			exact:     true,
+		},
+		{
+			desc:      "text in",
+			body:      `{"field":"sector","op":"in","value":["Technology","Energy"]}`,
+			condition: "(TRIM(company_snapshots.sector) <> '' AND LOWER(company_snapshots.sector) IN ?)",
+			args:      []interface{}{[]string{"technology", "energy"}},
+			exact:     true,
+		},
+		{
+			desc:      "text contains",
+			body:      `{"field":"company","op":"contains","value":"50%_Co"}`,
+			condition: `(TRIM(tickers.company) <> '' AND LOWER(tickers.company) LIKE ? ESCAPE '\')`,
+			args:      []interface{}{`%50\%\_co%`},
+			exact:     true,
+		},
+		{
+			desc:      "and with calculated field",
+			body:      `{"and":[{"field":"price","op":"lt","value":10},{"field":"score","op":"gt","value":0.5}]}`,
+			condition: "((company_snapshots.price > 0 AND company_snapshots.price < ?))",
+			args:      []interface{}{10.0},
+			exact:     false,
+		},
+		{
+			desc:      "or",
+			body:      `{"or":[{"field":"country","op":"eq","value":"US"},{"field":"volume","op":"in","value":[100]}]}`,
+			condition: "((TRIM(company_snapshots.country) <> '' AND LOWER(company_snapshots.country) = ?) OR company_snapshots.volume IN ?)",
+			args:      []interface{}{"us", []interface{}{100.0}},
+			exact:     true,
+		},
+		{
+			desc:  "or with calculated field",
+			body:  `{"or":[{"field":"price","op":"lt","value":10},{"field":"recentActions","op":"contains","value":"upgraded"}]}`,
+			exact: false,
+		},
+		{
+			desc:      "and with or with calculated field",
+			body:      `{"and":[{"field":"ticker","op":"neq","value":"acme"},{"or":[{"field":"price","op":"lt","value":10},{"field":"sentiment","op":"eq","value":"positive"}]}]}`,
+			condition: "((TRIM(tickers.id) <> '' AND LOWER(tickers.id) <> ?))",
+			args:      []interface{}{"acme"},
+			exact:     false,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := parseFilter(t, tC.body)
+			assert.NoError(t, filter.Validate())
+
+			condition, args, exact := filter.SQL()
+			assert.Equal(t, tC.condition, condition)
+			assert.Equal(t, tC.args, args)
+			assert.Equal(t, tC.exact, exact)
+		})
+	}
+}
+
+func TestOrderSQL(t *testing.T) {
+	order, ok := OrderSQL("sector", true)
+	assert.True(t, ok)
+	assert.Equal(t, "CASE WHEN TRIM(company_snapshots.sector) <> '' THEN 0 ELSE 1 END, CASE WHEN TRIM(company_snapshots.sector) <> '' THEN LOWER(company_snapshots.sector) END DESC, tickers.id ASC", order)
+
+	order, ok = OrderSQL("price", false)
+	assert.True(t, ok)
+	assert.Equal(t, "CASE WHEN company_snapshots.price > 0 THEN 0 ELSE 1 END, CASE WHEN company_snapshots.price > 0 THEN company_snapshots.price END ASC, tickers.id ASC", order)
+
+	_, ok = OrderSQL("score", false)
+	assert.False(t, ok)
+}
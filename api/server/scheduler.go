@@ -0,0 +1,202 @@
+package server
+
+import (
+	"api/cache"
+	apilogger "api/logger"
+	"api/services"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// names of the lock rows shared by all the replicas
+const (
+	ratingsSyncLock      = "ratings_sync"
+	predictionsScoreLock = "predictions_score"
+	brokerageStatsLock   = "brokerage_stats"
+	alertsEvaluateLock   = "alerts_evaluate"
+	companySnapshotsLock = "company_snapshots"
+)
+
+// ratingsScheduler refreshes the analyst ratings periodically while the server runs
+type ratingsScheduler struct {
+	interval    time.Duration
+	lockTTL     time.Duration
+	syncService *services.RatingsSyncService
+	lockService *services.LockService
+	cache       cache.ICache
+
+	// running avoids two syncs at the same time in this replica
+	running sync.Mutex
+}
+
+// Run executes a sync at start and then every interval until the context is cancelled
+func (s *ratingsScheduler) Run(ctx context.Context) {
+	apilogger.Logger().Info().Msg(fmt.Sprintf("[ratingsScheduler] started with interval %s", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.runOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			apilogger.Logger().Info().Msg("[ratingsScheduler] stopped")
+			return
+		case <-ticker.C:
+			s.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce runs a sync if no other sync is running in this or other replica
+func (s *ratingsScheduler) runOnce(ctx context.Context) {
+	if !s.running.TryLock() {
+		return
+	}
+	defer s.running.Unlock()
+
+	locked, err := s.lockService.TryLock(ctx, ratingsSyncLock, s.lockTTL)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[ratingsScheduler] failed to take the lock")
+		return
+	}
+
+	if !locked {
+		apilogger.Logger().Debug().Msg("[ratingsScheduler] sync running in other replica, skip")
+		return
+	}
+
+	defer func() {
+		// release with a new context, the sync context can be cancelled
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.lockService.Unlock(releaseCtx, ratingsSyncLock); err != nil {
+			apilogger.Logger().Err(err).Msg("[ratingsScheduler] failed to release the lock")
+		}
+	}()
+
+	syncCtx, cancelSync := context.WithCancel(ctx)
+	defer cancelSync()
+	go s.keepLock(syncCtx, cancelSync)
+
+	result, err := s.syncService.Sync(syncCtx)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[ratingsScheduler] failed to sync ratings")
+		return
+	}
+
+	if result.Inserted > 0 && s.cache != nil {
+		if err := s.cache.Delete(ctx, services.TickersTotalCacheKey); err != nil {
+			apilogger.Logger().Err(err).Msg("[ratingsScheduler] failed to invalidate the tickers total")
+		}
+	}
+
+	apilogger.Logger().Info().Msg(fmt.Sprintf("[ratingsScheduler] ratings synced: inserted %d, skipped %d", result.Inserted, result.Skipped))
+}
+
+// keepLock extends the lease while the sync runs
+// if the lease is lost the sync is cancelled to avoid two replicas writing at the same time
+func (s *ratingsScheduler) keepLock(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(s.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			locked, err := s.lockService.TryLock(ctx, ratingsSyncLock, s.lockTTL)
+			if err != nil || !locked {
+				apilogger.Logger().Warn().Msg("[ratingsScheduler] lost the lock, cancel sync")
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// periodicJob runs a job periodically while the server runs, only one replica runs it at the same time
+// run returns a summary that is logged when the job succeeds
+type periodicJob struct {
+	name        string
+	lock        string
+	interval    time.Duration
+	lockTTL     time.Duration
+	lockService *services.LockService
+	run         func(ctx context.Context) (string, error)
+}
+
+// Run executes the job at start and then every interval until the context is cancelled
+func (j *periodicJob) Run(ctx context.Context) {
+	apilogger.Logger().Info().Msg(fmt.Sprintf("[%s] started with interval %s", j.name, j.interval))
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.runOnce(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			apilogger.Logger().Info().Msg(fmt.Sprintf("[%s] stopped", j.name))
+			return
+		case <-ticker.C:
+			j.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce runs the job if no other replica is running it
+// the lease is extended while the job runs and the job is cancelled if the lease is lost
+func (j *periodicJob) runOnce(ctx context.Context) {
+	locked, err := j.lockService.TryLock(ctx, j.lock, j.lockTTL)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg(fmt.Sprintf("[%s] failed to take the lock", j.name))
+		return
+	}
+
+	if !locked {
+		return
+	}
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := j.lockService.Unlock(releaseCtx, j.lock); err != nil {
+			apilogger.Logger().Err(err).Msg(fmt.Sprintf("[%s] failed to release the lock", j.name))
+		}
+	}()
+
+	runCtx, cancelRun := context.WithCancel(ctx)
+	defer cancelRun()
+	go j.keepLock(runCtx, cancelRun)
+
+	summary, err := j.run(runCtx)
+	if err != nil {
+		apilogger.Logger().Err(err).Msg(fmt.Sprintf("[%s] failed", j.name))
+		return
+	}
+
+	apilogger.Logger().Info().Msg(fmt.Sprintf("[%s] %s", j.name, summary))
+}
+
+// keepLock extends the lease while the job runs, like the lease of the ratings sync
+func (j *periodicJob) keepLock(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(j.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			locked, err := j.lockService.TryLock(ctx, j.lock, j.lockTTL)
+			if err != nil || !locked {
+				apilogger.Logger().Warn().Msg(fmt.Sprintf("[%s] lost the lock, cancel run", j.name))
+				cancel()
+				return
+			}
+		}
+	}
+}
@@ -1,13 +1,26 @@
 package server
 
 import (
+	"api/alerts"
+	"api/cache"
 	"api/config"
+	apilogger "api/logger"
+	"api/metrics"
+	"api/middlewares"
 	"api/models"
 	"api/routes"
+	"api/services"
+	"api/stream"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -18,6 +31,18 @@ import (
 type Server struct {
 	Router *chi.Mux
 	Config *models.ServerConfig
+
+	httpServer *http.Server
+
+	// registry is the failover of the market data providers shared by all the services
+	registry *services.ProviderRegistry
+
+	// hub delivers the live updates of the tickers, nil if the cache has no pub/sub
+	hub *stream.Hub
+
+	// jobs tracks the background jobs started with the server
+	jobs       sync.WaitGroup
+	cancelJobs context.CancelFunc
 }
 
 func NewServer(config models.ServerConfig) *Server {
@@ -32,16 +57,202 @@ func NewServer(config models.ServerConfig) *Server {
 	}
 
 	return &Server{
-		Router: chi.NewRouter(),
-		Config: &config,
+		Router:   chi.NewRouter(),
+		Config:   &config,
+		registry: newProviderRegistry(config),
 	}
 }
 
+// newProviderRegistry creates the registry of the providers of the configuration shared by the services of the server
+func newProviderRegistry(serverConfig models.ServerConfig) *services.ProviderRegistry {
+	return services.NewProviderRegistry(serverConfig.DB, serverConfig.Cache, config.Providers())
+}
+
+// Start runs the server until it receives SIGINT or SIGTERM
+// then drains the in-flight requests and closes the database and the cache
 func (s *Server) Start() error {
 	s.Setup()
 
-	log.Println("Server started on port:", s.Config.Port)
-	return http.ListenAndServe(":"+s.Config.Port, s.Router)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	s.cancelJobs = cancelJobs
+	s.startBackgroundJobs(jobsCtx)
+
+	s.httpServer = &http.Server{
+		Addr:         ":" + s.Config.Port,
+		Handler:      s.Router,
+		ReadTimeout:  config.Server().ReadTimeout,
+		WriteTimeout: config.Server().WriteTimeout,
+		IdleTimeout:  config.Server().IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Println("Server started on port:", s.Config.Port)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var err error
+	select {
+	case err = <-serverErr:
+		apilogger.Logger().Err(err).Msg("Server stopped unexpectedly")
+	case <-ctx.Done():
+		log.Println("Shutting down server")
+		apilogger.Logger().Info().Msg("Shutting down server")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Server().ShutdownTimeout)
+	defer cancel()
+
+	if shutdownErr := s.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+		err = shutdownErr
+	}
+
+	return err
+}
+
+// Shutdown stops the server gracefully in order:
+// drains the in-flight requests until the context deadline, stops the background jobs,
+// closes the database and finally the cache
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	// the streams are closed first, otherwise they keep the drain waiting until the deadline
+	if s.hub != nil {
+		if err := s.hub.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close stream: %w", err))
+		}
+	}
+
+	if s.httpServer != nil {
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to drain requests: %w", err))
+		}
+	}
+
+	if s.cancelJobs != nil {
+		s.cancelJobs()
+	}
+	s.jobs.Wait()
+
+	if db, err := s.Config.DB.DB(); err == nil {
+		if err := db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database: %w", err))
+		}
+	}
+
+	if err := s.Config.Cache.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close cache: %w", err))
+	}
+
+	log.Println("Server stopped")
+	return errors.Join(errs...)
+}
+
+// startBackgroundJobs starts the optional jobs enabled in the configuration
+func (s *Server) startBackgroundJobs(ctx context.Context) {
+	tickerService := services.NewTickerService(s.Config.DB, s.Config.Cache, s.registry)
+
+	if interval := config.Sync().RatingsInterval; interval > 0 {
+		analystRatingsService := services.NewAnalystRatingsService(s.Config.DB)
+
+		syncService := services.NewRatingsSyncService(s.Config.DB, &analystRatingsService, tickerService)
+		if pubsub, ok := s.Config.Cache.(cache.IPubSub); ok {
+			syncService.WithPubSub(pubsub)
+		}
+
+		scheduler := &ratingsScheduler{
+			interval:    interval,
+			lockTTL:     config.Sync().LockTTL,
+			syncService: syncService,
+			lockService: services.NewLockService(s.Config.DB),
+			cache:       s.Config.Cache,
+		}
+
+		s.jobs.Add(1)
+		go func() {
+			defer s.jobs.Done()
+			scheduler.Run(ctx)
+		}()
+	}
+
+	if interval := config.Sync().PredictionsScoreInterval; interval > 0 {
+		predictionService := services.NewPredictionService(s.Config.DB, tickerService)
+
+		s.startPeriodicJob(ctx, &periodicJob{
+			name:        "predictionsScheduler",
+			lock:        predictionsScoreLock,
+			interval:    interval,
+			lockTTL:     config.Sync().LockTTL,
+			lockService: services.NewLockService(s.Config.DB),
+			run: func(ctx context.Context) (string, error) {
+				result, err := predictionService.ScorePredictions(ctx)
+				return fmt.Sprintf("predictions scored: %d of %d pending", result.Scored, result.Points), err
+			},
+		})
+	}
+
+	if interval := config.Sync().BrokerageStatsInterval; interval > 0 {
+		brokerageService := services.NewBrokerageService(s.Config.DB, tickerService)
+
+		s.startPeriodicJob(ctx, &periodicJob{
+			name:        "brokerageStatsScheduler",
+			lock:        brokerageStatsLock,
+			interval:    interval,
+			lockTTL:     config.Sync().LockTTL,
+			lockService: services.NewLockService(s.Config.DB),
+			run: func(ctx context.Context) (string, error) {
+				result, err := brokerageService.RefreshStats(ctx)
+				return fmt.Sprintf("brokerages updated: %d, tickers without prices %d of %d", result.Brokerages, result.FailedTickers, result.Tickers), err
+			},
+		})
+	}
+
+	if interval := config.Alerts().EvaluateInterval; interval > 0 {
+		alertService := services.NewAlertService(s.Config.DB, tickerService, alerts.DefaultSinks())
+
+		s.startPeriodicJob(ctx, &periodicJob{
+			name:        "alertsScheduler",
+			lock:        alertsEvaluateLock,
+			interval:    interval,
+			lockTTL:     config.Sync().LockTTL,
+			lockService: services.NewLockService(s.Config.DB),
+			run: func(ctx context.Context) (string, error) {
+				result, err := alertService.Evaluate(ctx)
+				return fmt.Sprintf("alerts evaluated: %d, fired %d, failed deliveries %d", result.Rules, result.Fired, result.Failed), err
+			},
+		})
+	}
+
+	if interval := config.Sync().SnapshotsInterval; interval > 0 {
+		screenerService := services.NewScreenerService(s.Config.DB, tickerService)
+
+		s.startPeriodicJob(ctx, &periodicJob{
+			name:        "snapshotsScheduler",
+			lock:        companySnapshotsLock,
+			interval:    interval,
+			lockTTL:     config.Sync().LockTTL,
+			lockService: services.NewLockService(s.Config.DB),
+			run: func(ctx context.Context) (string, error) {
+				result, err := screenerService.RefreshSnapshots(ctx)
+				return fmt.Sprintf("company snapshots updated: %d, tickers without company data %d", result.Tickers-result.FailedTickers, result.FailedTickers), err
+			},
+		})
+	}
+}
+
+// startPeriodicJob runs the job in background until the jobs are stopped
+func (s *Server) startPeriodicJob(ctx context.Context, job *periodicJob) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		job.Run(ctx)
+	}()
 }
 
 func (s *Server) Setup() *Server {
@@ -52,9 +263,13 @@ func (s *Server) Setup() *Server {
 
 // setupMiddleware configures the middleware
 func (s *Server) setupMiddleware() *Server {
+	// Credential of the stream and the websocket in the query, first so the logger does not print it
+	s.Router.Use(middlewares.QueryToken("/api/v1/stream", "/api/v1/ws"))
+
 	// Basic middleware
 	s.Router.Use(middleware.RequestID)
 	s.Router.Use(middleware.RealIP)
+	s.Router.Use(middlewares.Metrics)
 	s.Router.Use(middleware.Recoverer)
 	s.Router.Use(middleware.Compress(5))
 	s.Router.Use(middleware.CleanPath)
@@ -65,30 +280,102 @@ func (s *Server) setupMiddleware() *Server {
 		s.Router.Use(middleware.Logger)
 	}
 
-	// Timeout
-	s.Router.Use(middleware.Timeout(60 * time.Second))
+	// Timeout, the stream and the websocket are long lived connections
+	s.Router.Use(middlewares.Timeout(60*time.Second, "/api/v1/stream", "/api/v1/ws"))
 
 	// CORS configuration
 	s.Router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{config.Server().ClientHost},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Client-ID", "X-API-Key"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
+	// Authentication with api keys or JWT, the root, health check and metrics are public
+	apiKeyService := services.NewApiKeyService(s.Config.DB)
+	s.checkAuthCredentials(&apiKeyService)
+	s.Router.Use(middlewares.Authenticate(&apiKeyService, middlewares.AuthOptions{
+		Required:    config.Auth().Enabled,
+		JWTSecret:   []byte(config.Auth().JWTSecret),
+		PublicPaths: []string{"/", "/health", "/metrics"},
+	}))
+
+	// Rate limit per client, shared by all the replicas in redis
+	s.setupRateLimit()
+
 	return s
 }
 
+// checkAuthCredentials stops the server when the auth is enabled and no credential can be valid,
+// without JWT secret and without api keys every request would be rejected
+func (s *Server) checkAuthCredentials(apiKeyService *services.ApiKeyService) {
+	if !config.Auth().Enabled || config.Auth().JWTSecret != "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hasKeys, err := apiKeyService.HasActiveKeys(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !hasKeys {
+		log.Fatal("the auth is enabled without JWT_SECRET and without api keys, set JWT_SECRET, create a key with the command api-keys create or set AUTH_ENABLED=false")
+	}
+}
+
+// setupRateLimit configures the rate limit of the api routes
+// the routes that call gemini or request many external prices have their own limit
+func (s *Server) setupRateLimit() {
+	limiter, ok := s.Config.Cache.(cache.IRateLimiter)
+	if !config.RateLimit().Enabled || !ok {
+		return
+	}
+
+	defaultLimit, err := middlewares.ParseRateLimit(config.RateLimit().Default)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	aiLimit, err := middlewares.ParseRateLimit(config.RateLimit().AI)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s.Router.Use(middlewares.RateLimit(limiter, middlewares.RateLimitOptions{
+		Groups: []middlewares.RateLimitGroup{
+			{
+				Name: "ai",
+				Patterns: []string{
+					"/api/v1/tickers",
+					"/api/v1/tickers/*/overview",
+					"/api/v1/tickers/*/predictions",
+					"/api/v1/watchlists/*/tickers",
+					"/api/v1/backtests",
+					"/api/v1/ws",
+				},
+				Limit: aiLimit,
+			},
+		},
+		Default:       defaultLimit,
+		ExcludedPaths: []string{"/", "/health", "/metrics"},
+	}))
+}
+
 // setupRoutes configures the routes
 func (s *Server) setupRoutes() *Server {
 	// Global routes
 	s.Router.Get("/", s.handleRoot)
 	s.Router.Get("/health", s.handleHealth)
+	s.Router.Method(http.MethodGet, "/metrics", metrics.Handler())
 
 	// API routes
-	routes.SetupRoutes(s.Router, s.Config)
+	s.hub = s.newHub()
+	routes.SetupRoutes(s.Router, s.Config, s.registry, s.hub)
 
 	// 404 handler
 	s.Router.NotFound(s.handleNotFound)
@@ -96,6 +383,21 @@ func (s *Server) setupRoutes() *Server {
 	return s
 }
 
+// newHub creates the hub of the live updates, it needs the pub/sub of the cache to work with many replicas
+// the prices are polled with the live providers of the registry, the lock of the hub polls each ticker once for all the replicas
+func (s *Server) newHub() *stream.Hub {
+	pubsub, ok := s.Config.Cache.(cache.IPubSub)
+	if !ok {
+		return nil
+	}
+
+	locker, _ := s.Config.Cache.(cache.ILocker)
+	return stream.NewHub(pubsub, locker, s.registry.LiveCompanyDataService(), stream.HubOptions{
+		PollInterval: config.Stream().PollInterval,
+		BufferSize:   config.Stream().BufferSize,
+	})
+}
+
 // Handler functions
 func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{
@@ -123,6 +425,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the failover keeps serving with a degraded provider, its health is in /api/v1/providers/health
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
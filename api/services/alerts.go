@@ -0,0 +1,459 @@
+package services
+
+import (
+	"api/alerts"
+	"api/database/scopes"
+	apilogger "api/logger"
+	"api/models"
+	"api/models/filters"
+	"api/models/ratings"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAlert is returned when the condition or the channel of an alert are not valid
+var ErrInvalidAlert = errors.New("invalid alert")
+
+// AlertUpdate fields of an alert that can be updated, the nil fields are not changed
+type AlertUpdate struct {
+	Threshold *float64             `json:"threshold"`
+	Sentiment *ratings.Sentiment   `json:"sentiment"`
+	Action    *models.Action       `json:"action"`
+	Channel   *models.AlertChannel `json:"channel"`
+	Target    *string              `json:"target"`
+	Enabled   *bool                `json:"enabled"`
+}
+
+// AlertEvaluationResult summary of an evaluation of the alerts
+type AlertEvaluationResult struct {
+	Rules  int
+	Fired  int
+	Failed int
+}
+
+// AlertService handles the alerts of the users and evaluates them
+// all the operations of the rules are scoped to the owner
+type AlertService struct {
+	db          *gorm.DB
+	companyData CompanyDataService
+	sinks       map[models.AlertChannel]alerts.Sink
+}
+
+// NewAlertService creates a new AlertService
+// companyData is used to retrieve the price of the tickers and sinks deliver the notifications of each channel
+func NewAlertService(db *gorm.DB, companyData CompanyDataService, sinks map[models.AlertChannel]alerts.Sink) AlertService {
+	return AlertService{
+		db:          db,
+		companyData: companyData,
+		sinks:       sinks,
+	}
+}
+
+// GetAlerts retrieves the alerts of the owner
+func (s *AlertService) GetAlerts(ctx context.Context, owner string) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	err := s.db.WithContext(ctx).
+		Where("owner = ?", owner).
+		Order("id asc").
+		Find(&rules).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to retrieve alerts: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetAlert retrieves an alert of the owner
+func (s *AlertService) GetAlert(ctx context.Context, owner string, id uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND owner = ?", id, owner).
+		First(&rule).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to retrieve alert by id: %d: %w", id, err)
+	}
+
+	return &rule, nil
+}
+
+// CreateAlert creates an enabled alert for the owner, the ticker must exist
+// the alerts only fire for the conditions met and the recommendations inserted after the creation,
+// the secret that signs the webhooks of the alert is generated and only returned here
+func (s *AlertService) CreateAlert(ctx context.Context, owner string, rule models.AlertRule) (*models.AlertRule, error) {
+	rule, err := s.validateRule(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var tickers int64
+	if err := s.db.WithContext(ctx).Model(&models.Ticker{}).Where("id = ?", rule.TickerID).Count(&tickers).Error; err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to validate ticker: %w", err)
+	}
+
+	if tickers == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTickers, rule.TickerID)
+	}
+
+	if rule.Type == models.RecommendationAlert {
+		var lastID *uint
+		err := s.db.WithContext(ctx).Model(&models.Recommendation{}).
+			Where("ticker_id = ?", rule.TickerID).
+			Select("MAX(id)").
+			Scan(&lastID).Error
+		if err != nil {
+			return nil, fmt.Errorf("[AlertService] failed to retrieve the last recommendation: %w", err)
+		}
+
+		if lastID != nil {
+			rule.LastRecommendationID = *lastID
+		}
+	}
+
+	secret, err := alerts.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to generate the secret of the alert: %w", err)
+	}
+
+	rule.ID = 0
+	rule.Secret = secret
+	rule.Owner = owner
+	rule.Enabled = true
+	rule.LastEvaluatedAt = nil
+	rule.LastFiredAt = nil
+
+	if err := s.seedState(ctx, &rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to create alert: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// UpdateAlert updates the fields of an alert of the owner
+// changing the condition seeds the state again with the current data of the ticker
+func (s *AlertService) UpdateAlert(ctx context.Context, owner string, id uint, update AlertUpdate) (*models.AlertRule, error) {
+	rule, err := s.GetAlert(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *rule
+	if update.Threshold != nil {
+		updated.Threshold = *update.Threshold
+	}
+	if update.Sentiment != nil {
+		updated.Sentiment = *update.Sentiment
+	}
+	if update.Action != nil {
+		updated.Action = *update.Action
+	}
+	if update.Channel != nil {
+		updated.Channel = *update.Channel
+	}
+	if update.Target != nil {
+		updated.Target = *update.Target
+	}
+	if update.Enabled != nil {
+		updated.Enabled = *update.Enabled
+	}
+
+	updated, err = s.validateRule(ctx, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Threshold != rule.Threshold || updated.Sentiment != rule.Sentiment {
+		if err := s.seedState(ctx, &updated); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.db.WithContext(ctx).Model(&updated).
+		Select("threshold", "sentiment", "action", "channel", "target", "enabled", "triggered").
+		Updates(&updated).Error
+	if err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to update alert: %w", err)
+	}
+
+	return s.GetAlert(ctx, owner, id)
+}
+
+// DeleteAlert deletes an alert of the owner and its history
+func (s *AlertService) DeleteAlert(ctx context.Context, owner string, id uint) error {
+	if _, err := s.GetAlert(ctx, owner, id); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("alert_rule_id = ?", id).Delete(&models.AlertEvent{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND owner = ?", id, owner).Delete(&models.AlertRule{}).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("[AlertService] failed to delete alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlertEvents retrieves a paginated history of the firings of an alert of the owner, the newest first
+func (s *AlertService) GetAlertEvents(ctx context.Context, owner string, id uint, filter filters.Filters) ([]models.AlertEvent, int64, error) {
+	if _, err := s.GetAlert(ctx, owner, id); err != nil {
+		return nil, 0, err
+	}
+
+	filter.Normalize()
+
+	query := s.db.WithContext(ctx).Model(&models.AlertEvent{}).Where("alert_rule_id = ?", id)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("[AlertService] failed to count alert events: %w", err)
+	}
+
+	var events []models.AlertEvent
+	err := query.
+		Order("fired_at desc").Order("id desc").
+		Scopes(scopes.Pagination(filter.Page, filter.PageSize)).
+		Find(&events).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("[AlertService] failed to retrieve alert events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// Evaluate evaluates the enabled alerts with the current price, sentiment and recommendations of their tickers,
+// records the firings in the history with the state of the alerts and then delivers their notifications
+// the failed deliveries are recorded with the error and are not retried
+func (s *AlertService) Evaluate(ctx context.Context) (AlertEvaluationResult, error) {
+	var result AlertEvaluationResult
+
+	var rules []models.AlertRule
+	if err := s.db.WithContext(ctx).Where("enabled = ?", true).Order("id asc").Find(&rules).Error; err != nil {
+		return result, fmt.Errorf("[AlertService] failed to retrieve alerts: %w", err)
+	}
+	result.Rules = len(rules)
+
+	rulesByTicker := make(map[string][]int)
+	for i := range rules {
+		rulesByTicker[rules[i].TickerID] = append(rulesByTicker[rules[i].TickerID], i)
+	}
+
+	snapshots := make(map[string]*alerts.Snapshot, len(rulesByTicker))
+	for ticker := range rulesByTicker {
+		snapshots[ticker] = &alerts.Snapshot{}
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	for ticker, indexes := range rulesByTicker {
+		snapshot := snapshots[ticker]
+		group.Go(func() error {
+			return s.loadSnapshot(groupCtx, ticker, rules, indexes, snapshot)
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+		events := alerts.Evaluate(rule, *snapshots[rule.TickerID])
+
+		rule.LastEvaluatedAt = &now
+		if len(events) > 0 {
+			rule.LastFiredAt = &now
+		}
+
+		records, err := s.saveEvaluation(ctx, rule, events, now)
+		if err != nil {
+			return result, err
+		}
+
+		for j := range records {
+			delivered := s.notify(ctx, rule, events[j], &records[j])
+			result.Fired++
+			if !delivered {
+				result.Failed++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// seedState evaluates the condition of the rule with the current data of its ticker without notifying,
+// so a condition that already holds does not fire on the first evaluation
+// the state is not met when the data could not be retrieved
+func (s *AlertService) seedState(ctx context.Context, rule *models.AlertRule) error {
+	rule.Triggered = false
+	if rule.Type == models.RecommendationAlert {
+		return nil
+	}
+
+	var snapshot alerts.Snapshot
+	if err := s.loadSnapshot(ctx, rule.TickerID, []models.AlertRule{*rule}, []int{0}, &snapshot); err != nil {
+		return err
+	}
+
+	alerts.Evaluate(rule, snapshot)
+	return nil
+}
+
+// loadSnapshot retrieves the data of the ticker needed by its rules
+// the failures of the company data are logged and the price rules of the ticker keep their state
+func (s *AlertService) loadSnapshot(ctx context.Context, ticker string, rules []models.AlertRule, indexes []int, snapshot *alerts.Snapshot) error {
+	var needsQuote, needsRecommendations bool
+	for _, i := range indexes {
+		switch rules[i].Type {
+		case models.PriceAboveAlert, models.PriceBelowAlert, models.ChangePercentAlert:
+			needsQuote = true
+		case models.SentimentAlert, models.RecommendationAlert:
+			needsRecommendations = true
+		}
+	}
+
+	if needsQuote {
+		company, err := s.companyData.GetCompanyData(ctx, ticker)
+		if err != nil {
+			apilogger.Logger().Warn().Err(err).Msg("[AlertService] failed to retrieve company data of " + ticker)
+		} else {
+			snapshot.Company = &company
+		}
+	}
+
+	if needsRecommendations {
+		var recommendations []models.Recommendation
+		err := s.db.WithContext(ctx).
+			Preload("Brokerage").
+			Where("ticker_id = ?", ticker).
+			Order("id asc").
+			Find(&recommendations).Error
+		if err != nil {
+			return fmt.Errorf("[AlertService] failed to retrieve recommendations of %s: %w", ticker, err)
+		}
+
+		snapshot.Recommendations = recommendations
+		if len(recommendations) > 0 {
+			snapshot.Sentiment = createRatingCollection(recommendations).CalculateSentiment().Sentiment
+		}
+	}
+
+	return nil
+}
+
+// saveEvaluation saves the state of the rule and records its events as not delivered in the same transaction,
+// so a failure or a lost lease after the delivery can not notify the same firing twice
+func (s *AlertService) saveEvaluation(ctx context.Context, rule *models.AlertRule, events []alerts.Event, firedAt time.Time) ([]models.AlertEvent, error) {
+	records := make([]models.AlertEvent, 0, len(events))
+	for _, event := range events {
+		records = append(records, models.AlertEvent{
+			AlertRuleID: rule.ID,
+			TickerID:    rule.TickerID,
+			Type:        rule.Type,
+			Message:     truncate(event.Message, 500),
+			Value:       event.Value,
+			Channel:     rule.Channel,
+			FiredAt:     firedAt,
+		})
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(rule).
+			Select("triggered", "last_recommendation_id", "last_evaluated_at", "last_fired_at").
+			Updates(rule).Error
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		return tx.Create(&records).Error
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("[AlertService] failed to save the state of alert %d: %w", rule.ID, err)
+	}
+
+	return records, nil
+}
+
+// notify delivers the event through the sink of the channel of the rule and saves the result in its record
+func (s *AlertService) notify(ctx context.Context, rule *models.AlertRule, event alerts.Event, record *models.AlertEvent) bool {
+	notification := alerts.Notification{
+		RuleID:  rule.ID,
+		Ticker:  rule.TickerID,
+		Type:    rule.Type,
+		Message: event.Message,
+		Value:   event.Value,
+		FiredAt: record.FiredAt,
+	}
+
+	sink, ok := s.sinks[rule.Channel]
+	if !ok {
+		record.Error = fmt.Sprintf("the channel %s is not configured", rule.Channel)
+	} else if err := sink.Send(ctx, alerts.Destination{Target: rule.Target, Secret: rule.Secret}, notification); err != nil {
+		apilogger.Logger().Warn().Err(err).Msg(fmt.Sprintf("[AlertService] failed to deliver alert %d", rule.ID))
+		record.Error = truncate(err.Error(), 500)
+	} else {
+		record.Delivered = true
+	}
+
+	if err := s.db.WithContext(ctx).Model(record).Select("delivered", "error").Updates(record).Error; err != nil {
+		apilogger.Logger().Err(err).Msg(fmt.Sprintf("[AlertService] failed to record the delivery of alert %d", rule.ID))
+	}
+
+	return record.Delivered
+}
+
+// validateRule validates the rule and that the sink of its channel is configured,
+// the host of the webhooks is resolved to reject the internal addresses
+func (s *AlertService) validateRule(ctx context.Context, rule models.AlertRule) (models.AlertRule, error) {
+	rule, err := alerts.NormalizeRule(rule)
+	if err != nil {
+		return rule, fmt.Errorf("%w: %s", ErrInvalidAlert, err.Error())
+	}
+
+	if rule.Channel == models.WebhookChannel {
+		target, _ := url.Parse(rule.Target)
+		if err := alerts.CheckWebhookTarget(ctx, target.Hostname()); err != nil {
+			return rule, fmt.Errorf("%w: %s", ErrInvalidAlert, err.Error())
+		}
+	}
+
+	if _, ok := s.sinks[rule.Channel]; !ok {
+		return rule, fmt.Errorf("%w: the channel %s is not configured", ErrInvalidAlert, rule.Channel)
+	}
+
+	return rule, nil
+}
+
+// truncate limits the text to the bytes of its column without splitting a character
+func truncate(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+
+	for size > 0 && !utf8.RuneStart(text[size]) {
+		size--
+	}
+
+	return text[:size]
+}
@@ -0,0 +1,225 @@
+package services_test
+
+import (
+	"api/alerts"
+	"api/models"
+	"api/models/filters"
+	"api/services"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCompanyData returns the price of the tickers
+type fakeCompanyData struct {
+	mu     sync.Mutex
+	prices map[string]float64
+}
+
+func (f *fakeCompanyData) GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return models.CompanyData{Symbol: ticker, Price: f.prices[ticker]}, nil
+}
+
+func (f *fakeCompanyData) setPrice(ticker string, price float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prices[ticker] = price
+}
+
+// delivery notification received by the fake sink
+type delivery struct {
+	destination  alerts.Destination
+	notification alerts.Notification
+}
+
+// fakeSink records the notifications, the deliveries fail with err and call onSend before
+type fakeSink struct {
+	mu         sync.Mutex
+	deliveries []delivery
+	err        error
+	onSend     func(notification alerts.Notification)
+}
+
+func (f *fakeSink) Send(ctx context.Context, destination alerts.Destination, notification alerts.Notification) error {
+	if f.onSend != nil {
+		f.onSend(notification)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deliveries = append(f.deliveries, delivery{destination: destination, notification: notification})
+	return f.err
+}
+
+func (f *fakeSink) Deliveries() []delivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]delivery(nil), f.deliveries...)
+}
+
+// newTestAlertService creates the alert service with the ticker AAPL at price and a webhook sink
+func newTestAlertService(t *testing.T, price float64) (services.AlertService, *fakeCompanyData, *fakeSink) {
+	t.Helper()
+
+	db := newTestDB(t, &models.Ticker{}, &models.Brokerage{}, &models.Recommendation{}, &models.AlertRule{}, &models.AlertEvent{})
+	assert.NoError(t, db.Create(&models.Ticker{ID: "AAPL", Company: "Apple Inc."}).Error)
+
+	companyData := &fakeCompanyData{prices: map[string]float64{"AAPL": price}}
+	sink := &fakeSink{}
+	service := services.NewAlertService(db, companyData, map[models.AlertChannel]alerts.Sink{models.WebhookChannel: sink})
+
+	return service, companyData, sink
+}
+
+// priceAbove is a webhook rule of AAPL over the threshold
+func priceAbove(threshold float64) models.AlertRule {
+	return models.AlertRule{
+		TickerID:  "AAPL",
+		Type:      models.PriceAboveAlert,
+		Threshold: threshold,
+		Channel:   models.WebhookChannel,
+		Target:    "https://93.184.216.34/hook",
+	}
+}
+
+func TestAlertServiceSecretPerRule(t *testing.T) {
+	service, companyData, sink := newTestAlertService(t, 90)
+	ctx := context.Background()
+
+	first, err := service.CreateAlert(ctx, "first", priceAbove(100))
+	assert.NoError(t, err)
+	second, err := service.CreateAlert(ctx, "second", priceAbove(100))
+	assert.NoError(t, err)
+
+	assert.Len(t, first.Secret, 64)
+	assert.NotEqual(t, first.Secret, second.Secret)
+
+	_, err = service.Evaluate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, sink.Deliveries())
+
+	companyData.setPrice("AAPL", 150)
+	result, err := service.Evaluate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Fired)
+
+	deliveries := sink.Deliveries()
+	assert.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		rule := first
+		if delivery.notification.RuleID == second.ID {
+			rule = second
+		}
+		assert.Equal(t, alerts.Destination{Target: rule.Target, Secret: rule.Secret}, delivery.destination)
+	}
+}
+
+func TestAlertServiceSeedsState(t *testing.T) {
+	t.Run("does not fire the condition met on creation", func(t *testing.T) {
+		service, _, sink := newTestAlertService(t, 150)
+		ctx := context.Background()
+
+		rule, err := service.CreateAlert(ctx, "trader", priceAbove(100))
+		assert.NoError(t, err)
+		assert.True(t, rule.Triggered)
+
+		result, err := service.Evaluate(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Fired)
+		assert.Empty(t, sink.Deliveries())
+	})
+
+	t.Run("does not fire the condition met on update", func(t *testing.T) {
+		service, companyData, sink := newTestAlertService(t, 150)
+		ctx := context.Background()
+
+		rule, err := service.CreateAlert(ctx, "trader", priceAbove(200))
+		assert.NoError(t, err)
+		assert.False(t, rule.Triggered)
+
+		threshold := 100.0
+		rule, err = service.UpdateAlert(ctx, "trader", rule.ID, services.AlertUpdate{Threshold: &threshold})
+		assert.NoError(t, err)
+		assert.True(t, rule.Triggered)
+
+		result, err := service.Evaluate(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Fired)
+
+		// the alert fires when the price crosses the threshold again
+		companyData.setPrice("AAPL", 90)
+		_, err = service.Evaluate(ctx)
+		assert.NoError(t, err)
+		companyData.setPrice("AAPL", 110)
+		result, err = service.Evaluate(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Fired)
+		assert.Len(t, sink.Deliveries(), 1)
+	})
+}
+
+func TestAlertServiceSavesStateBeforeDelivery(t *testing.T) {
+	service, companyData, sink := newTestAlertService(t, 90)
+	ctx := context.Background()
+
+	rule, err := service.CreateAlert(ctx, "trader", priceAbove(100))
+	assert.NoError(t, err)
+
+	sink.err = errors.New("connection refused")
+	sink.onSend = func(notification alerts.Notification) {
+		saved, err := service.GetAlert(ctx, "trader", notification.RuleID)
+		assert.NoError(t, err)
+		assert.True(t, saved.Triggered)
+		assert.NotNil(t, saved.LastFiredAt)
+
+		events, total, err := service.GetAlertEvents(ctx, "trader", notification.RuleID, filters.Filters{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.False(t, events[0].Delivered)
+	}
+
+	companyData.setPrice("AAPL", 150)
+	result, err := service.Evaluate(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Fired)
+	assert.Equal(t, 1, result.Failed)
+	assert.Len(t, sink.Deliveries(), 1)
+
+	events, _, err := service.GetAlertEvents(ctx, "trader", rule.ID, filters.Filters{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.False(t, events[0].Delivered)
+	assert.Equal(t, "connection refused", events[0].Error)
+}
+
+func TestAlertServiceTruncatesErrorsByCharacter(t *testing.T) {
+	service, companyData, sink := newTestAlertService(t, 90)
+	ctx := context.Background()
+
+	rule, err := service.CreateAlert(ctx, "trader", priceAbove(100))
+	assert.NoError(t, err)
+
+	// 2 bytes per character, the 500 bytes of the column split the last one without backing off
+	sink.err = errors.New("x" + strings.Repeat("é", 300))
+
+	companyData.setPrice("AAPL", 150)
+	_, err = service.Evaluate(ctx)
+	assert.NoError(t, err)
+
+	events, _, err := service.GetAlertEvents(ctx, "trader", rule.ID, filters.Filters{})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.True(t, utf8.ValidString(events[0].Error))
+	assert.Len(t, events[0].Error, 499)
+}
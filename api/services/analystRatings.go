@@ -5,6 +5,7 @@ import (
 	apilogger "api/logger"
 	"api/models"
 	CustomClient "api/services/customClient"
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -20,8 +21,8 @@ type AnalystRatingResponse struct {
 
 // AnalystRatingsServiceInterface interface for analyst ratings service
 type AnalystRatingsServiceInterface interface {
-	GetAll() ([]models.StockRecommendation, error)
-	GetWithNext(nextPage string) (AnalystRatingResponse, error)
+	GetAll(ctx context.Context) ([]models.StockRecommendation, error)
+	GetWithNext(ctx context.Context, nextPage string) (AnalystRatingResponse, error)
 }
 
 // AnalystRatingsService struct for analyst ratings service
@@ -36,7 +37,7 @@ func NewAnalystRatingsService(db *gorm.DB) AnalystRatingsService {
 
 // GetAll returns all recommendations
 // iterate all items until next_page is empty
-func (s *AnalystRatingsService) GetAll() ([]models.StockRecommendation, error) {
+func (s *AnalystRatingsService) GetAll(ctx context.Context) ([]models.StockRecommendation, error) {
 	var recommendations []models.StockRecommendation
 	var recommendation AnalystRatingResponse
 
@@ -45,7 +46,7 @@ func (s *AnalystRatingsService) GetAll() ([]models.StockRecommendation, error) {
 
 	i := 1
 	for {
-		if err := client.Get("/list", map[string]string{"next_page": recommendation.Next}, &recommendation); err != nil {
+		if err := client.Get(ctx, "/list", map[string]string{"next_page": recommendation.Next}, &recommendation); err != nil {
 			return nil, err
 		}
 
@@ -63,13 +64,13 @@ func (s *AnalystRatingsService) GetAll() ([]models.StockRecommendation, error) {
 
 // GetWithNext returns the recommendations for the next page
 // nextPage is the next page to fetch if its empty it will fetch the first page
-func (s *AnalystRatingsService) GetWithNext(nextPage string) (AnalystRatingResponse, error) {
+func (s *AnalystRatingsService) GetWithNext(ctx context.Context, nextPage string) (AnalystRatingResponse, error) {
 	var recommendation AnalystRatingResponse
 
 	client := CustomClient.NewCustomClient(config.StockApi().Url)
 	client.SetAuthToken(config.StockApi().Token)
 
-	if err := client.Get("/list", map[string]string{"next_page": nextPage}, &recommendation); err != nil {
+	if err := client.Get(ctx, "/list", map[string]string{"next_page": nextPage}, &recommendation); err != nil {
 		return recommendation, err
 	}
 
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"api/auth"
+	apilogger "api/logger"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidApiKey is returned when the key does not exist or was revoked
+var ErrInvalidApiKey = auth.ErrInvalidApiKey
+
+// apiKeyLastUsedInterval min time between the updates of the last use of a key
+const apiKeyLastUsedInterval = time.Minute
+
+// ApiKeyService handles the api keys used to authenticate the requests
+type ApiKeyService struct {
+	db *gorm.DB
+}
+
+// NewApiKeyService creates a new ApiKeyService
+func NewApiKeyService(db *gorm.DB) ApiKeyService {
+	return ApiKeyService{
+		db: db,
+	}
+}
+
+// CreateApiKey creates a key for the subject
+// returns the raw key, it is not stored and can not be recovered
+func (s *ApiKeyService) CreateApiKey(ctx context.Context, name string, subject string) (string, *models.ApiKey, error) {
+	rawKey, prefix, err := auth.GenerateApiKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("[ApiKeyService] failed to generate key: %w", err)
+	}
+
+	apiKey := models.ApiKey{
+		Name:    name,
+		Subject: subject,
+		Prefix:  prefix,
+		Hash:    auth.HashApiKey(rawKey),
+	}
+
+	if err := s.db.WithContext(ctx).Create(&apiKey).Error; err != nil {
+		return "", nil, fmt.Errorf("[ApiKeyService] failed to create key: %w", err)
+	}
+
+	return rawKey, &apiKey, nil
+}
+
+// RevokeApiKey revokes a key, the revoked keys are kept to audit
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, id uint) error {
+	result := s.db.WithContext(ctx).
+		Model(&models.ApiKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now().UTC())
+
+	if result.Error != nil {
+		return fmt.Errorf("[ApiKeyService] failed to revoke key %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("[ApiKeyService] The key with ID %d was not found or is revoked: %w", id, gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// GetApiKeys returns all the keys, including the revoked
+func (s *ApiKeyService) GetApiKeys(ctx context.Context) ([]models.ApiKey, error) {
+	var apiKeys []models.ApiKey
+	if err := s.db.WithContext(ctx).Order("id asc").Find(&apiKeys).Error; err != nil {
+		return nil, fmt.Errorf("[ApiKeyService] failed to retrieve keys: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// HasActiveKeys checks there is at least one key that is not revoked
+func (s *ApiKeyService) HasActiveKeys(ctx context.Context) (bool, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.ApiKey{}).Where("revoked_at IS NULL").Count(&count).Error; err != nil {
+		return false, fmt.Errorf("[ApiKeyService] failed to count keys: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Authenticate returns the identity of the owner of the key
+// returns ErrInvalidApiKey if the key does not exist or was revoked
+func (s *ApiKeyService) Authenticate(ctx context.Context, rawKey string) (auth.Identity, error) {
+	var apiKey models.ApiKey
+	err := s.db.WithContext(ctx).
+		Where("hash = ? AND revoked_at IS NULL", auth.HashApiKey(rawKey)).
+		First(&apiKey).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return auth.Identity{}, ErrInvalidApiKey
+	}
+
+	if err != nil {
+		return auth.Identity{}, fmt.Errorf("[ApiKeyService] failed to authenticate key: %w", err)
+	}
+
+	// the last use is updated at most once per interval to not write on every request
+	now := time.Now().UTC()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := s.db.WithContext(ctx).Model(&apiKey).Update("last_used_at", now).Error; err != nil {
+			apilogger.Logger().Warn().Err(err).Msg(fmt.Sprintf("[ApiKeyService] failed to update the last use of the key %d", apiKey.ID))
+		}
+	}
+
+	return auth.Identity{
+		Subject: apiKey.Subject,
+		Method:  auth.MethodApiKey,
+		KeyID:   apiKey.ID,
+	}, nil
+}
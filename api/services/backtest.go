@@ -0,0 +1,196 @@
+package services
+
+import (
+	"api/backtest"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+)
+
+// MaxBacktestTickers max number of tickers with signals in a backtest,
+// each ticker requests its historical prices
+const MaxBacktestTickers = 50
+
+// MaxBacktestDays max number of days of the range of a backtest, without from the range starts
+// MaxBacktestDays before to, so a backtest never loads all the recommendations
+const MaxBacktestDays = 3 * 365
+
+// backtestBatchSize number of recommendations retrieved per query
+const backtestBatchSize = 1000
+
+// ErrInvalidBacktest is returned when the strategy or the range of the backtest are not valid
+var ErrInvalidBacktest = errors.New("invalid backtest")
+
+// BacktestService runs the strategies of the backtest package over the stored recommendations
+type BacktestService struct {
+	db     *gorm.DB
+	prices HistoricalPriceService
+}
+
+// NewBacktestService creates a new BacktestService
+// prices is used to retrieve the historical prices of the tickers with signals
+func NewBacktestService(db *gorm.DB, prices HistoricalPriceService) BacktestService {
+	return BacktestService{
+		db:     db,
+		prices: prices,
+	}
+}
+
+// Run simulates the strategy with the recommendations between from and to
+// tickers limits the recommendations to those tickers, if empty all the tickers are used
+// returns ErrInvalidBacktest if the strategy is not valid, the range is longer than MaxBacktestDays
+// or the signals have too many tickers
+func (s *BacktestService) Run(ctx context.Context, strategy backtest.Strategy, from time.Time, to time.Time, tickers []string) (backtest.Result, error) {
+	strategy, err := strategy.Normalize()
+	if err != nil {
+		return backtest.Result{}, fmt.Errorf("%w: %s", ErrInvalidBacktest, err.Error())
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return backtest.Result{}, fmt.Errorf("%w: 'To' date cannot be earlier than 'From' date", ErrInvalidBacktest)
+	}
+
+	end := to
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	first := end.AddDate(0, 0, -MaxBacktestDays)
+	if from.IsZero() {
+		from = first
+	}
+
+	if from.Before(first) {
+		return backtest.Result{}, fmt.Errorf("%w: the range is longer than %d days", ErrInvalidBacktest, MaxBacktestDays)
+	}
+
+	// the last day is included
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ids := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		ids = append(ids, strings.ToUpper(strings.TrimSpace(ticker)))
+	}
+
+	recommendations, err := s.loadRecommendations(ctx, strategy, from, to, ids)
+	if err != nil {
+		return backtest.Result{}, err
+	}
+
+	signals := strategy.Signals(recommendations, from, to)
+
+	// range of prices needed by the signals of each ticker
+	firstSignal := make(map[string]time.Time)
+	lastSignal := make(map[string]time.Time)
+	for _, signal := range signals {
+		if first, ok := firstSignal[signal.Ticker]; !ok || signal.Date.Before(first) {
+			firstSignal[signal.Ticker] = signal.Date
+		}
+
+		if signal.Date.After(lastSignal[signal.Ticker]) {
+			lastSignal[signal.Ticker] = signal.Date
+		}
+	}
+
+	if len(firstSignal) > MaxBacktestTickers {
+		return backtest.Result{}, fmt.Errorf("%w: the signals have %d tickers, the limit is %d, reduce the range or select the tickers", ErrInvalidBacktest, len(firstSignal), MaxBacktestTickers)
+	}
+
+	prices, err := s.loadPrices(ctx, firstSignal, lastSignal, strategy.HoldDays)
+	if err != nil {
+		return backtest.Result{}, err
+	}
+
+	return backtest.Simulate(strategy, signals, prices), nil
+}
+
+// loadRecommendations retrieves the recommendations of the range in batches,
+// the sentiment strategy also needs the last Window ratings of each ticker before the range
+func (s *BacktestService) loadRecommendations(ctx context.Context, strategy backtest.Strategy, from time.Time, to time.Time, tickers []string) ([]models.Recommendation, error) {
+	query := s.db.WithContext(ctx).Model(&models.Recommendation{}).Preload("Brokerage")
+	if len(tickers) > 0 {
+		query = query.Where("ticker_id IN ?", tickers)
+	}
+
+	inRange := s.db.Where("time >= ?", from)
+	if !to.IsZero() {
+		inRange = inRange.Where("time <= ?", to)
+	}
+
+	if strategy.Type == backtest.SentimentStrategy {
+		before := s.db.Model(&models.Recommendation{}).
+			Select("id, ROW_NUMBER() OVER (PARTITION BY ticker_id ORDER BY time DESC, id DESC) AS position").
+			Where("time < ?", from)
+		if len(tickers) > 0 {
+			before = before.Where("ticker_id IN ?", tickers)
+		}
+
+		window := s.db.Table("(?) AS ranked", before).Select("id").Where("position <= ?", strategy.Window)
+		inRange = inRange.Or("id IN (?)", window)
+	}
+
+	recommendations := make([]models.Recommendation, 0)
+	var batch []models.Recommendation
+	err := query.Where(inRange).FindInBatches(&batch, backtestBatchSize, func(tx *gorm.DB, _ int) error {
+		recommendations = append(recommendations, batch...)
+		return nil
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("[BacktestService] failed to retrieve recommendations: %w", err)
+	}
+
+	return recommendations, nil
+}
+
+// loadPrices retrieves the prices of each ticker since its first signal until
+// the hold days after its last signal, the calendar days are estimated from the trading days
+func (s *BacktestService) loadPrices(ctx context.Context, firstSignal map[string]time.Time, lastSignal map[string]time.Time, holdDays int) (map[string][]models.HistoricalPrice, error) {
+	tickers := make([]string, 0, len(firstSignal))
+	for ticker := range firstSignal {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	var mu sync.Mutex
+	prices := make(map[string][]models.HistoricalPrice, len(tickers))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	now := time.Now()
+	for _, ticker := range tickers {
+		from := firstSignal[ticker].AddDate(0, 0, -1)
+		to := lastSignal[ticker].AddDate(0, 0, holdDays*7/5+10)
+		if to.After(now) {
+			to = now
+		}
+
+		group.Go(func() error {
+			tickerPrices, err := s.prices.GetHistoricalPrices(groupCtx, ticker, from, to)
+			if err != nil {
+				return fmt.Errorf("[BacktestService] failed to retrieve prices of %s: %w", ticker, err)
+			}
+
+			mu.Lock()
+			prices[ticker] = tickerPrices
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"api/backtest"
+	"api/models"
+	"api/services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// noPrices returns the tickers without historical prices, the signals are counted and their trades skipped
+type noPrices struct{}
+
+func (noPrices) GetHistoricalPrices(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.HistoricalPrice, error) {
+	return nil, nil
+}
+
+// newTestBacktestService creates the backtest service with the recommendations of AAPL
+func newTestBacktestService(t *testing.T, recommendations []models.Recommendation) services.BacktestService {
+	t.Helper()
+
+	db := newTestDB(t, &models.Ticker{}, &models.Brokerage{}, &models.Recommendation{})
+	assert.NoError(t, db.Create(&models.Ticker{ID: "AAPL", Company: "Apple Inc."}).Error)
+	assert.NoError(t, db.Create(&models.Brokerage{ID: 1, Name: "Goldman Sachs"}).Error)
+	for i := range recommendations {
+		recommendations[i].TickerID = "AAPL"
+		recommendations[i].BrokerageID = 1
+	}
+	assert.NoError(t, db.Create(&recommendations).Error)
+
+	return services.NewBacktestService(db, noPrices{})
+}
+
+func TestBacktestRange(t *testing.T) {
+	now := time.Now()
+	service := newTestBacktestService(t, []models.Recommendation{
+		{Action: "upgraded by", RatingTo: "Buy", Time: now.AddDate(-5, 0, 0)},
+		{Action: "upgraded by", RatingTo: "Buy", Time: now.AddDate(0, 0, -10)},
+	})
+	strategy := backtest.Strategy{Type: backtest.ActionStrategy, Action: "upgraded"}
+
+	t.Run("without from the range is capped", func(t *testing.T) {
+		result, err := service.Run(context.Background(), strategy, time.Time{}, time.Time{}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Signals)
+	})
+
+	t.Run("rejects the ranges too long", func(t *testing.T) {
+		_, err := service.Run(context.Background(), strategy, now.AddDate(-10, 0, 0), now, nil)
+		assert.ErrorIs(t, err, services.ErrInvalidBacktest)
+	})
+}
+
+func TestBacktestSentimentWindowBeforeRange(t *testing.T) {
+	from := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	recommendations := []models.Recommendation{
+		{Action: "downgraded by", RatingTo: "Sell", Time: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Action: "upgraded by", RatingTo: "Buy", Time: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Action: "reiterated by", RatingTo: "Buy", Time: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{Action: "reiterated by", RatingTo: "Buy", Time: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{Action: "downgraded by", RatingTo: "Sell", Time: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{Action: "downgraded by", RatingTo: "Sell", Time: time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{Action: "upgraded by", RatingTo: "Buy", Time: time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC)},
+		{Action: "upgraded by", RatingTo: "Buy", Time: time.Date(2025, 1, 28, 0, 0, 0, 0, time.UTC)},
+	}
+	strategy, err := backtest.Strategy{Type: backtest.SentimentStrategy, Window: 2}.Normalize()
+	assert.NoError(t, err)
+
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	expected := strategy.Signals(recommendations, from, to)
+	// without the ratings before the range the first rating in the range would turn the sentiment positive
+	assert.NotEqual(t, len(expected), len(strategy.Signals(recommendations[3:], from, to)))
+
+	service := newTestBacktestService(t, recommendations)
+	result, err := service.Run(context.Background(), strategy, from, to, []string{"aapl"})
+	assert.NoError(t, err)
+	assert.Equal(t, len(expected), result.Signals)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"api/analytics"
+	"api/database/scopes"
+	apilogger "api/logger"
+	"api/models"
+	"api/models/filters"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BrokerageStatsResult summary of a refresh of the stats
+// FailedTickers are the tickers without prices, their calls are not evaluated
+type BrokerageStatsResult struct {
+	Brokerages    int
+	Tickers       int
+	FailedTickers int
+}
+
+// BrokerageService handles the brokerages and the stats of their calls
+type BrokerageService struct {
+	db     *gorm.DB
+	prices HistoricalPriceService
+}
+
+// NewBrokerageService creates a new BrokerageService
+// prices is used to retrieve the closes after the calls
+func NewBrokerageService(db *gorm.DB, prices HistoricalPriceService) BrokerageService {
+	return BrokerageService{
+		db:     db,
+		prices: prices,
+	}
+}
+
+// GetBrokerages retrieves a paginated list of brokerages with their stats
+// the query filters by name, OrderBy can be name, calls, upgrades, upgradeHitRate30, upgradeHitRate90, targetError or lastCallAt
+func (s *BrokerageService) GetBrokerages(ctx context.Context, filter filters.Filters) ([]models.Brokerage, int64, error) {
+	filter.Normalize()
+
+	query := s.db.WithContext(ctx).Model(&models.Brokerage{}).Joins("Stats")
+	if filter.Query != "" {
+		query = query.Where("brokerages.name ILIKE ?", "%"+filter.Query+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("[BrokerageService] failed to count brokerages: %w", err)
+	}
+
+	var brokerages []models.Brokerage
+	err := query.
+		Scopes(scopes.SortBrokerage(filter.OrderBy, filter.Sort), scopes.Pagination(filter.Page, filter.PageSize)).
+		Find(&brokerages).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("[BrokerageService] failed to retrieve brokerages: %w", err)
+	}
+
+	return brokerages, total, nil
+}
+
+// GetBrokerage retrieves a brokerage with its stats
+func (s *BrokerageService) GetBrokerage(ctx context.Context, id uint) (*models.Brokerage, error) {
+	var brokerage models.Brokerage
+	err := s.db.WithContext(ctx).Joins("Stats").Where("brokerages.id = ?", id).First(&brokerage).Error
+	if err != nil {
+		return nil, fmt.Errorf("[BrokerageService] failed to retrieve brokerage %d: %w", id, err)
+	}
+
+	return &brokerage, nil
+}
+
+// RefreshStats recalculates the stats of all the brokerages with the closes after their calls
+func (s *BrokerageService) RefreshStats(ctx context.Context) (BrokerageStatsResult, error) {
+	var result BrokerageStatsResult
+
+	var recommendations []models.Recommendation
+	err := s.db.WithContext(ctx).
+		Select("id", "ticker_id", "brokerage_id", "action", "target_to", "time").
+		Find(&recommendations).Error
+	if err != nil {
+		return result, fmt.Errorf("[BrokerageService] failed to retrieve recommendations: %w", err)
+	}
+
+	firstCall := make(map[string]time.Time)
+	for _, recommendation := range recommendations {
+		if first, ok := firstCall[recommendation.TickerID]; !ok || recommendation.Time.Before(first) {
+			firstCall[recommendation.TickerID] = recommendation.Time
+		}
+	}
+	result.Tickers = len(firstCall)
+
+	var mu sync.Mutex
+	prices := make(map[string][]models.HistoricalPrice, len(firstCall))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	now := time.Now()
+	for ticker, first := range firstCall {
+		group.Go(func() error {
+			tickerPrices, err := s.prices.GetHistoricalPrices(groupCtx, ticker, first.AddDate(0, 0, -1), now)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// a ticker without prices does not stop the refresh
+			if err != nil {
+				apilogger.Logger().Warn().Err(err).Msg("[BrokerageService] failed to retrieve prices of " + ticker)
+				result.FailedTickers++
+				return groupCtx.Err()
+			}
+
+			prices[ticker] = tickerPrices
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return result, fmt.Errorf("[BrokerageService] failed to retrieve prices: %w", err)
+	}
+
+	stats := analytics.BrokerageMetrics(recommendations, prices)
+	rows := make([]models.BrokerageStats, 0, len(stats))
+	for _, brokerageStats := range stats {
+		rows = append(rows, *brokerageStats)
+	}
+	result.Brokerages = len(rows)
+
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	err = s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "brokerage_id"}},
+			UpdateAll: true,
+		}).
+		CreateInBatches(rows, 500).Error
+	if err != nil {
+		return result, fmt.Errorf("[BrokerageService] failed to save stats: %w", err)
+	}
+
+	return result, nil
+}
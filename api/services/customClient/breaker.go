@@ -0,0 +1,136 @@
+package CustomClient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// states of the circuit of a host
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// outcome of a request for the circuit of its host
+type outcome int
+
+const (
+	// succeeded the host answered, including the 4xx responses
+	succeeded outcome = iota
+	// failed the host did not answer or answered 5xx after the retries
+	failed
+	// neutral the host throttled the request, the circuit does not change
+	neutral
+)
+
+// hostState circuit breaker and semaphore of a host shared by all the clients
+type hostState struct {
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	semaphore chan struct{}
+}
+
+var hosts sync.Map
+
+// stateOf returns the state of the host, the semaphore is sized with the policy of the first request of the host
+func stateOf(host string, policy Policy) *hostState {
+	if state, ok := hosts.Load(host); ok {
+		return state.(*hostState)
+	}
+
+	state := &hostState{state: CircuitClosed}
+	if policy.MaxConcurrent > 0 {
+		state.semaphore = make(chan struct{}, policy.MaxConcurrent)
+	}
+
+	actual, _ := hosts.LoadOrStore(host, state)
+	return actual.(*hostState)
+}
+
+// CircuitState returns the state of the circuit of the host
+func CircuitState(host string) string {
+	state, ok := hosts.Load(host)
+	if !ok {
+		return CircuitClosed
+	}
+
+	h := state.(*hostState)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.state
+}
+
+// allow checks the request can be sent, after the cooldown the circuit is half-open and one request probes the host
+func (h *hostState) allow(policy Policy, now time.Time) bool {
+	if policy.BreakerThreshold <= 0 {
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch h.state {
+	case CircuitOpen:
+		if now.Sub(h.openedAt) < policy.BreakerCooldown {
+			return false
+		}
+
+		h.state = CircuitHalfOpen
+		h.probing = true
+		return true
+	case CircuitHalfOpen:
+		if h.probing {
+			return false
+		}
+
+		h.probing = true
+		return true
+	}
+
+	return true
+}
+
+// record updates the circuit with the outcome of a request
+func (h *hostState) record(policy Policy, result outcome, now time.Time) {
+	if policy.BreakerThreshold <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch result {
+	case succeeded:
+		h.state = CircuitClosed
+		h.failures = 0
+		h.probing = false
+	case failed:
+		h.failures++
+		if h.state == CircuitHalfOpen || h.failures >= policy.BreakerThreshold {
+			h.state = CircuitOpen
+			h.openedAt = now
+		}
+		h.probing = false
+	case neutral:
+		h.probing = false
+	}
+}
+
+// acquire takes a place of the semaphore of the host, the release function frees it
+func (h *hostState) acquire(ctx context.Context) (func(), error) {
+	if h.semaphore == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case h.semaphore <- struct{}{}:
+		return func() { <-h.semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -1,6 +1,7 @@
 package CustomClient
 
 import (
+	"api/config"
 	"net/http"
 	"time"
 )
@@ -9,16 +10,43 @@ type CustomClient struct {
 	Client  *http.Client
 	BaseURL string
 	Headers map[string]string
+	Policy  Policy
+}
+
+// Policy retries, circuit breaker and concurrency of the requests
+// the circuit breaker and the semaphore are shared by all the clients of the same host
+// RetryNonIdempotent also retries the POST and PATCH requests, only for the endpoints that tolerate duplicates
+type Policy struct {
+	MaxAttempts        int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+	MaxConcurrent      int
+	BreakerThreshold   int
+	BreakerCooldown    time.Duration
+	RetryNonIdempotent bool
+}
+
+// DefaultPolicy returns the policy of config.HTTPClient
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:      config.HTTPClient().MaxAttempts,
+		RetryBaseDelay:   config.HTTPClient().RetryBaseDelay,
+		RetryMaxDelay:    config.HTTPClient().RetryMaxDelay,
+		MaxConcurrent:    config.HTTPClient().MaxConcurrent,
+		BreakerThreshold: config.HTTPClient().BreakerThreshold,
+		BreakerCooldown:  config.HTTPClient().BreakerCooldown,
+	}
 }
 
 func NewCustomClient(baseURL string) CustomClient {
 	return CustomClient{
 		Client: &http.Client{
-			Timeout: time.Second * 60,
+			Timeout: config.HTTPClient().Timeout,
 		},
 		BaseURL: baseURL,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
+		Policy: DefaultPolicy(),
 	}
 }
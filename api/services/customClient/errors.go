@@ -0,0 +1,91 @@
+package CustomClient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxBodyExcerpt max bytes of the body of the response kept in the errors
+const maxBodyExcerpt = 512
+
+var (
+	// ErrNotFound matches the HTTPError of the 404 responses
+	ErrNotFound = errors.New("not found")
+	// ErrThrottled matches the HTTPError of the 429 responses
+	ErrThrottled = errors.New("throttled")
+	// ErrCircuitOpen is returned without calling the host while its circuit is open
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// HTTPError is the error of a request that failed after the retries
+// StatusCode is 0 when the request got no response, then Err is the error of the transport
+type HTTPError struct {
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int
+	Body       string
+	Retries    int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("error executing request: %s %s%s after %d retries: %v", e.Method, e.Host, e.Path, e.Retries, e.Err)
+	}
+
+	message := fmt.Sprintf("unexpected status code: %d: %s %s%s after %d retries", e.StatusCode, e.Method, e.Host, e.Path, e.Retries)
+	if e.Body != "" {
+		message += ": " + e.Body
+	}
+
+	return message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrNotFound with the 404 responses and ErrThrottled with the 429 responses
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrThrottled:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// IsNotFound checks the error is a 404 response
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsThrottled checks the error is a 429 response
+func IsThrottled(err error) bool {
+	return errors.Is(err, ErrThrottled)
+}
+
+// StatusCode returns the status code of the HTTPError of the chain, 0 without response
+func StatusCode(err error) int {
+	var httpError *HTTPError
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode
+	}
+
+	return 0
+}
+
+// excerpt returns the beginning of the body of a response
+func excerpt(body []byte) string {
+	if len(body) > maxBodyExcerpt {
+		return string(body[:maxBodyExcerpt]) + "..."
+	}
+
+	return string(body)
+}
@@ -1,32 +1,34 @@
 package CustomClient
 
 import (
+	"context"
+	"maps"
 	"net/http"
 )
 
-func (c *CustomClient) GetRaw(endpoint string, queryParams map[string]string) ([]byte, error) {
-	// create a new client with the same base url and headers, and remove content type to return raw response
-	client := NewCustomClient(c.BaseURL)
-	client.Headers = c.Headers
+func (c *CustomClient) GetRaw(ctx context.Context, endpoint string, queryParams map[string]string) ([]byte, error) {
+	// copy the client with its own headers, and remove content type to return raw response
+	client := *c
+	client.Headers = maps.Clone(c.Headers)
 	client.Headers["Content-Type"] = ""
-	return client.doRequestRaw(http.MethodGet, endpoint, queryParams, nil)
+	return client.doRequestRaw(ctx, http.MethodGet, endpoint, queryParams, nil)
 }
 
-func (c *CustomClient) Get(endpoint string, queryParams map[string]string, result interface{}) error {
-	return c.doRequest(http.MethodGet, endpoint, queryParams, nil, result)
+func (c *CustomClient) Get(ctx context.Context, endpoint string, queryParams map[string]string, result interface{}) error {
+	return c.doRequest(ctx, http.MethodGet, endpoint, queryParams, nil, result)
 }
 
-func (c *CustomClient) Post(endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
-	return c.doRequest(http.MethodPost, endpoint, queryParams, body, result)
+func (c *CustomClient) Post(ctx context.Context, endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
+	return c.doRequest(ctx, http.MethodPost, endpoint, queryParams, body, result)
 }
 
-func (c *CustomClient) Put(endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
-	return c.doRequest(http.MethodPut, endpoint, queryParams, body, result)
+func (c *CustomClient) Put(ctx context.Context, endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
+	return c.doRequest(ctx, http.MethodPut, endpoint, queryParams, body, result)
 }
-func (c *CustomClient) Patch(endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
-	return c.doRequest(http.MethodPatch, endpoint, queryParams, body, result)
+func (c *CustomClient) Patch(ctx context.Context, endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
+	return c.doRequest(ctx, http.MethodPatch, endpoint, queryParams, body, result)
 }
 
-func (c *CustomClient) Delete(endpoint string, queryParams map[string]string, result interface{}) error {
-	return c.doRequest(http.MethodDelete, endpoint, queryParams, nil, result)
+func (c *CustomClient) Delete(ctx context.Context, endpoint string, queryParams map[string]string, result interface{}) error {
+	return c.doRequest(ctx, http.MethodDelete, endpoint, queryParams, nil, result)
 }
@@ -1,17 +1,22 @@
 package CustomClient
 
 import (
+	"api/metrics"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // doRequest execute a  HTTP request
-func (c *CustomClient) doRequest(method, endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
-	bodyBytes, err := c.doRequestRaw(method, endpoint, queryParams, body)
+func (c *CustomClient) doRequest(ctx context.Context, method, endpoint string, queryParams map[string]string, body interface{}, result interface{}) error {
+	bodyBytes, err := c.doRequestRaw(ctx, method, endpoint, queryParams, body)
 	if err != nil {
 		return err
 	}
@@ -27,24 +32,137 @@ func (c *CustomClient) doRequest(method, endpoint string, queryParams map[string
 }
 
 // doRequestRaw execute a HTTP request without parsing the response
-func (c *CustomClient) doRequestRaw(method, endpoint string, queryParams map[string]string, body interface{}) ([]byte, error) {
-	req, err := c.BuildRequest(method, endpoint, queryParams, body)
+// the 429 and 5xx responses and the errors of the transport are retried with the policy of the client,
+// only the idempotent methods are retried unless the policy allows the others,
+// the errors of the responses are *HTTPError, the cancellation of the context stops the retries
+func (c *CustomClient) doRequestRaw(ctx context.Context, method, endpoint string, queryParams map[string]string, body interface{}) ([]byte, error) {
+	req, err := c.BuildRequest(ctx, method, endpoint, queryParams, body)
 	if err != nil {
 		return nil, fmt.Errorf("error building request: %w", err)
 	}
 
-	// 2. execute request
+	host := req.URL.Host
+	state := stateOf(host, c.Policy)
+	if !state.allow(c.Policy, time.Now()) {
+		metrics.UpstreamRequestsTotal.WithLabelValues(host, "circuit_open").Inc()
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+	}
+
+	maxAttempts := 1
+	if idempotent(method) || c.Policy.RetryNonIdempotent {
+		maxAttempts = max(c.Policy.MaxAttempts, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			// the body of the request is consumed by the previous attempt
+			if req, err = c.BuildRequest(ctx, method, endpoint, queryParams, body); err != nil {
+				return nil, fmt.Errorf("error building request: %w", err)
+			}
+		}
+
+		// the slot is taken only while the request is sent, the backoff does not hold it
+		release, err := state.acquire(ctx)
+		if err != nil {
+			state.record(c.Policy, neutral, time.Now())
+			return nil, fmt.Errorf("error executing request: %w", err)
+		}
+
+		responseBody, httpError := c.execute(req)
+		release()
+		if httpError == nil {
+			state.record(c.Policy, succeeded, time.Now())
+			return responseBody, nil
+		}
+
+		httpError.Retries = attempt - 1
+		if ctx.Err() != nil {
+			// the caller gave up, it says nothing about the host
+			state.record(c.Policy, neutral, time.Now())
+			return nil, canceled(ctx, httpError)
+		}
+
+		canRetry := httpError.StatusCode == 0 || retryable(httpError.StatusCode)
+
+		if !canRetry || attempt >= maxAttempts {
+			switch {
+			case httpError.StatusCode == http.StatusTooManyRequests:
+				state.record(c.Policy, neutral, time.Now())
+			case canRetry:
+				state.record(c.Policy, failed, time.Now())
+			default:
+				state.record(c.Policy, succeeded, time.Now())
+			}
+
+			return nil, httpError
+		}
+
+		delay := backoff(c.Policy, attempt-1)
+		if httpError.RetryAfter > 0 {
+			delay = httpError.RetryAfter
+			if c.Policy.RetryMaxDelay > 0 && delay > c.Policy.RetryMaxDelay {
+				delay = c.Policy.RetryMaxDelay
+			}
+		}
+
+		metrics.UpstreamRetriesTotal.WithLabelValues(host).Inc()
+		if err := sleep(ctx, delay); err != nil {
+			state.record(c.Policy, neutral, time.Now())
+			return nil, canceled(ctx, httpError)
+		}
+	}
+}
+
+// canceled returns the error of the last attempt matching the error of the context
+func canceled(ctx context.Context, httpError *HTTPError) error {
+	if errors.Is(httpError, ctx.Err()) {
+		return httpError
+	}
+
+	return fmt.Errorf("%w: %w", ctx.Err(), httpError)
+}
+
+// execute sends the request once, the responses that are not 2xx are returned as *HTTPError
+func (c *CustomClient) execute(req *http.Request) ([]byte, *HTTPError) {
+	start := time.Now()
 	resp, err := c.Client.Do(req)
+	metrics.UpstreamRequestDuration.WithLabelValues(req.URL.Host).Observe(time.Since(start).Seconds())
 	if err != nil {
-		return nil, fmt.Errorf("error executing request: %w", err)
+		metrics.UpstreamRequestsTotal.WithLabelValues(req.URL.Host, "error").Inc()
+		return nil, &HTTPError{Method: req.Method, Host: req.URL.Host, Path: req.URL.Path, Err: withoutQuery(err, req.URL)}
 	}
 	defer resp.Body.Close()
 
-	return c.validateAndProcessBody(resp)
+	metrics.UpstreamRequestsTotal.WithLabelValues(req.URL.Host, strconv.Itoa(resp.StatusCode)).Inc()
+
+	responseBody, err := c.validateAndProcessBody(resp)
+	if err != nil {
+		var httpError *HTTPError
+		if errors.As(err, &httpError) {
+			httpError.Method = req.Method
+			httpError.Host = req.URL.Host
+			httpError.Path = req.URL.Path
+			return nil, httpError
+		}
+
+		return nil, &HTTPError{Method: req.Method, Host: req.URL.Host, Path: req.URL.Path, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return responseBody, nil
+}
+
+// withoutQuery removes the query of the url of the transport error, it has the api keys of the providers
+func withoutQuery(err error, requestURL *url.URL) error {
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		urlError.URL = requestURL.Host + requestURL.Path
+	}
+
+	return err
 }
 
 // BuildRequest builds a HTTP request
-func (c *CustomClient) BuildRequest(method, endpoint string, queryParams map[string]string, body interface{}) (*http.Request, error) {
+func (c *CustomClient) BuildRequest(ctx context.Context, method, endpoint string, queryParams map[string]string, body interface{}) (*http.Request, error) {
 	// 1. build url
 	fullURL, err := c.buildURL(endpoint, queryParams)
 	if err != nil {
@@ -58,7 +176,7 @@ func (c *CustomClient) BuildRequest(method, endpoint string, queryParams map[str
 	}
 
 	// 3. create request
-	req, err := http.NewRequest(method, fullURL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
@@ -110,7 +228,12 @@ func (c *CustomClient) setHeaders(req *http.Request) {
 func (c *CustomClient) validateAndProcessBody(resp *http.Response) ([]byte, error) {
 	// check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyExcerpt+1))
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Body:       excerpt(responseBody),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	// read body
@@ -0,0 +1,347 @@
+package CustomClient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient returns a client of the server with short delays
+func newTestClient(t *testing.T, handler http.HandlerFunc) (CustomClient, string) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewCustomClient(server.URL)
+	client.Policy = Policy{
+		MaxAttempts:      3,
+		RetryBaseDelay:   time.Millisecond,
+		RetryMaxDelay:    5 * time.Millisecond,
+		MaxConcurrent:    10,
+		BreakerThreshold: 2,
+		BreakerCooldown:  50 * time.Millisecond,
+	}
+
+	return client, strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestRetries(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	var result map[string]bool
+	err := client.Get(context.Background(), "/", nil, &result)
+	assert.NoError(t, err)
+	assert.True(t, result["ok"])
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	var calls atomic.Int32
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("x", 2*maxBodyExcerpt)))
+	})
+
+	_, err := client.GetRaw(context.Background(), "/quote", nil)
+
+	var httpError *HTTPError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, http.StatusBadGateway, httpError.StatusCode)
+	assert.Equal(t, 2, httpError.Retries)
+	assert.Equal(t, host, httpError.Host)
+	assert.Equal(t, "/quote", httpError.Path)
+	assert.Len(t, httpError.Body, maxBodyExcerpt+len("..."))
+	assert.ErrorContains(t, err, "unexpected status code: 502")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestNotFoundIsNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, r)
+	})
+
+	_, err := client.GetRaw(context.Background(), "/missing", nil)
+	assert.True(t, IsNotFound(err))
+	assert.False(t, IsThrottled(err))
+	assert.Equal(t, http.StatusNotFound, StatusCode(err))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+	client.Policy.RetryMaxDelay = time.Second
+
+	start := time.Now()
+	body, err := client.GetRaw(context.Background(), "/", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestThrottled(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	client.Policy.MaxAttempts = 1
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetRaw(context.Background(), "/", nil)
+		assert.True(t, IsThrottled(err))
+	}
+
+	// the throttling does not open the circuit
+	assert.Equal(t, CircuitClosed, CircuitState(host))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var calls atomic.Int32
+	failing.Store(true)
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+	client.Policy.MaxAttempts = 1
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetRaw(context.Background(), "/", nil)
+		assert.Equal(t, http.StatusInternalServerError, StatusCode(err))
+	}
+	assert.Equal(t, CircuitOpen, CircuitState(host))
+
+	_, err := client.GetRaw(context.Background(), "/", nil)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// the failed probe opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetRaw(context.Background(), "/", nil)
+	assert.Equal(t, http.StatusInternalServerError, StatusCode(err))
+	assert.Equal(t, CircuitOpen, CircuitState(host))
+
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	body, err := client.GetRaw(context.Background(), "/", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, CircuitClosed, CircuitState(host))
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	var current, peak atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		current.Add(-1)
+		w.Write([]byte("ok"))
+	})
+	client.Policy.MaxConcurrent = 2
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetRaw(context.Background(), "/", nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestContextCanceled(t *testing.T) {
+	var calls atomic.Int32
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.Policy.RetryBaseDelay = time.Second
+	client.Policy.RetryMaxDelay = time.Second
+
+	// the context is cancelled while waiting the retry
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.GetRaw(ctx, "/", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// a cancelled context does not call the host
+	_, err = client.GetRaw(ctx, "/", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, CircuitClosed, CircuitState(host))
+}
+
+func TestContextDeadline(t *testing.T) {
+	var calls atomic.Int32
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetRaw(ctx, "/", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// the deadline of the caller is not retried and is not a failure of the host
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, CircuitClosed, CircuitState(host))
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{RetryBaseDelay: 100 * time.Millisecond, RetryMaxDelay: time.Second}
+
+	for retry, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := backoff(policy, retry)
+		assert.GreaterOrEqual(t, delay, expected/2)
+		assert.LessOrEqual(t, delay, expected)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		desc     string
+		header   string
+		expected time.Duration
+	}{
+		{desc: "empty", header: "", expected: 0},
+		{desc: "seconds", header: "30", expected: 30 * time.Second},
+		{desc: "date", header: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute},
+		{desc: "past date", header: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0},
+		{desc: "invalid", header: "soon", expected: 0},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, retryAfter(tC.header, now))
+		})
+	}
+}
+
+func TestNonIdempotentNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client.Policy.BreakerThreshold = 0
+
+	err := client.Post(context.Background(), "/", nil, map[string]string{"name": "test"}, nil)
+	var httpError *HTTPError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 0, httpError.Retries)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// the caller allows the retries of the POST
+	client.Policy.RetryNonIdempotent = true
+	err = client.Post(context.Background(), "/", nil, map[string]string{"name": "test"}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestBackoffReleasesSlot(t *testing.T) {
+	var calls atomic.Int32
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/retry" && calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte("ok"))
+	})
+	client.Policy.MaxConcurrent = 1
+	client.Policy.RetryBaseDelay = 200 * time.Millisecond
+	client.Policy.RetryMaxDelay = 200 * time.Millisecond
+
+	retried := make(chan error, 1)
+	go func() {
+		_, err := client.GetRaw(context.Background(), "/retry", nil)
+		retried <- err
+	}()
+
+	// the other request is sent while the first one waits for its retry
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	_, err := client.GetRaw(context.Background(), "/other", nil)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 90*time.Millisecond)
+
+	assert.NoError(t, <-retried)
+}
+
+func TestTransportErrorWithoutQuery(t *testing.T) {
+	client, host := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {})
+	client.Policy.MaxAttempts = 1
+	client.Client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	_, err := client.GetRaw(context.Background(), "/profile", map[string]string{"apikey": "secret_token"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, StatusCode(err))
+	assert.Contains(t, err.Error(), host+"/profile")
+	assert.NotContains(t, err.Error(), "apikey")
+	assert.NotContains(t, err.Error(), "secret_token")
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,75 @@
+package CustomClient
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryable checks the status code is worth another attempt
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// idempotent checks a request with the method can be sent again without changing the result,
+// a retry of the other methods can repeat the action when the first attempt reached the host
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff returns the delay before the retry, it doubles with each retry until the max delay
+// and half of it is random so the clients do not retry at the same time
+func backoff(policy Policy, retry int) time.Duration {
+	delay := policy.RetryBaseDelay
+	for i := 0; i < retry && delay < policy.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if policy.RetryMaxDelay > 0 && delay > policy.RetryMaxDelay {
+		delay = policy.RetryMaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses the Retry-After header in seconds or as HTTP date, 0 if it is missing or invalid
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
+
+// sleep waits the delay or until the context is done
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
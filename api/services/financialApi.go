@@ -75,9 +75,9 @@ func (s *FinancialService) GetHistoricalPrices(ctx context.Context, ticker strin
 	expiration := calculateHistoricDataExpirationInMinutes(365)
 	key := fmt.Sprintf("FinancialService:historical_prices:%s:%s:%s", ticker, from.Format("2006-01-02"), to.Format("2006-01-02"))
 
-	historicalPrices, err := cache.GetOrLoad(ctx, s.Cache, key, expiration, func() ([]models.HistoricalPrice, error) {
+	historicalPrices, err := cache.GetOrLoad(ctx, s.Cache, key, expiration, func(ctx context.Context) ([]models.HistoricalPrice, error) {
 		var historicalPrices []models.HistoricalPrice
-		if err := s.Client.Get("/stable/historical-price-eod/full", params, &historicalPrices); err != nil {
+		if err := s.Client.Get(ctx, "/stable/historical-price-eod/full", params, &historicalPrices); err != nil {
 			return nil, fmt.Errorf("[FinancialService] failed to retrieve historical prices id: %s: %w", ticker, err)
 		}
 
@@ -94,7 +94,7 @@ func (s *FinancialService) GetHistoricalPrices(ctx context.Context, ticker strin
 // GetLogo returns the logo of a company as a byte array
 func (s *FinancialService) GetLogo(ctx context.Context, ticker string) ([]byte, error) {
 	url := fmt.Sprintf("/image-stock/%s.png", strings.ToUpper(ticker))
-	logo, err := s.Client.GetRaw(url, nil)
+	logo, err := s.Client.GetRaw(ctx, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("[FinancialService] failed to retrieve logo id: %s: %w", ticker, err)
 	}
@@ -119,11 +119,15 @@ func (s *FinancialService) GetCompanyData(ctx context.Context, ticker string) (m
 	key := fmt.Sprintf("FinancialService:company_data:%s", ticker)
 	expiration := s.CacheExpiration.CompanyData
 
-	companyData, err := cache.GetOrLoad(ctx, s.Cache, key, expiration, func() (models.CompanyData, error) {
+	companyData, err := cache.GetOrLoad(ctx, s.Cache, key, expiration, func(ctx context.Context) (models.CompanyData, error) {
 		var companyData []models.CompanyData
-		if err := s.Client.Get("/stable/profile", params, &companyData); err != nil {
+		if err := s.Client.Get(ctx, "/stable/profile", params, &companyData); err != nil {
 			return models.CompanyData{}, fmt.Errorf("[FinancialService] failed to retrieve company data id: %s: %w", ticker, err)
 		}
+
+		if len(companyData) == 0 {
+			return models.CompanyData{}, fmt.Errorf("[FinancialService] company data not found id: %s: %w", ticker, CustomClient.ErrNotFound)
+		}
 		return companyData[0], nil
 	})
 
@@ -131,6 +135,11 @@ func (s *FinancialService) GetCompanyData(ctx context.Context, ticker string) (m
 		return models.CompanyData{}, err
 	}
 
+	return sanitizeCompanyData(companyData), nil
+}
+
+// sanitizeCompanyData sanitizes the texts of the company data of the providers
+func sanitizeCompanyData(companyData models.CompanyData) models.CompanyData {
 	companyData.Website = sanatizer.SanatizerString(companyData.Website).SanatizedAll().String()
 	companyData.ExchangeFullName = sanatizer.SanatizerString(companyData.ExchangeFullName).SanatizedAll().String()
 	companyData.Exchange = sanatizer.SanatizerString(companyData.Exchange).SanatizedAll().String()
@@ -141,7 +150,7 @@ func (s *FinancialService) GetCompanyData(ctx context.Context, ticker string) (m
 	companyData.Image = sanatizer.SanatizerString(companyData.Image).SanatizedAll().String()
 	companyData.CEO = sanatizer.SanatizerString(companyData.CEO).SanatizedAll().String()
 
-	return companyData, nil
+	return companyData
 }
 
 func calculateHistoricDataExpirationInMinutes(days int) time.Duration {
@@ -8,6 +8,7 @@ import (
 	CustomClient "api/services/customClient"
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,7 +22,9 @@ type FinghubService struct {
 }
 
 type FinghubCacheExpiration struct {
-	News time.Duration
+	News             time.Duration
+	HistoricalPrices time.Duration
+	CompanyData      time.Duration
 }
 
 func (f FinghubCacheExpiration) Normalize() FinghubCacheExpiration {
@@ -29,6 +32,14 @@ func (f FinghubCacheExpiration) Normalize() FinghubCacheExpiration {
 		f.News = 5 * time.Minute
 	}
 
+	if f.HistoricalPrices <= 0 {
+		f.HistoricalPrices = 10 * time.Minute
+	}
+
+	if f.CompanyData <= 0 {
+		f.CompanyData = 30 * time.Minute
+	}
+
 	return f
 }
 
@@ -72,9 +83,9 @@ func (s *FinghubService) GetNews(ctx context.Context, ticker string, from time.T
 
 	cacheKey := fmt.Sprintf("FinghubService:news:%s:%s:%s", ticker, fromString, toString)
 	expiration := s.CacheExpiration.News
-	news, err := cache.GetOrLoad(ctx, s.Cache, cacheKey, expiration, func() ([]models.CompanyNew, error) {
+	news, err := cache.GetOrLoad(ctx, s.Cache, cacheKey, expiration, func(ctx context.Context) ([]models.CompanyNew, error) {
 		var news []models.CompanyNew
-		if err := s.Client.Get("/company-news", queryParams, &news); err != nil {
+		if err := s.Client.Get(ctx, "/company-news", queryParams, &news); err != nil {
 			return nil, fmt.Errorf("[FinghubService] failed to retrieve news id: %s: %w", ticker, err)
 		}
 		return news, nil
@@ -99,3 +110,182 @@ func (s *FinghubService) GetNews(ctx context.Context, ticker string, from time.T
 
 	return news, nil
 }
+
+// finnhubCandles daily candles of /stock/candle, the values of each bar are at the same index
+// Status is no_data when the range has no bars
+type finnhubCandles struct {
+	Close  []float64 `json:"c"`
+	High   []float64 `json:"h"`
+	Low    []float64 `json:"l"`
+	Open   []float64 `json:"o"`
+	Time   []int64   `json:"t"`
+	Volume []float64 `json:"v"`
+	Status string    `json:"s"`
+}
+
+// finnhubProfile company profile of /stock/profile2, the market capitalization is in millions
+type finnhubProfile struct {
+	Ticker               string  `json:"ticker"`
+	Name                 string  `json:"name"`
+	Country              string  `json:"country"`
+	Exchange             string  `json:"exchange"`
+	FinnhubIndustry      string  `json:"finnhubIndustry"`
+	Logo                 string  `json:"logo"`
+	MarketCapitalization float64 `json:"marketCapitalization"`
+	WebURL               string  `json:"weburl"`
+}
+
+// finnhubQuote current price of /quote
+type finnhubQuote struct {
+	Current       float64 `json:"c"`
+	Change        float64 `json:"d"`
+	ChangePercent float64 `json:"dp"`
+}
+
+// GetHistoricalPrices returns the daily prices of the ticker from the candles, the newest first like the financial API
+// a zero from is 5 years before to and a zero to is today
+func (s *FinghubService) GetHistoricalPrices(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.HistoricalPrice, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	if from.IsZero() {
+		from = to.AddDate(-5, 0, 0)
+	}
+
+	ticker = strings.ToUpper(ticker)
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 0, time.UTC)
+
+	queryParams := map[string]string{
+		"symbol":     ticker,
+		"resolution": "D",
+		"from":       strconv.FormatInt(fromDay.Unix(), 10),
+		"to":         strconv.FormatInt(toDay.Unix(), 10),
+		"token":      s.Token,
+	}
+
+	cacheKey := fmt.Sprintf("FinghubService:historical_prices:%s:%s:%s", ticker, fromDay.Format("2006-01-02"), toDay.Format("2006-01-02"))
+	return cache.GetOrLoad(ctx, s.Cache, cacheKey, s.CacheExpiration.HistoricalPrices, func(ctx context.Context) ([]models.HistoricalPrice, error) {
+		var candles finnhubCandles
+		if err := s.Client.Get(ctx, "/stock/candle", queryParams, &candles); err != nil {
+			return nil, fmt.Errorf("[FinghubService] failed to retrieve historical prices id: %s: %w", ticker, err)
+		}
+
+		if candles.Status != "ok" && candles.Status != "no_data" {
+			return nil, fmt.Errorf("[FinghubService] failed to retrieve historical prices id: %s: status %q", ticker, candles.Status)
+		}
+
+		prices := make([]models.HistoricalPrice, 0, len(candles.Time))
+		for i := len(candles.Time) - 1; i >= 0; i-- {
+			if i >= len(candles.Close) || i >= len(candles.Open) || i >= len(candles.High) || i >= len(candles.Low) || i >= len(candles.Volume) {
+				continue
+			}
+
+			price := models.HistoricalPrice{
+				Symbol: ticker,
+				Date:   time.Unix(candles.Time[i], 0).UTC().Format("2006-01-02"),
+				Open:   candles.Open[i],
+				High:   candles.High[i],
+				Low:    candles.Low[i],
+				Close:  candles.Close[i],
+				Volume: candles.Volume[i],
+				Change: candles.Close[i] - candles.Open[i],
+				Vwap:   (candles.High[i] + candles.Low[i] + candles.Close[i]) / 3,
+			}
+
+			if price.Open != 0 {
+				price.ChangeP = price.Change / price.Open * 100
+			}
+
+			prices = append(prices, price)
+		}
+
+		return prices, nil
+	})
+}
+
+// GetCompanyData returns the company data of the ticker from the profile and the quote
+// finnhub has no sector, the industry of finnhub is used as sector
+func (s *FinghubService) GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error) {
+	ticker = strings.ToUpper(ticker)
+
+	cacheKey := fmt.Sprintf("FinghubService:company_data:%s", ticker)
+	companyData, err := cache.GetOrLoad(ctx, s.Cache, cacheKey, s.CacheExpiration.CompanyData, func(ctx context.Context) (models.CompanyData, error) {
+		profile, err := s.getProfile(ctx, ticker)
+		if err != nil {
+			return models.CompanyData{}, err
+		}
+
+		var quote finnhubQuote
+		if err := s.Client.Get(ctx, "/quote", map[string]string{"symbol": ticker, "token": s.Token}, &quote); err != nil {
+			return models.CompanyData{}, fmt.Errorf("[FinghubService] failed to retrieve quote id: %s: %w", ticker, err)
+		}
+
+		return models.CompanyData{
+			Symbol:           ticker,
+			Price:            quote.Current,
+			MarketCap:        profile.MarketCapitalization * 1e6,
+			Change:           quote.Change,
+			ChangePercentage: quote.ChangePercent,
+			CompanyName:      profile.Name,
+			ExchangeFullName: profile.Exchange,
+			Exchange:         profile.Exchange,
+			Industry:         profile.FinnhubIndustry,
+			Sector:           profile.FinnhubIndustry,
+			Website:          profile.WebURL,
+			Country:          profile.Country,
+			Image:            profile.Logo,
+		}, nil
+	})
+
+	if err != nil {
+		return models.CompanyData{}, err
+	}
+
+	return sanitizeCompanyData(companyData), nil
+}
+
+// GetLogoUrl returns the url of the logo of the profile of the ticker
+func (s *FinghubService) GetLogoUrl(ctx context.Context, ticker string) (string, error) {
+	companyData, err := s.GetCompanyData(ctx, ticker)
+	if err != nil {
+		return "", err
+	}
+
+	if companyData.Image == "" {
+		return "", fmt.Errorf("[FinghubService] logo not found id: %s: %w", ticker, CustomClient.ErrNotFound)
+	}
+
+	return companyData.Image, nil
+}
+
+// GetLogo returns the logo of the profile of the ticker as a byte array
+func (s *FinghubService) GetLogo(ctx context.Context, ticker string) ([]byte, error) {
+	url, err := s.GetLogoUrl(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+
+	client := CustomClient.NewCustomClient(url)
+	logo, err := client.GetRaw(ctx, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("[FinghubService] failed to retrieve logo id: %s: %w", ticker, err)
+	}
+
+	return logo, nil
+}
+
+// getProfile retrieves the profile of the ticker, finnhub returns an empty profile for the unknown tickers
+func (s *FinghubService) getProfile(ctx context.Context, ticker string) (finnhubProfile, error) {
+	var profile finnhubProfile
+	if err := s.Client.Get(ctx, "/stock/profile2", map[string]string{"symbol": ticker, "token": s.Token}, &profile); err != nil {
+		return profile, fmt.Errorf("[FinghubService] failed to retrieve company data id: %s: %w", ticker, err)
+	}
+
+	if profile.Ticker == "" && profile.Name == "" {
+		return profile, fmt.Errorf("[FinghubService] company data not found id: %s: %w", ticker, CustomClient.ErrNotFound)
+	}
+
+	return profile, nil
+}
@@ -22,16 +22,22 @@ func TestNormalizeFinghubCacheExpiration(t *testing.T) {
 				News: 0,
 			},
 			expected: services.FinghubCacheExpiration{
-				News: 5 * time.Minute,
+				News:             5 * time.Minute,
+				HistoricalPrices: 10 * time.Minute,
+				CompanyData:      30 * time.Minute,
 			},
 		},
 		{
 			name: "custom values",
 			input: services.FinghubCacheExpiration{
-				News: 10 * time.Minute,
+				News:             10 * time.Minute,
+				HistoricalPrices: time.Minute,
+				CompanyData:      time.Hour,
 			},
 			expected: services.FinghubCacheExpiration{
-				News: 10 * time.Minute,
+				News:             10 * time.Minute,
+				HistoricalPrices: time.Minute,
+				CompanyData:      time.Hour,
 			},
 		},
 	}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"api/cache"
+	"api/indicators"
+	"api/models/responses"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// indicatorsCacheExpiration the indicators are recalculated with the new prices
+const indicatorsCacheExpiration = 15 * time.Minute
+
+// GetIndicators implements TickerService interface
+// GetIndicators calculates the technical indicators of the config over the historical prices
+// the prices before from needed by the periods are requested too, so the indicators
+// have values since the first date of the range
+func (s *tickerService) GetIndicators(ctx context.Context, ticker string, from time.Time, to time.Time, config indicators.Config) (responses.TickerIndicators, error) {
+	ticker = strings.ToUpper(ticker)
+
+	result := responses.TickerIndicators{Symbol: ticker}
+	if !from.IsZero() {
+		result.From = from.Format("2006-01-02")
+	}
+
+	if !to.IsZero() {
+		result.To = to.Format("2006-01-02")
+	}
+
+	key := fmt.Sprintf("Indicators:%s:%s:%s:%s", ticker, result.From, result.To, config.Key())
+
+	values, err := cache.GetOrLoad(ctx, s.cache, key, indicatorsCacheExpiration, func(ctx context.Context) (map[string]indicators.Indicator, error) {
+		fetchFrom := from
+		if !from.IsZero() {
+			// the lookback is in trading days, 5 of every 7 days plus holidays
+			fetchFrom = from.AddDate(0, 0, -(config.Lookback()*7/5 + 10))
+		}
+
+		prices, err := s.GetHistoricalPrices(ctx, ticker, fetchFrom, to)
+		if err != nil {
+			return nil, err
+		}
+
+		values := indicators.Compute(prices, config)
+		if !from.IsZero() {
+			for name, indicator := range values {
+				values[name] = indicator.Since(result.From)
+			}
+		}
+
+		return values, nil
+	})
+
+	if err != nil {
+		return result, fmt.Errorf("[TickerService] failed to calculate indicators of %s: %w", ticker, err)
+	}
+
+	result.Indicators = values
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"api/cache"
+	"api/models"
+	"api/models/filters"
+	"context"
+	"fmt"
+	"time"
+)
+
+// systemInstruction instruction of the advice and the predictions
+const systemInstruction = "You are a quantitative analyst. Provide objective analysis based on data, without speculation."
+
+// GenerateAdvice generates the advice of the stock with the provider
+// the stock predict is for the next 7 days
+//
+//	with a limit of 30 to analyze
+//
+// the request to the provider is cancelled with ctx and lasts 60 seconds at most
+func GenerateAdvice(ctx context.Context, provider LLMProvider, symbol string, historicalData []models.HistoricalPrice, daysToAnalyze int, c cache.ICache) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("[LLM] advice cancelled: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	if len(historicalData) == 0 {
+		return "UNKNOWN. We don't have enough data to generate advice", nil
+	}
+
+	key := fmt.Sprintf("LLM:%s:advice:%s-%s", provider.Name(), symbol, time.Now().Format("2006-01-02"))
+	expiration := 10 * time.Minute
+
+	result, err := cache.GetOrLoad(ctx, c, key, expiration, func(ctx context.Context) (advice string, err error) {
+		start := time.Now()
+		defer func() { observeRequest(provider.Name(), "advice", start, err) }()
+
+		if daysToAnalyze > 30 {
+			daysToAnalyze = 30
+		}
+
+		if daysToAnalyze < 1 {
+			daysToAnalyze = 7
+		}
+
+		return provider.GenerateText(ctx, Request{
+			Operation:      "advice",
+			System:         systemInstruction,
+			Prompt:         buildPromptAdvice(symbol, historicalData, daysToAnalyze),
+			Temperature:    0.2,
+			TopP:           0.7,
+			TopK:           30,
+			MaxTokens:      512, // short advice
+			ThinkingBudget: 30,  // answers fast
+		})
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+// GeneratePredict generates the predictions of the stock with the provider
+// the stock predict is for the next 7 days
+//
+//	with a limit of 30 days to analyze
+//	with a limit of 14 days to predict
+//
+// the request to the provider is cancelled with ctx and lasts 90 seconds at most
+func GeneratePredict(ctx context.Context, provider LLMProvider, symbol string, historicalData []models.HistoricalPrice, daysToAnalyze int, daysToPredict int, c cache.ICache) ([]models.HistoricalPrice, error) {
+	if err := ctx.Err(); err != nil {
+		return make([]models.HistoricalPrice, 0), fmt.Errorf("[LLM] predict cancelled: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+
+	key := fmt.Sprintf("LLM:%s:predict:%s-%s-%d", provider.Name(), symbol, time.Now().Format("2006-01-02"), daysToPredict)
+	expiration := 30 * time.Minute
+
+	result, err := cache.GetOrLoad(ctx, c, key, expiration, func(ctx context.Context) (historicalPredict []models.HistoricalPrice, err error) {
+		start := time.Now()
+		defer func() { observeRequest(provider.Name(), "predict", start, err) }()
+
+		if daysToPredict > 14 {
+			daysToPredict = 14
+		}
+
+		if daysToPredict < 7 {
+			daysToPredict = 7
+		}
+
+		if daysToAnalyze > 30 {
+			daysToAnalyze = 30
+		}
+
+		if daysToAnalyze < 7 {
+			daysToAnalyze = 7
+		}
+
+		var prediction struct {
+			StocksNextWeek []StockPredict `json:"stocksNextWeek"`
+		}
+
+		err = provider.GenerateJSON(ctx, Request{
+			Operation:      "predict",
+			System:         systemInstruction,
+			Prompt:         buildPredictPromp(symbol, historicalData[:], daysToAnalyze, daysToPredict),
+			Temperature:    0.2, // low for consistency
+			TopP:           0.7,
+			TopK:           30,
+			ThinkingBudget: 50, // answers fast
+		}, predictSchema, &prediction)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range prediction.StocksNextWeek {
+			historicalPredict = append(historicalPredict, models.HistoricalPrice{
+				Symbol:  symbol,
+				Date:    p.Date,
+				Open:    filters.TruncateFloat(p.Open, 2),
+				High:    filters.TruncateFloat(p.High, 2),
+				Low:     filters.TruncateFloat(p.Low, 2),
+				Close:   filters.TruncateFloat(p.Close, 2),
+				Volume:  filters.TruncateFloat(p.Volume, 0),
+				Change:  filters.TruncateFloat(p.Close-p.Open, 2),
+				ChangeP: filters.TruncateFloat((p.Close-p.Open)/p.Open, 5),
+				Vwap:    filters.TruncateFloat(p.Vwap, 4),
+			})
+		}
+
+		return historicalPredict, nil
+	})
+
+	if err != nil {
+		return make([]models.HistoricalPrice, 0), err
+	}
+
+	return result, nil
+}
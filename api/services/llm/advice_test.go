@@ -0,0 +1,251 @@
+package llm_test
+
+import (
+	"api/config"
+	"api/models"
+	"api/services/llm"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertAdvice checks the format [BEHAVIOR]. [JUSTIFICATION] of the advice
+func assertAdvice(t *testing.T, advice string) {
+	parts := strings.SplitN(advice, ".", 2)
+
+	assert.Equal(t, 2, len(parts), "Advice should have 2 parts")
+	assert.NotEmpty(t, parts[0], "Behavior should not be empty")
+	assert.NotEmpty(t, parts[1], "Justification should not be empty")
+	assert.Contains(t, []string{"BUY", "SELL", "HOLD"}, parts[0], "Behavior should be BUY, SELL or HOLD")
+}
+
+func TestGenerateAdvice(t *testing.T) {
+	provider := llm.NewFakeProvider()
+
+	advice, err := llm.GenerateAdvice(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, nil)
+	assert.NoError(t, err)
+	assertAdvice(t, advice)
+
+	requests := provider.Requests()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "advice", requests[0].Operation)
+	assert.Contains(t, requests[0].Prompt, "AAPL")
+	assert.NotEmpty(t, requests[0].System)
+}
+
+func TestGenerateAdviceWithoutData(t *testing.T) {
+	provider := llm.NewFakeProvider()
+
+	advice, err := llm.GenerateAdvice(context.Background(), provider, "AAPL", nil, 20, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(advice, "UNKNOWN."))
+	assert.Empty(t, provider.Requests())
+}
+
+func TestGeneratePredict(t *testing.T) {
+	provider := llm.NewFakeProvider()
+	provider.JSONs["predict"] = `{"stocksNextWeek": [
+		{"date": "2025-10-24", "open": 260, "close": 262.6, "high": 263, "low": 259, "volume": 40000000.4, "vwap": 261.12345},
+		{"date": "2025-10-27", "open": 262.6, "close": 261, "high": 264, "low": 260, "volume": 38000000, "vwap": 262}
+	]}`
+
+	predictions, err := llm.GeneratePredict(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.HistoricalPrice{
+		{Symbol: "AAPL", Date: "2025-10-24", Open: 260, High: 263, Low: 259, Close: 262.6, Volume: 40000000, Change: 2.6, ChangeP: 0.01, Vwap: 261.1234},
+		{Symbol: "AAPL", Date: "2025-10-27", Open: 262.6, High: 264, Low: 260, Close: 261, Volume: 38000000, Change: -1.6, ChangeP: -0.00609, Vwap: 262},
+	}, predictions)
+}
+
+func TestGeneratePredictOffline(t *testing.T) {
+	// the default response of the fake has no predictions, the controllers use the statistical model
+	predictions, err := llm.GeneratePredict(context.Background(), llm.NewFakeProvider(), "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, predictions)
+}
+
+func TestGenerateError(t *testing.T) {
+	provider := llm.NewFakeProvider()
+	provider.Err = errors.New("quota exceeded")
+
+	_, err := llm.GenerateAdvice(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, nil)
+	assert.ErrorIs(t, err, provider.Err)
+
+	_, err = llm.GeneratePredict(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.ErrorIs(t, err, provider.Err)
+}
+
+func TestGenerateAdviceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	provider := llm.NewFakeProvider()
+	advice, err := llm.GenerateAdvice(ctx, provider, "AAPL", getTestHistoricStock(), 20, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, advice)
+	assert.Empty(t, provider.Requests())
+}
+
+func TestGeneratePredictDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	provider := llm.NewFakeProvider()
+	predictions, err := llm.GeneratePredict(ctx, provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, predictions)
+	assert.Empty(t, provider.Requests())
+}
+
+func TestGeminiProvider(t *testing.T) {
+	_ = godotenv.Load("../../.env")
+	if config.GeminiAi().Token == "" {
+		t.Skip("GEMINI_API_KEY is required to call gemini")
+	}
+
+	provider := llm.NewGeminiProvider(config.GeminiAi().Token, "")
+
+	advice, err := llm.GenerateAdvice(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, nil)
+	assert.NoError(t, err)
+	assertAdvice(t, advice)
+
+	predictions, err := llm.GeneratePredict(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, predictions)
+}
+
+func getTestHistoricStock() []models.HistoricalPrice {
+	historicalData := []models.HistoricalPrice{
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-23",
+			Open:    259.89,
+			High:    260.6199,
+			Low:     258.0101,
+			Close:   259.58,
+			Volume:  32618794,
+			Change:  -0.31,
+			ChangeP: -0.11928123,
+			Vwap:    259.4,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-22",
+			Open:    262.65,
+			High:    262.85,
+			Low:     255.43,
+			Close:   258.45,
+			Volume:  45015300,
+			Change:  -4.2,
+			ChangeP: -1.6,
+			Vwap:    259.845,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-21",
+			Open:    261.88,
+			High:    265.29,
+			Low:     261.83,
+			Close:   262.77,
+			Volume:  46695948,
+			Change:  0.89,
+			ChangeP: 0.33985,
+			Vwap:    262.9425,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-20",
+			Open:    255.89,
+			High:    264.38,
+			Low:     255.63,
+			Close:   262.24,
+			Volume:  90483029,
+			Change:  6.36,
+			ChangeP: 2.48,
+			Vwap:    259.535,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-17",
+			Open:    248.02,
+			High:    253.38,
+			Low:     247.27,
+			Close:   252.29,
+			Volume:  49147000,
+			Change:  4.27,
+			ChangeP: 1.72,
+			Vwap:    250.24,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-16",
+			Open:    248.25,
+			High:    249.04,
+			Low:     245.13,
+			Close:   247.45,
+			Volume:  39777000,
+			Change:  -0.8,
+			ChangeP: -0.32226,
+			Vwap:    247.4675,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-15",
+			Open:    249.49,
+			High:    251.82,
+			Low:     247.47,
+			Close:   249.34,
+			Volume:  33893611,
+			Change:  -0.145,
+			ChangeP: -0.06012265,
+			Vwap:    249.53,
+		},
+		{
+			Symbol:  "AAPL",
+			Date:    "2025-10-14",
+			Open:    246.6,
+			High:    248.85,
+			Low:     244.7,
+			Close:   247.77,
+			Volume:  35478000,
+			Change:  1.17,
+			ChangeP: 0.47445,
+			Vwap:    246.98,
+		},
+	}
+
+	return historicalData
+}
+
+func TestGeneratePredictNewestDays(t *testing.T) {
+	// 60 days of prices from the oldest to the newest
+	var history []models.HistoricalPrice
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		history = append(history, models.HistoricalPrice{Symbol: "AAPL", Date: day.AddDate(0, 0, i).Format("2006-01-02"), Close: 100})
+	}
+
+	provider := llm.NewFakeProvider()
+	_, err := llm.GeneratePredict(context.Background(), provider, "AAPL", history, 20, 7, nil)
+	assert.NoError(t, err)
+
+	if !assert.Len(t, provider.Requests(), 1) {
+		return
+	}
+
+	// the prompt has the last 20 days, from 2025-02-10 to 2025-03-01
+	prompt := provider.Requests()[0].Prompt
+	assert.Contains(t, prompt, "2025-03-01:")
+	assert.Contains(t, prompt, "2025-02-10:")
+	assert.NotContains(t, prompt, "2025-02-09:")
+	assert.NotContains(t, prompt, "2025-01-01:")
+
+	// the prices of the caller keep their order
+	assert.Equal(t, "2025-01-01", history[0].Date)
+	assert.Equal(t, "2025-03-01", history[59].Date)
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// responses of the fake provider when it has none for the operation
+const (
+	FakeText = "HOLD. The advice is generated by the offline provider."
+	FakeJSON = "{}"
+)
+
+// FakeProvider deterministic provider for the tests and to run without network,
+// it responds the text or the JSON of the operation of the request and records the requests
+type FakeProvider struct {
+	Texts map[string]string
+	JSONs map[string]string
+	Err   error
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// NewFakeProvider creates a FakeProvider with the default responses,
+// the predictions are empty so the statistical model is used
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{Texts: map[string]string{}, JSONs: map[string]string{}}
+}
+
+func (p *FakeProvider) Name() string {
+	return FakeProviderName
+}
+
+// GenerateText returns the text of the operation of the request or FakeText
+func (p *FakeProvider) GenerateText(ctx context.Context, request Request) (string, error) {
+	if err := p.record(ctx, request); err != nil {
+		return "", err
+	}
+
+	if text, ok := p.Texts[request.Operation]; ok {
+		return text, nil
+	}
+
+	return FakeText, nil
+}
+
+// GenerateJSON unmarshals the JSON of the operation of the request or FakeJSON
+func (p *FakeProvider) GenerateJSON(ctx context.Context, request Request, schema Schema, result interface{}) error {
+	if err := p.record(ctx, request); err != nil {
+		return err
+	}
+
+	content, ok := p.JSONs[request.Operation]
+	if !ok {
+		content = FakeJSON
+	}
+
+	if err := json.Unmarshal([]byte(content), result); err != nil {
+		return fmt.Errorf("[FakeProvider] cannot unmarshal JSON: %s", content)
+	}
+
+	return nil
+}
+
+// Requests returns the requests received by the provider
+func (p *FakeProvider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Request(nil), p.requests...)
+}
+
+// record stores the request and returns the error of the provider or of the context
+func (p *FakeProvider) record(ctx context.Context, request Request) error {
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	p.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return p.Err
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"google.golang.org/genai"
+)
+
+// DefaultGeminiModel model of gemini when the configuration has none
+const DefaultGeminiModel = "gemini-2.5-flash"
+
+// GeminiProvider generates with the Gemini API, the client is created with the first request
+type GeminiProvider struct {
+	apiKey string
+	model  string
+	mu     sync.Mutex
+	client *genai.Client
+}
+
+// NewGeminiProvider creates a new GeminiProvider
+func NewGeminiProvider(apiKey string, model string) *GeminiProvider {
+	if model == "" {
+		model = DefaultGeminiModel
+	}
+
+	return &GeminiProvider{apiKey: apiKey, model: model}
+}
+
+func (p *GeminiProvider) Name() string {
+	return GeminiProviderName
+}
+
+// GenerateText returns the text of the response of gemini
+func (p *GeminiProvider) GenerateText(ctx context.Context, request Request) (string, error) {
+	result, err := p.generate(ctx, request, p.contentConfig(request))
+	if err != nil {
+		return "", err
+	}
+
+	return result.Text(), nil
+}
+
+// GenerateJSON unmarshals the response of gemini generated with the schema
+func (p *GeminiProvider) GenerateJSON(ctx context.Context, request Request, schema Schema, result interface{}) error {
+	contentConfig := p.contentConfig(request)
+	contentConfig.ResponseMIMEType = "application/json"
+	contentConfig.ResponseJsonSchema = schema
+
+	response, err := p.generate(ctx, request, contentConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(response.Text()), result); err != nil {
+		return fmt.Errorf("[GeminiProvider] cannot unmarshal JSON: %s", response.Text())
+	}
+
+	return nil
+}
+
+// generate sends the prompt of the request to the model
+func (p *GeminiProvider) generate(ctx context.Context, request Request, contentConfig *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	client, err := p.getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.Models.GenerateContent(ctx, p.model, genai.Text(request.Prompt), contentConfig)
+	if err != nil {
+		return nil, fmt.Errorf("[GeminiProvider] failed to generate content: %w", err)
+	}
+
+	return result, nil
+}
+
+// getClient returns the client of the Gemini API, it is created once
+func (p *GeminiProvider) getClient(ctx context.Context) (*genai.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  p.apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[GeminiProvider] failed to create client: %w", err)
+	}
+
+	p.client = client
+	return client, nil
+}
+
+// contentConfig returns the configuration of the model with the sampling of the request
+func (p *GeminiProvider) contentConfig(request Request) *genai.GenerateContentConfig {
+	contentConfig := &genai.GenerateContentConfig{
+		MaxOutputTokens: request.MaxTokens,
+	}
+
+	if request.System != "" {
+		contentConfig.SystemInstruction = genai.NewContentFromText(request.System, genai.RoleUser)
+	}
+
+	if request.Temperature > 0 {
+		contentConfig.Temperature = &request.Temperature
+	}
+
+	if request.TopP > 0 {
+		contentConfig.TopP = &request.TopP
+	}
+
+	if request.TopK > 0 {
+		contentConfig.TopK = &request.TopK
+	}
+
+	if request.ThinkingBudget > 0 {
+		contentConfig.ThinkingConfig = &genai.ThinkingConfig{ThinkingBudget: &request.ThinkingBudget}
+	}
+
+	return contentConfig
+}
@@ -0,0 +1,129 @@
+package llm
+
+import (
+	CustomClient "api/services/customClient"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultOpenAIModel model of the OpenAI-compatible server when the configuration has none
+const DefaultOpenAIModel = "llama3.1"
+
+// OpenAIProvider generates with the chat completions of an OpenAI-compatible server,
+// for example llama.cpp or Ollama running locally
+type OpenAIProvider struct {
+	Client CustomClient.CustomClient
+	Model  string
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIJSONSchema struct {
+	Name   string `json:"name"`
+	Schema Schema `json:"schema"`
+}
+
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type openAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    *float32              `json:"temperature,omitempty"`
+	TopP           *float32              `json:"top_p,omitempty"`
+	MaxTokens      int32                 `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIProvider creates a new OpenAIProvider, baseURL includes the version, example http://localhost:11434/v1
+// the api key is optional for the local servers
+func NewOpenAIProvider(baseURL string, apiKey string, model string) *OpenAIProvider {
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+
+	client := CustomClient.NewCustomClient(baseURL)
+	// a completion does not change anything in the server, the POST can be sent again
+	client.Policy.RetryNonIdempotent = true
+	if apiKey != "" {
+		client.SetAuthToken(apiKey)
+	}
+
+	return &OpenAIProvider{Client: client, Model: model}
+}
+
+func (p *OpenAIProvider) Name() string {
+	return OpenAIProviderName
+}
+
+// GenerateText returns the content of the first choice of the completion
+func (p *OpenAIProvider) GenerateText(ctx context.Context, request Request) (string, error) {
+	return p.complete(ctx, p.chatRequest(request, nil))
+}
+
+// GenerateJSON unmarshals the content of the completion generated with the schema
+func (p *OpenAIProvider) GenerateJSON(ctx context.Context, request Request, schema Schema, result interface{}) error {
+	content, err := p.complete(ctx, p.chatRequest(request, &openAIResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &openAIJSONSchema{Name: request.Operation, Schema: schema},
+	}))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(content), result); err != nil {
+		return fmt.Errorf("[OpenAIProvider] cannot unmarshal JSON: %s", content)
+	}
+
+	return nil
+}
+
+// complete sends the chat request and returns the content of the first choice
+func (p *OpenAIProvider) complete(ctx context.Context, chatRequest openAIChatRequest) (string, error) {
+	var response openAIChatResponse
+	if err := p.Client.Post(ctx, "/chat/completions", nil, chatRequest, &response); err != nil {
+		return "", fmt.Errorf("[OpenAIProvider] failed to generate content: %w", err)
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("[OpenAIProvider] the completion has no choices")
+	}
+
+	return response.Choices[0].Message.Content, nil
+}
+
+// chatRequest returns the chat request with the messages and the sampling of the request
+func (p *OpenAIProvider) chatRequest(request Request, format *openAIResponseFormat) openAIChatRequest {
+	chatRequest := openAIChatRequest{
+		Model:          p.Model,
+		MaxTokens:      request.MaxTokens,
+		ResponseFormat: format,
+	}
+
+	if request.System != "" {
+		chatRequest.Messages = append(chatRequest.Messages, openAIMessage{Role: "system", Content: request.System})
+	}
+	chatRequest.Messages = append(chatRequest.Messages, openAIMessage{Role: "user", Content: request.Prompt})
+
+	if request.Temperature > 0 {
+		chatRequest.Temperature = &request.Temperature
+	}
+
+	if request.TopP > 0 {
+		chatRequest.TopP = &request.TopP
+	}
+
+	return chatRequest
+}
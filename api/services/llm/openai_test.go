@@ -0,0 +1,103 @@
+package llm_test
+
+import (
+	"api/config"
+	"api/services/llm"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newOpenAIStandIn returns an OpenAI-compatible server that responds the content and stores the last request
+func newOpenAIStandIn(t *testing.T, content string, received *map[string]interface{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test_key", r.Header.Get("Authorization"))
+		json.NewDecoder(r.Body).Decode(received)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOpenAIProviderText(t *testing.T) {
+	var received map[string]interface{}
+	server := newOpenAIStandIn(t, "BUY. The trend is up.", &received)
+	provider := llm.NewOpenAIProvider(server.URL+"/v1", "test_key", "")
+
+	advice, err := llm.GenerateAdvice(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "BUY. The trend is up.", advice)
+
+	assert.Equal(t, llm.DefaultOpenAIModel, received["model"])
+	assert.Equal(t, float64(512), received["max_tokens"])
+	assert.Nil(t, received["response_format"])
+
+	messages := received["messages"].([]interface{})
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].(map[string]interface{})["role"])
+	assert.Equal(t, "user", messages[1].(map[string]interface{})["role"])
+}
+
+func TestOpenAIProviderJSON(t *testing.T) {
+	var received map[string]interface{}
+	server := newOpenAIStandIn(t, `{"stocksNextWeek": [{"date": "2025-10-24", "open": 260, "close": 262, "high": 263, "low": 259, "volume": 1000, "vwap": 261}]}`, &received)
+	provider := llm.NewOpenAIProvider(server.URL+"/v1", "test_key", "qwen2.5")
+
+	predictions, err := llm.GeneratePredict(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.NoError(t, err)
+	assert.Len(t, predictions, 1)
+	assert.Equal(t, 262.0, predictions[0].Close)
+
+	assert.Equal(t, "qwen2.5", received["model"])
+	format := received["response_format"].(map[string]interface{})
+	assert.Equal(t, "json_schema", format["type"])
+	assert.Equal(t, "predict", format["json_schema"].(map[string]interface{})["name"])
+	assert.NotNil(t, format["json_schema"].(map[string]interface{})["schema"])
+}
+
+func TestOpenAIProviderInvalidJSON(t *testing.T) {
+	var received map[string]interface{}
+	server := newOpenAIStandIn(t, "not json", &received)
+	provider := llm.NewOpenAIProvider(server.URL+"/v1", "test_key", "")
+
+	_, err := llm.GeneratePredict(context.Background(), provider, "AAPL", getTestHistoricStock(), 20, 7, nil)
+	assert.ErrorContains(t, err, "[OpenAIProvider] cannot unmarshal JSON")
+}
+
+func TestNewProvider(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		provider string
+		expected string
+		hasError bool
+	}{
+		{desc: "gemini", provider: "gemini", expected: llm.GeminiProviderName},
+		{desc: "openai", provider: "openai", expected: llm.OpenAIProviderName},
+		{desc: "fake", provider: "fake", expected: llm.FakeProviderName},
+		{desc: "unknown", provider: "claude", hasError: true},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			provider, err := llm.NewProvider(&config.LLMConfig{Provider: tC.provider, BaseURL: "http://localhost:11434/v1"})
+			if tC.hasError {
+				assert.ErrorIs(t, err, llm.ErrUnknownProvider)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, provider.Name())
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package geminiai
+package llm
 
 import (
 	"api/models"
@@ -92,13 +92,11 @@ func buildPredictPromp(symbol string, historicalData []models.HistoricalPrice, d
 
 // buildHistoricalDataString builds the historical data string
 // date format must be 2006-01-02
-// maxDays is the max number of days to analyze, the limit is 30
+// maxDays is the max number of days to analyze, the newest ones, the limit is 30
 func buildHistoricalDataString(symbol string, historicalData []models.HistoricalPrice, maxDays int) string {
 	var historicalStr strings.Builder
 	historicalStr.WriteString(fmt.Sprintf("Historical data of %s:\n", symbol))
 
-	start := 0
-
 	if len(historicalData) == 0 {
 		return ""
 	}
@@ -111,18 +109,20 @@ func buildHistoricalDataString(symbol string, historicalData []models.Historical
 		maxDays = 30
 	}
 
-	sort.Slice(historicalData, func(i, j int) bool {
-		dateI, _ := time.Parse("2006-01-02", historicalData[i].Date)
-		dateJ, _ := time.Parse("2006-01-02", historicalData[j].Date)
+	// the prices of the caller are not reordered, the newest days are the first ones
+	sorted := append([]models.HistoricalPrice(nil), historicalData...)
+	sort.Slice(sorted, func(i, j int) bool {
+		dateI, _ := time.Parse("2006-01-02", sorted[i].Date)
+		dateJ, _ := time.Parse("2006-01-02", sorted[j].Date)
 
 		return dateI.After(dateJ)
 	})
 
-	if len(historicalData) > maxDays {
-		start = len(historicalData) - maxDays
+	if len(sorted) > maxDays {
+		sorted = sorted[:maxDays]
 	}
 
-	historicalStr.WriteString(formatHistoricalData(historicalData[start:]))
+	historicalStr.WriteString(formatHistoricalData(sorted))
 
 	return historicalStr.String()
 }
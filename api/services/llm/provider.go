@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"api/config"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// names of the providers of the configuration
+const (
+	GeminiProviderName = "gemini"
+	OpenAIProviderName = "openai"
+	FakeProviderName   = "fake"
+)
+
+// ErrUnknownProvider is returned for the providers that are not gemini, openai or fake
+var ErrUnknownProvider = errors.New("unknown llm provider")
+
+// Schema JSON schema of the responses of GenerateJSON
+type Schema map[string]interface{}
+
+// Request prompt and sampling of a generation, the zero values use the defaults of the provider
+// Operation names the generation in the metrics, example advice or predict
+type Request struct {
+	Operation      string
+	System         string
+	Prompt         string
+	Temperature    float32
+	TopP           float32
+	TopK           float32
+	MaxTokens      int32
+	ThinkingBudget int32
+}
+
+// LLMProvider generates the text and the JSON of the prompts of the advice and the predictions
+type LLMProvider interface {
+	// Name of the provider, it is part of the cache keys
+	Name() string
+	// GenerateText returns the text generated for the request
+	GenerateText(ctx context.Context, request Request) (string, error)
+	// GenerateJSON unmarshals in result the JSON generated for the request with the schema
+	GenerateJSON(ctx context.Context, request Request, schema Schema, result interface{}) error
+}
+
+// NewProvider returns the provider of the configuration
+func NewProvider(cfg *config.LLMConfig) (LLMProvider, error) {
+	switch cfg.Provider {
+	case GeminiProviderName:
+		return NewGeminiProvider(config.GeminiAi().Token, cfg.Model), nil
+	case OpenAIProviderName:
+		return NewOpenAIProvider(cfg.BaseURL, cfg.APIKey, cfg.Model), nil
+	case FakeProviderName:
+		return NewFakeProvider(), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, cfg.Provider)
+}
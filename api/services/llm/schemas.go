@@ -0,0 +1,32 @@
+package llm
+
+type StockPredict struct {
+	Date   string  `json:"date"`
+	Open   float64 `json:"open"`
+	Close  float64 `json:"close"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Volume float64 `json:"volume"`
+	Vwap   float64 `json:"vwap"`
+}
+
+var predictSchema = Schema{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"stocksNextWeek": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"date":   map[string]interface{}{"type": "string", "description": "Trading date (YYYY-MM-DD)"},
+					"open":   map[string]interface{}{"type": "number", "description": "Predicted closing price"},
+					"close":  map[string]interface{}{"type": "number", "description": "Predicted closing price"},
+					"high":   map[string]interface{}{"type": "number", "description": "Predicted high price"},
+					"low":    map[string]interface{}{"type": "number", "description": "Predicted low price"},
+					"volume": map[string]interface{}{"type": "number", "description": "Predicted trading volume"},
+					"vwap":   map[string]interface{}{"type": "number", "description": "Predicted volume-weighted average price"},
+				},
+			},
+		},
+	},
+}
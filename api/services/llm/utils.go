@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"api/metrics"
+	"api/models"
+	"api/sanatizer"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func formatHistoricalData(data []models.HistoricalPrice) string {
+	var sb strings.Builder
+	for _, d := range data {
+		sb.WriteString(fmt.Sprintf("%s: O:%.2f C:%.2f H:%.2f L:%.2f V:%f\n",
+			sanatizer.SanatizerString(d.Date).SanatizedForLLM(10).String(),
+			d.Open,
+			d.Close,
+			d.High,
+			d.Low,
+			d.Volume,
+		))
+	}
+
+	return sb.String()
+}
+
+// observeRequest records the latency and the result of a call to the provider
+func observeRequest(provider string, operation string, start time.Time, err error) {
+	metrics.LLMRequestDuration.WithLabelValues(provider, operation).Observe(time.Since(start).Seconds())
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.LLMRequestsTotal.WithLabelValues(provider, operation, result).Inc()
+}
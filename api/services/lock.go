@@ -0,0 +1,78 @@
+package services
+
+import (
+	"api/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LockService handles the leases stored in the sync_locks table
+// used to run a job only in one replica at the same time
+type LockService struct {
+	db    *gorm.DB
+	owner string
+}
+
+// NewLockService creates a new LockService, the owner identifies this process
+func NewLockService(db *gorm.DB) *LockService {
+	return &LockService{
+		db:    db,
+		owner: newLockOwner(),
+	}
+}
+
+// TryLock takes the lock with the given name for ttl
+// returns false if other owner holds the lock and has not expired
+// if the lock is already held by this owner the lease is extended
+func (s *LockService) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	lock := models.SyncLock{
+		Name:      name,
+		Owner:     s.owner,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"owner", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "sync_locks.expires_at < ? OR sync_locks.owner = ?", Vars: []interface{}{now, s.owner}},
+		}},
+	}).Create(&lock)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("[LockService] failed to take lock %s: %w", name, result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Unlock releases the lock if it is held by this owner
+func (s *LockService) Unlock(ctx context.Context, name string) error {
+	err := s.db.WithContext(ctx).
+		Model(&models.SyncLock{}).
+		Where("name = ? AND owner = ?", name, s.owner).
+		Update("expires_at", time.Now().UTC()).Error
+
+	if err != nil {
+		return fmt.Errorf("[LockService] failed to release lock %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// newLockOwner builds an unique owner id with the hostname, pid and a random suffix
+func newLockOwner() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
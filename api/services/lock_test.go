@@ -0,0 +1,63 @@
+package services_test
+
+import (
+	"api/models"
+	"api/services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockServiceTryLock(t *testing.T) {
+	db := newTestDB(t, &models.SyncLock{})
+	ctx := context.Background()
+	first := services.NewLockService(db)
+	second := services.NewLockService(db)
+
+	locked, err := first.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked, "acquire a free lock")
+
+	locked, err = first.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked, "renew by the same owner")
+
+	locked, err = second.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, locked, "refuse another owner")
+
+	locked, err = second.TryLock(ctx, "other-job", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked, "the locks are independent by name")
+
+	assert.NoError(t, first.Unlock(ctx, "job"))
+	locked, err = second.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked, "acquire a released lock")
+}
+
+func TestLockServiceTryLockExpired(t *testing.T) {
+	db := newTestDB(t, &models.SyncLock{})
+	ctx := context.Background()
+	first := services.NewLockService(db)
+	second := services.NewLockService(db)
+
+	locked, err := first.TryLock(ctx, "job", 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	time.Sleep(20 * time.Millisecond)
+	locked, err = second.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, locked, "take over an expired lock")
+
+	locked, err = first.TryLock(ctx, "job", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, locked, "the previous owner lost the lock")
+
+	var lock models.SyncLock
+	assert.NoError(t, db.Where("name = ?", "job").First(&lock).Error)
+	assert.True(t, lock.ExpiresAt.After(time.Now().Add(30*time.Second)))
+}
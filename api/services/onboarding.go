@@ -3,11 +3,19 @@ package services
 import (
 	"api/models"
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInvalidTour is returned when the name of the tour has an invalid format
+var ErrInvalidTour = errors.New("invalid tour name: must have between 1 and 50 characters a-z, 0-9, - or _")
+
+var tourNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
 type OnboardingService struct {
 	db *gorm.DB
 }
@@ -18,12 +26,29 @@ func NewOnboardingService(db *gorm.DB) OnboardingService {
 	}
 }
 
-// get the onboarding data
+// get the onboarding data of a tour for the client
 //
-// in this case we only have a one data
-func (s *OnboardingService) GetOnboarding(ctx context.Context) (*models.Onboarding, error) {
+// if the client never started the tour it is created in the first step, the unique index of
+// the client and the tour keeps one row when the first requests of the client are concurrent
+func (s *OnboardingService) GetOnboarding(ctx context.Context, clientID string, tour string) (*models.Onboarding, error) {
+	if !tourNameRegex.MatchString(tour) {
+		return nil, ErrInvalidTour
+	}
+
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}, {Name: "tour"}},
+			DoNothing: true,
+		}).
+		Create(&models.Onboarding{ClientID: clientID, Tour: tour, Step: 1}).Error
+	if err != nil {
+		return nil, fmt.Errorf("[OnboardingService] failed to create the onboarding: %w", err)
+	}
+
 	var onboarding models.Onboarding
-	err := s.db.WithContext(ctx).FirstOrCreate(&onboarding, models.Onboarding{ID: 1}).Error
+	err = s.db.WithContext(ctx).
+		Where("client_id = ? AND tour = ?", clientID, tour).
+		First(&onboarding).Error
 	if err != nil {
 		return nil, fmt.Errorf("[OnboardingService] failed to get the onboarding: %w", err)
 	}
@@ -31,23 +56,42 @@ func (s *OnboardingService) GetOnboarding(ctx context.Context) (*models.Onboardi
 	return &onboarding, nil
 }
 
-// update the onboarding data
-//
-// in this case we only have a one data to update
-func (s *OnboardingService) UpdateOnboarding(ctx context.Context, step int, done bool) (*models.Onboarding, error) {
+// get the onboarding data of all the tours started by the client
+func (s *OnboardingService) GetOnboardings(ctx context.Context, clientID string) ([]models.Onboarding, error) {
+	var onboardings []models.Onboarding
+	err := s.db.WithContext(ctx).
+		Where("client_id = ?", clientID).
+		Order("tour asc").
+		Find(&onboardings).Error
+	if err != nil {
+		return nil, fmt.Errorf("[OnboardingService] failed to get the onboardings: %w", err)
+	}
 
-	var onboarding models.Onboarding
-	err := s.db.WithContext(ctx).FirstOrCreate(&onboarding, models.Onboarding{ID: 1}).Error
+	return onboardings, nil
+}
+
+// update the onboarding data of a tour for the client
+func (s *OnboardingService) UpdateOnboarding(ctx context.Context, clientID string, tour string, step int, done bool) (*models.Onboarding, error) {
+	onboarding, err := s.GetOnboarding(ctx, clientID, tour)
 	if err != nil {
-		return nil, fmt.Errorf("[OnboardingService] The onboarding with ID %d was not found: %w", 1, err)
+		return nil, err
 	}
 
-	onboarding.OverviewStep = step
-	onboarding.OverviewDone = done
-	err = s.db.WithContext(ctx).Save(&onboarding).Error
+	if step < 1 {
+		step = 1
+	}
+
+	onboarding.Step = step
+	onboarding.Done = done
+	err = s.db.WithContext(ctx).Save(onboarding).Error
 	if err != nil {
 		return nil, fmt.Errorf("[OnboardingService] failed to update onboarding: %w", err)
 	}
 
-	return &onboarding, nil
+	return onboarding, nil
+}
+
+// reset the onboarding data of a tour for the client to the first step
+func (s *OnboardingService) ResetOnboarding(ctx context.Context, clientID string, tour string) (*models.Onboarding, error) {
+	return s.UpdateOnboarding(ctx, clientID, tour, 1, false)
 }
@@ -0,0 +1,106 @@
+package services_test
+
+import (
+	"api/models"
+	"api/services"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestOnboardingServiceClients(t *testing.T) {
+	service := services.NewOnboardingService(newTestDB(t, &models.Onboarding{}))
+	ctx := context.Background()
+
+	_, err := service.UpdateOnboarding(ctx, "first", models.OverviewTour, 3, true)
+	assert.NoError(t, err)
+
+	// the progress of a client does not change the tours of other clients
+	onboarding, err := service.GetOnboarding(ctx, "second", models.OverviewTour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, onboarding.Step)
+	assert.False(t, onboarding.Done)
+
+	onboarding, err = service.GetOnboarding(ctx, "first", models.OverviewTour)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, onboarding.Step)
+	assert.True(t, onboarding.Done)
+}
+
+func TestOnboardingServiceTours(t *testing.T) {
+	service := services.NewOnboardingService(newTestDB(t, &models.Onboarding{}))
+	ctx := context.Background()
+
+	_, err := service.UpdateOnboarding(ctx, "client", "portfolio", 2, false)
+	assert.NoError(t, err)
+	_, err = service.UpdateOnboarding(ctx, "client", "alerts", 4, true)
+	assert.NoError(t, err)
+	_, err = service.UpdateOnboarding(ctx, "other", "screener", 1, false)
+	assert.NoError(t, err)
+
+	onboardings, err := service.GetOnboardings(ctx, "client")
+	assert.NoError(t, err)
+	if assert.Len(t, onboardings, 2) {
+		assert.Equal(t, "alerts", onboardings[0].Tour)
+		assert.Equal(t, 4, onboardings[0].Step)
+		assert.Equal(t, "portfolio", onboardings[1].Tour)
+		assert.Equal(t, 2, onboardings[1].Step)
+	}
+
+	_, err = service.GetOnboarding(ctx, "client", "Invalid Tour")
+	assert.ErrorIs(t, err, services.ErrInvalidTour)
+}
+
+func TestOnboardingServiceReset(t *testing.T) {
+	service := services.NewOnboardingService(newTestDB(t, &models.Onboarding{}))
+	ctx := context.Background()
+
+	_, err := service.UpdateOnboarding(ctx, "client", "portfolio", 5, true)
+	assert.NoError(t, err)
+
+	onboarding, err := service.ResetOnboarding(ctx, "client", "portfolio")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, onboarding.Step)
+	assert.False(t, onboarding.Done)
+
+	onboarding, err = service.GetOnboarding(ctx, "client", "portfolio")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, onboarding.Step)
+	assert.False(t, onboarding.Done)
+}
+
+func TestOnboardingServiceConcurrentFirstRequests(t *testing.T) {
+	db := newTestDB(t, &models.Onboarding{})
+	service := services.NewOnboardingService(db)
+	// the requests read the missing tour at the same time before creating it
+	err := db.Callback().Query().After("gorm:query").Register("test:delay", func(*gorm.DB) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.GetOnboarding(context.Background(), "client", models.OverviewTour)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// the first request of the client keeps its progress
+	_, err = service.UpdateOnboarding(context.Background(), "client", models.OverviewTour, 2, false)
+	assert.NoError(t, err)
+	onboarding, err := service.GetOnboarding(context.Background(), "client", models.OverviewTour)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, onboarding.Step)
+
+	var rows int64
+	assert.NoError(t, db.Model(&models.Onboarding{}).Count(&rows).Error)
+	assert.Equal(t, int64(1), rows)
+}
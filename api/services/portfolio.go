@@ -0,0 +1,454 @@
+package services
+
+import (
+	"api/cache"
+	apilogger "api/logger"
+	"api/models"
+	"api/models/ratings"
+	"api/portfolio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidTransaction is returned when a transaction is not valid or leaves a sell without shares
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// riskCacheExpiration the risk is recalculated with the new daily prices
+const riskCacheExpiration = time.Hour
+
+// PortfolioService handles the portfolios of the users and values their positions
+// all the operations are scoped to the owner of the portfolio
+type PortfolioService struct {
+	db          *gorm.DB
+	companyData CompanyDataService
+	prices      HistoricalPriceService
+	cache       cache.ICache
+}
+
+// NewPortfolioService creates a new PortfolioService
+// companyData is used to retrieve the price and the sector of the tickers and prices the series of the risk
+func NewPortfolioService(db *gorm.DB, companyData CompanyDataService, prices HistoricalPriceService, cache cache.ICache) PortfolioService {
+	return PortfolioService{
+		db:          db,
+		companyData: companyData,
+		prices:      prices,
+		cache:       cache,
+	}
+}
+
+// GetPortfolios retrieves the portfolios of the owner without their transactions
+func (s *PortfolioService) GetPortfolios(ctx context.Context, owner string) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	err := s.db.WithContext(ctx).
+		Where("owner = ?", owner).
+		Order("name asc").
+		Find(&portfolios).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to retrieve portfolios: %w", err)
+	}
+
+	return portfolios, nil
+}
+
+// GetPortfolio retrieves a portfolio of the owner with its transactions in the order they are applied
+func (s *PortfolioService) GetPortfolio(ctx context.Context, owner string, id uint) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := s.db.WithContext(ctx).
+		Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("date asc").Order("id asc")
+		}).
+		Where("id = ? AND owner = ?", id, owner).
+		First(&portfolio).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to retrieve portfolio by id: %d: %w", id, err)
+	}
+
+	return &portfolio, nil
+}
+
+// CreatePortfolio creates an empty portfolio for the owner
+func (s *PortfolioService) CreatePortfolio(ctx context.Context, owner string, name string) (*models.Portfolio, error) {
+	portfolio := models.Portfolio{
+		Owner:        owner,
+		Name:         name,
+		Transactions: []models.Transaction{},
+	}
+
+	if err := s.db.WithContext(ctx).Create(&portfolio).Error; err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to create portfolio: %w", err)
+	}
+
+	return &portfolio, nil
+}
+
+// UpdatePortfolio renames a portfolio of the owner
+func (s *PortfolioService) UpdatePortfolio(ctx context.Context, owner string, id uint, name string) (*models.Portfolio, error) {
+	portfolio, err := s.GetPortfolio(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio.Name = name
+	if err := s.db.WithContext(ctx).Model(portfolio).Update("name", name).Error; err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to update portfolio: %w", err)
+	}
+
+	return portfolio, nil
+}
+
+// DeletePortfolio deletes a portfolio of the owner and its transactions
+func (s *PortfolioService) DeletePortfolio(ctx context.Context, owner string, id uint) error {
+	if _, err := s.GetPortfolio(ctx, owner, id); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("portfolio_id = ?", id).Delete(&models.Transaction{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND owner = ?", id, owner).Delete(&models.Portfolio{}).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("[PortfolioService] failed to delete portfolio: %w", err)
+	}
+
+	return nil
+}
+
+// AddTransaction adds a buy or a sell to a portfolio of the owner, the ticker must exist
+// returns ErrInvalidTransaction if a sell of the portfolio would have more shares than the open lots
+func (s *PortfolioService) AddTransaction(ctx context.Context, owner string, id uint, transaction models.Transaction) (*models.Transaction, error) {
+	transaction, err := validateTransaction(transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := findUnknownTickers(ctx, s.db, []string{transaction.TickerID}); err != nil {
+		if errors.Is(err, ErrUnknownTickers) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("[PortfolioService] failed to validate ticker: %w", err)
+	}
+
+	transaction.ID = 0
+	transaction.PortfolioID = id
+	transaction.Ticker = nil
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		portfolio, err := lockPortfolio(tx, owner, id)
+		if err != nil {
+			return err
+		}
+
+		// the sells after the date of the transaction must still have shares
+		if err := checkTransactions(append(portfolio.Transactions, transaction)); err != nil {
+			return err
+		}
+
+		return tx.Create(&transaction).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidTransaction) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("[PortfolioService] failed to add transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+// DeleteTransaction deletes a transaction of a portfolio of the owner
+// returns ErrInvalidTransaction if a later sell would have more shares than the open lots
+func (s *PortfolioService) DeleteTransaction(ctx context.Context, owner string, id uint, transactionID uint) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		portfolio, err := lockPortfolio(tx, owner, id)
+		if err != nil {
+			return err
+		}
+
+		remaining := make([]models.Transaction, 0, len(portfolio.Transactions))
+		found := false
+		for _, transaction := range portfolio.Transactions {
+			if transaction.ID == transactionID {
+				found = true
+				continue
+			}
+			remaining = append(remaining, transaction)
+		}
+
+		if !found {
+			return fmt.Errorf("[PortfolioService] failed to retrieve transaction by id: %d: %w", transactionID, gorm.ErrRecordNotFound)
+		}
+
+		if err := checkTransactions(remaining); err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND portfolio_id = ?", transactionID, id).Delete(&models.Transaction{}).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidTransaction) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		return fmt.Errorf("[PortfolioService] failed to delete transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockPortfolio retrieves the portfolio of the owner with its transactions and locks its row until the end of tx,
+// the changes of the transactions of a portfolio are serialized so each one is checked with the latest transactions
+func lockPortfolio(tx *gorm.DB, owner string, id uint) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND owner = ?", id, owner).
+		First(&portfolio).Error
+	if err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to retrieve portfolio by id: %d: %w", id, err)
+	}
+
+	err = tx.Where("portfolio_id = ?", id).
+		Order("date asc").
+		Order("id asc").
+		Find(&portfolio.Transactions).Error
+	if err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to retrieve transactions of portfolio: %d: %w", id, err)
+	}
+
+	return &portfolio, nil
+}
+
+// GetSummary values the positions of a portfolio of the owner with the current prices
+// the positions include the sentiment of the ratings of their tickers with the mode
+// the failures of the company data are logged and the position is listed as unpriced
+func (s *PortfolioService) GetSummary(ctx context.Context, owner string, id uint, mode ratings.SentimentMode) (portfolio.Summary, error) {
+	record, err := s.GetPortfolio(ctx, owner, id)
+	if err != nil {
+		return portfolio.Summary{}, err
+	}
+
+	positions, err := portfolio.BuildPositions(record.Transactions)
+	if err != nil {
+		return portfolio.Summary{}, fmt.Errorf("[PortfolioService] failed to build positions: %w", err)
+	}
+
+	quotes := s.loadQuotes(ctx, positions)
+	summary := portfolio.Summarize(positions, quotes)
+
+	if err := s.fillSentiment(ctx, summary.Positions, mode); err != nil {
+		return portfolio.Summary{}, err
+	}
+
+	return summary, nil
+}
+
+// GetRisk calculates the volatility, beta, correlation and 1-day VaR of the open positions of a portfolio of the owner
+// with the daily prices of the last lookback days, the result is cached by the open quantities of the portfolio
+// returns portfolio.ErrInsufficientData if the positions do not have enough prices in common
+func (s *PortfolioService) GetRisk(ctx context.Context, owner string, id uint, benchmark string, confidence float64, lookbackDays int) (portfolio.Risk, error) {
+	record, err := s.GetPortfolio(ctx, owner, id)
+	if err != nil {
+		return portfolio.Risk{}, err
+	}
+
+	positions, err := portfolio.BuildPositions(record.Transactions)
+	if err != nil {
+		return portfolio.Risk{}, fmt.Errorf("[PortfolioService] failed to build positions: %w", err)
+	}
+
+	holdings := make(map[string]float64)
+	var fingerprint strings.Builder
+	for _, position := range positions {
+		if position.Quantity > 0 {
+			holdings[position.Ticker] = position.Quantity
+			fmt.Fprintf(&fingerprint, "%s=%g;", position.Ticker, position.Quantity)
+		}
+	}
+
+	if len(holdings) == 0 {
+		return portfolio.Risk{}, fmt.Errorf("%w: the portfolio has no open positions", portfolio.ErrInsufficientData)
+	}
+
+	benchmark = strings.ToUpper(strings.TrimSpace(benchmark))
+	to := time.Now()
+	from := to.AddDate(0, 0, -lookbackDays)
+
+	hash := sha256.Sum256([]byte(fingerprint.String()))
+	key := fmt.Sprintf("PortfolioService:risk:%d:%s:%s:%g:%d:%s", id, hex.EncodeToString(hash[:8]), benchmark, confidence, lookbackDays, to.Format("2006-01-02"))
+
+	return cache.GetOrLoad(ctx, s.cache, key, riskCacheExpiration, func(ctx context.Context) (portfolio.Risk, error) {
+		prices, err := s.loadPrices(ctx, append(mapKeys(holdings), benchmark), from, to)
+		if err != nil {
+			return portfolio.Risk{}, err
+		}
+
+		return portfolio.CalculateRisk(holdings, prices, benchmark, confidence)
+	})
+}
+
+// loadPrices retrieves the daily prices of the tickers, the failures are logged and the ticker has no prices
+func (s *PortfolioService) loadPrices(ctx context.Context, tickers []string, from time.Time, to time.Time) (map[string][]models.HistoricalPrice, error) {
+	var mu sync.Mutex
+	prices := make(map[string][]models.HistoricalPrice, len(tickers))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	for _, ticker := range tickers {
+		group.Go(func() error {
+			series, err := s.prices.GetHistoricalPrices(groupCtx, ticker, from, to)
+			if err != nil {
+				if groupCtx.Err() != nil {
+					return groupCtx.Err()
+				}
+
+				apilogger.Logger().Warn().Err(err).Msg("[PortfolioService] failed to retrieve historical prices of " + ticker)
+				return nil
+			}
+
+			mu.Lock()
+			prices[ticker] = series
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, fmt.Errorf("[PortfolioService] failed to retrieve historical prices: %w", err)
+	}
+
+	return prices, nil
+}
+
+// loadQuotes retrieves the price and the sector of the tickers of the positions
+// the closed positions only need the sector, it is retrieved too so they are grouped with the same data
+func (s *PortfolioService) loadQuotes(ctx context.Context, positions []portfolio.Position) map[string]portfolio.Quote {
+	var mu sync.Mutex
+	quotes := make(map[string]portfolio.Quote, len(positions))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	for _, position := range positions {
+		ticker := position.Ticker
+		group.Go(func() error {
+			company, err := s.companyData.GetCompanyData(groupCtx, ticker)
+			if err != nil {
+				apilogger.Logger().Warn().Err(err).Msg("[PortfolioService] failed to retrieve company data of " + ticker)
+				return nil
+			}
+
+			mu.Lock()
+			quotes[ticker] = portfolio.Quote{Price: company.Price, Sector: company.Sector}
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	group.Wait()
+	return quotes
+}
+
+// fillSentiment calculates the sentiment of the tickers of the positions with their recommendations
+func (s *PortfolioService) fillSentiment(ctx context.Context, positions []portfolio.Position, mode ratings.SentimentMode) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(positions))
+	for i, position := range positions {
+		ids[i] = position.Ticker
+	}
+
+	var tickers []models.Ticker
+	err := s.db.WithContext(ctx).
+		Preload("Recommendations").
+		Where("id IN ?", ids).
+		Find(&tickers).Error
+	if err != nil {
+		return fmt.Errorf("[PortfolioService] failed to retrieve recommendations: %w", err)
+	}
+
+	if err := calculateSentiment(ctx, s.db, tickers, mode); err != nil {
+		return fmt.Errorf("[PortfolioService] failed to calculate sentiment: %w", err)
+	}
+
+	sentiments := make(map[string]ratings.SentimentScore, len(tickers))
+	for _, ticker := range tickers {
+		sentiments[string(ticker.ID)] = ticker.SentimentScore
+	}
+
+	for i := range positions {
+		positions[i].Sentiment = sentiments[positions[i].Ticker]
+	}
+
+	return nil
+}
+
+// mapKeys returns the keys of the map sorted
+func mapKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// validateTransaction normalizes the ticker and checks the fields of the transaction
+func validateTransaction(transaction models.Transaction) (models.Transaction, error) {
+	transaction.TickerID = strings.ToUpper(strings.TrimSpace(transaction.TickerID))
+	transaction.Type = models.TransactionType(strings.ToLower(string(transaction.Type)))
+
+	switch {
+	case transaction.TickerID == "":
+		return transaction, fmt.Errorf("%w: the ticker is required", ErrInvalidTransaction)
+	case !transaction.Type.IsValid():
+		return transaction, fmt.Errorf("%w: the type must be buy or sell", ErrInvalidTransaction)
+	case transaction.Quantity <= 0:
+		return transaction, fmt.Errorf("%w: the quantity must be positive", ErrInvalidTransaction)
+	case transaction.Price <= 0:
+		return transaction, fmt.Errorf("%w: the price must be positive", ErrInvalidTransaction)
+	case transaction.Fees < 0:
+		return transaction, fmt.Errorf("%w: the fees can not be negative", ErrInvalidTransaction)
+	case transaction.Date.IsZero():
+		return transaction, fmt.Errorf("%w: the date is required", ErrInvalidTransaction)
+	case transaction.Date.After(time.Now()):
+		return transaction, fmt.Errorf("%w: the date can not be in the future", ErrInvalidTransaction)
+	}
+
+	return transaction, nil
+}
+
+// checkTransactions checks that every sell of the transactions has shares in the open lots
+func checkTransactions(transactions []models.Transaction) error {
+	if _, err := portfolio.BuildPositions(transactions); err != nil {
+		if errors.Is(err, portfolio.ErrInsufficientQuantity) {
+			return fmt.Errorf("%w: %s", ErrInvalidTransaction, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
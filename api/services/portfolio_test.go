@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"api/models"
+	"api/services"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newTestPortfolio(t *testing.T, shares float64) (*services.PortfolioService, *models.Portfolio, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t, &models.Ticker{}, &models.Portfolio{}, &models.Transaction{})
+	assert.NoError(t, db.Create(&models.Ticker{ID: "AAPL", Company: "Apple Inc."}).Error)
+
+	service := services.NewPortfolioService(db, nil, nil, nil)
+	portfolio, err := service.CreatePortfolio(context.Background(), "client-a", "main")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	_, err = service.AddTransaction(context.Background(), "client-a", portfolio.ID, models.Transaction{
+		TickerID: "aapl",
+		Type:     models.BuyTransaction,
+		Quantity: shares,
+		Price:    100,
+		Date:     time.Now().AddDate(0, 0, -10),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return &service, portfolio, db
+}
+
+func TestPortfolioServiceRejectsConcurrentOversell(t *testing.T) {
+	service, portfolio, db := newTestPortfolio(t, 10)
+	// the sells read the transactions at the same time without the lock
+	err := db.Callback().Query().After("gorm:query").Register("test:delay", func(*gorm.DB) {
+		time.Sleep(10 * time.Millisecond)
+	})
+	assert.NoError(t, err)
+
+	const sells = 5
+	errs := make([]error, sells)
+	var wg sync.WaitGroup
+	for i := 0; i < sells; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.AddTransaction(context.Background(), "client-a", portfolio.ID, models.Transaction{
+				TickerID: "AAPL",
+				Type:     models.SellTransaction,
+				Quantity: 10,
+				Price:    120,
+				Date:     time.Now().AddDate(0, 0, -1),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, services.ErrInvalidTransaction)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	saved, err := service.GetPortfolio(context.Background(), "client-a", portfolio.ID)
+	assert.NoError(t, err)
+	assert.Len(t, saved.Transactions, 2)
+}
+
+func TestPortfolioServiceDeleteTransaction(t *testing.T) {
+	service, portfolio, _ := newTestPortfolio(t, 10)
+	ctx := context.Background()
+
+	sell, err := service.AddTransaction(ctx, "client-a", portfolio.ID, models.Transaction{
+		TickerID: "AAPL",
+		Type:     models.SellTransaction,
+		Quantity: 5,
+		Price:    120,
+		Date:     time.Now().AddDate(0, 0, -1),
+	})
+	assert.NoError(t, err)
+
+	saved, err := service.GetPortfolio(ctx, "client-a", portfolio.ID)
+	assert.NoError(t, err)
+	buyID := saved.Transactions[0].ID
+
+	// the sell would have no shares without the buy
+	assert.ErrorIs(t, service.DeleteTransaction(ctx, "client-a", portfolio.ID, buyID), services.ErrInvalidTransaction)
+	// the transactions of other owners are not found
+	assert.Error(t, service.DeleteTransaction(ctx, "client-b", portfolio.ID, sell.ID))
+
+	assert.NoError(t, service.DeleteTransaction(ctx, "client-a", portfolio.ID, sell.ID))
+	assert.NoError(t, service.DeleteTransaction(ctx, "client-a", portfolio.ID, buyID))
+
+	saved, err = service.GetPortfolio(ctx, "client-a", portfolio.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, saved.Transactions)
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"api/forecast"
+	"api/models"
+	"api/models/responses"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PredictionScoreResult summary of a scoring run
+type PredictionScoreResult struct {
+	Sets   int64
+	Points int64
+	Scored int64
+}
+
+// PredictionService stores the generated predictions and scores them against the real prices
+type PredictionService struct {
+	db     *gorm.DB
+	prices HistoricalPriceService
+}
+
+// NewPredictionService creates a new PredictionService
+// prices is used to retrieve the real prices of the predicted dates
+func NewPredictionService(db *gorm.DB, prices HistoricalPriceService) PredictionService {
+	return PredictionService{
+		db:     db,
+		prices: prices,
+	}
+}
+
+// SavePredictions stores the predictions generated by the model
+// history are the real prices used to generate them, the newest is the base of the set
+// if the set of the ticker, model and horizon was already saved today it is ignored
+func (s *PredictionService) SavePredictions(ctx context.Context, ticker string, model string, history []models.HistoricalPrice, predicts []models.HistoricalPrice) error {
+	if len(predicts) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	set := models.PredictionSet{
+		TickerID:    strings.ToUpper(ticker),
+		Model:       model,
+		GeneratedOn: now.Format("2006-01-02"),
+		Horizon:     len(predicts),
+		GeneratedAt: now,
+	}
+
+	for _, price := range history {
+		if price.Date > set.BaseDate {
+			set.BaseDate = price.Date
+			set.BaseClose = price.Close
+		}
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit("Points").Create(&set)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		points := make([]models.PredictionPoint, 0, len(predicts))
+		for _, predict := range predicts {
+			points = append(points, models.PredictionPoint{
+				PredictionSetID: set.ID,
+				Date:            predict.Date,
+				PredictedClose:  predict.Close,
+			})
+		}
+
+		return tx.Create(&points).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("[PredictionService] failed to save predictions of %s: %w", ticker, err)
+	}
+
+	return nil
+}
+
+// ScorePredictions joins the predictions of the past dates with the real closes
+// the dates without price older than the newest real price are scored without close (no trading day),
+// the dates without price yet are kept to the next run
+func (s *PredictionService) ScorePredictions(ctx context.Context) (PredictionScoreResult, error) {
+	var result PredictionScoreResult
+	today := time.Now().UTC().Format("2006-01-02")
+
+	var sets []models.PredictionSet
+	err := s.db.WithContext(ctx).
+		Where("id IN (?)", s.db.Model(&models.PredictionPoint{}).
+			Select("prediction_set_id").
+			Where("scored = ? AND date < ?", false, today)).
+		Preload("Points", "scored = ? AND date < ?", false, today).
+		Find(&sets).Error
+	if err != nil {
+		return result, fmt.Errorf("[PredictionService] failed to retrieve pending predictions: %w", err)
+	}
+
+	setsByTicker := make(map[string][]models.PredictionSet)
+	for _, set := range sets {
+		setsByTicker[set.TickerID] = append(setsByTicker[set.TickerID], set)
+	}
+
+	for ticker, tickerSets := range setsByTicker {
+		from := today
+		for _, set := range tickerSets {
+			for _, point := range set.Points {
+				if point.Date < from {
+					from = point.Date
+				}
+			}
+		}
+
+		fromTime, _ := time.Parse("2006-01-02", from)
+		prices, err := s.prices.GetHistoricalPrices(ctx, ticker, fromTime, time.Now().UTC())
+		if err != nil {
+			return result, fmt.Errorf("[PredictionService] failed to retrieve prices of %s: %w", ticker, err)
+		}
+
+		closes := make(map[string]float64, len(prices))
+		newest := ""
+		for _, price := range prices {
+			closes[price.Date] = price.Close
+			if price.Date > newest {
+				newest = price.Date
+			}
+		}
+
+		for _, set := range tickerSets {
+			result.Sets++
+			for _, point := range set.Points {
+				result.Points++
+
+				actual, ok := closes[point.Date]
+				if !ok && point.Date >= newest {
+					continue
+				}
+
+				updates := map[string]interface{}{"scored": true, "actual_close": nil}
+				if ok {
+					updates["actual_close"] = actual
+				}
+
+				if err := s.db.WithContext(ctx).Model(&models.PredictionPoint{}).Where("id = ?", point.ID).Updates(updates).Error; err != nil {
+					return result, fmt.Errorf("[PredictionService] failed to score prediction %d: %w", point.ID, err)
+				}
+				result.Scored++
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// GetAccuracy calculates the accuracy of the scored predictions of the ticker per model
+// if model is empty all the models are returned
+func (s *PredictionService) GetAccuracy(ctx context.Context, ticker string, model string) ([]responses.PredictionAccuracy, error) {
+	query := s.db.WithContext(ctx).
+		Where("ticker_id = ?", strings.ToUpper(ticker)).
+		Preload("Points", func(db *gorm.DB) *gorm.DB {
+			return db.Where("scored = ?", true).Order("date asc")
+		}).
+		Order("generated_at asc")
+
+	if model != "" {
+		query = query.Where("model = ?", model)
+	}
+
+	var sets []models.PredictionSet
+	if err := query.Find(&sets).Error; err != nil {
+		return nil, fmt.Errorf("[PredictionService] failed to retrieve predictions of %s: %w", ticker, err)
+	}
+
+	observations := make(map[string][]forecast.Observation)
+	setsCount := make(map[string]int)
+	for _, set := range sets {
+		setObservations := PredictionObservations(set)
+		if len(setObservations) == 0 {
+			continue
+		}
+
+		observations[set.Model] = append(observations[set.Model], setObservations...)
+		setsCount[set.Model]++
+	}
+
+	accuracies := make([]responses.PredictionAccuracy, 0, len(observations))
+	for model, modelObservations := range observations {
+		accuracies = append(accuracies, responses.PredictionAccuracy{
+			Model:    model,
+			Sets:     setsCount[model],
+			Accuracy: forecast.Evaluate(modelObservations),
+		})
+	}
+
+	sort.Slice(accuracies, func(i, j int) bool { return accuracies[i].Model < accuracies[j].Model })
+	return accuracies, nil
+}
+
+// PredictionObservations builds the observations of the scored points of the set ordered by date
+// the reference of each point is the previous real close, starting from the base of the set
+func PredictionObservations(set models.PredictionSet) []forecast.Observation {
+	points := append([]models.PredictionPoint(nil), set.Points...)
+	sort.Slice(points, func(i, j int) bool { return points[i].Date < points[j].Date })
+
+	observations := make([]forecast.Observation, 0, len(points))
+	reference := set.BaseClose
+	for _, point := range points {
+		if !point.Scored || point.ActualClose == nil {
+			continue
+		}
+
+		if reference > 0 {
+			observations = append(observations, forecast.Observation{
+				Reference: reference,
+				Predicted: point.PredictedClose,
+				Actual:    *point.ActualClose,
+			})
+		}
+
+		reference = *point.ActualClose
+	}
+
+	return observations
+}
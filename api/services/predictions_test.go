@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"api/forecast"
+	"api/models"
+	"api/services"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictionObservations(t *testing.T) {
+	close := func(value float64) *float64 { return &value }
+
+	testCases := []struct {
+		desc     string
+		set      models.PredictionSet
+		expected []forecast.Observation
+	}{
+		{
+			desc: "reference is the previous real close",
+			set: models.PredictionSet{
+				BaseClose: 100,
+				Points: []models.PredictionPoint{
+					{Date: "2025-01-03", PredictedClose: 103, ActualClose: close(99), Scored: true},
+					{Date: "2025-01-02", PredictedClose: 101, ActualClose: close(102), Scored: true},
+				},
+			},
+			expected: []forecast.Observation{
+				{Reference: 100, Predicted: 101, Actual: 102},
+				{Reference: 102, Predicted: 103, Actual: 99},
+			},
+		},
+		{
+			desc: "skip days without trading and pending days",
+			set: models.PredictionSet{
+				BaseClose: 100,
+				Points: []models.PredictionPoint{
+					{Date: "2025-01-01", PredictedClose: 101, ActualClose: nil, Scored: true},
+					{Date: "2025-01-02", PredictedClose: 102, ActualClose: close(101), Scored: true},
+					{Date: "2025-01-03", PredictedClose: 103},
+				},
+			},
+			expected: []forecast.Observation{
+				{Reference: 100, Predicted: 102, Actual: 101},
+			},
+		},
+		{
+			desc:     "no points",
+			set:      models.PredictionSet{BaseClose: 100},
+			expected: []forecast.Observation{},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, services.PredictionObservations(tC.set))
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	apilogger "api/logger"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const dateLayout = "2006-01-02"
+
+// BackfillResult summary of a backfill of the historical prices
+// FailedTickers are the tickers whose prices could not be retrieved
+type BackfillResult struct {
+	Tickers       int
+	FailedTickers int
+	Prices        int
+}
+
+// DateRange range of dates (YYYY-MM-DD) including both ends
+type DateRange struct {
+	From string
+	To   string
+}
+
+// HistoricalPriceStore implements HistoricalPriceService with the prices stored in the database,
+// only the dates not covered yet are retrieved from the upstream and stored
+type HistoricalPriceStore struct {
+	db       *gorm.DB
+	upstream HistoricalPriceService
+	mu       sync.Mutex
+	fetching map[string]*sync.Mutex
+}
+
+// NewHistoricalPriceStore creates a new HistoricalPriceStore
+// upstream is used to retrieve the prices that are not stored
+func NewHistoricalPriceStore(db *gorm.DB, upstream HistoricalPriceService) *HistoricalPriceStore {
+	return &HistoricalPriceStore{
+		db:       db,
+		upstream: upstream,
+		fetching: make(map[string]*sync.Mutex),
+	}
+}
+
+// GetHistoricalPrices returns the prices of the ticker between the dates, the newest first like the financial API
+// a zero to is today, a zero from is the full history, which is not stored and is always retrieved from the upstream.
+// If the upstream fails the stored prices are returned, the error is returned only without stored prices
+func (s *HistoricalPriceStore) GetHistoricalPrices(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.HistoricalPrice, error) {
+	if from.IsZero() {
+		return s.upstream.GetHistoricalPrices(ctx, ticker, from, to)
+	}
+
+	ticker = strings.ToUpper(ticker)
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	first, last := from.Format(dateLayout), to.Format(dateLayout)
+	if first > last {
+		return []models.HistoricalPrice{}, nil
+	}
+
+	_, fetchErr := s.fetch(ctx, ticker, first, last)
+	if fetchErr != nil && ctx.Err() != nil {
+		return nil, fetchErr
+	}
+
+	var prices []models.HistoricalPrice
+	err := s.db.WithContext(ctx).
+		Where("ticker = ? AND date >= ? AND date <= ?", ticker, first, last).
+		Order("date desc").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("[HistoricalPriceStore] failed to retrieve historical prices id: %s: %w", ticker, err)
+	}
+
+	if fetchErr != nil {
+		if len(prices) == 0 {
+			return nil, fetchErr
+		}
+
+		apilogger.Logger().Warn().Err(fetchErr).Msg("[HistoricalPriceStore] using the stored prices of " + ticker)
+	}
+
+	return prices, nil
+}
+
+// Backfill stores the prices of the tickers between the dates, at most concurrency tickers at the same time
+// a ticker that fails does not stop the backfill
+func (s *HistoricalPriceStore) Backfill(ctx context.Context, tickers []string, from time.Time, to time.Time, concurrency int) (BackfillResult, error) {
+	result := BackfillResult{Tickers: len(tickers)}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	first, last := from.Format(dateLayout), to.Format(dateLayout)
+
+	var mu sync.Mutex
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+
+	for _, ticker := range tickers {
+		group.Go(func() error {
+			stored, err := s.fetch(groupCtx, strings.ToUpper(ticker), first, last)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				apilogger.Logger().Warn().Err(err).Msg("[HistoricalPriceStore] failed to backfill prices of " + ticker)
+				result.FailedTickers++
+				return groupCtx.Err()
+			}
+
+			result.Prices += stored
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return result, fmt.Errorf("[HistoricalPriceStore] failed to backfill prices: %w", err)
+	}
+
+	return result, nil
+}
+
+// fetch retrieves from the upstream the dates between first and last that are not covered and stores them
+// returns the number of stored prices, the requests of the same ticker are serialized to fetch each range once
+func (s *HistoricalPriceStore) fetch(ctx context.Context, ticker string, first string, last string) (int, error) {
+	lock := s.tickerLock(ticker)
+	lock.Lock()
+	defer lock.Unlock()
+
+	var coverage *models.PriceCoverage
+	var row models.PriceCoverage
+	err := s.db.WithContext(ctx).Where("ticker = ?", ticker).First(&row).Error
+	if err == nil {
+		coverage = &row
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("[HistoricalPriceStore] failed to retrieve coverage id: %s: %w", ticker, err)
+	}
+
+	today := time.Now().Format(dateLayout)
+	ranges := MissingDateRanges(coverage, first, last)
+
+	stored := 0
+	for _, missing := range ranges {
+		from, _ := time.Parse(dateLayout, missing.From)
+		to, _ := time.Parse(dateLayout, missing.To)
+
+		prices, err := s.upstream.GetHistoricalPrices(ctx, ticker, from, to)
+		if err != nil {
+			return stored, err
+		}
+
+		saved, updated, err := s.save(ctx, ticker, prices, coverage, missing, today)
+		if err != nil {
+			return stored, err
+		}
+
+		coverage = updated
+		stored += saved
+	}
+
+	return stored, nil
+}
+
+// save stores the prices of the fetched range and the new coverage in the same transaction,
+// returns the stored prices and the coverage extended until the last stored date
+func (s *HistoricalPriceStore) save(ctx context.Context, ticker string, prices []models.HistoricalPrice, coverage *models.PriceCoverage, fetched DateRange, today string) (int, *models.PriceCoverage, error) {
+	rows := make([]models.HistoricalPrice, 0, len(prices))
+	lastDate := ""
+	for _, price := range prices {
+		if price.Date < fetched.From || price.Date > fetched.To {
+			continue
+		}
+
+		price.Symbol = ticker
+		rows = append(rows, price)
+		lastDate = max(lastDate, price.Date)
+	}
+
+	updated := ExtendCoverage(coverage, ticker, fetched, lastDate, today)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(rows) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "ticker"}, {Name: "date"}},
+				UpdateAll: true,
+			}).CreateInBatches(rows, 500).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if updated == nil || updated == coverage {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ticker"}},
+			UpdateAll: true,
+		}).Create(updated).Error
+	})
+
+	if err != nil {
+		return 0, coverage, fmt.Errorf("[HistoricalPriceStore] failed to save historical prices id: %s: %w", ticker, err)
+	}
+
+	return len(rows), updated, nil
+}
+
+// tickerLock returns the lock of the fetches of the ticker
+func (s *HistoricalPriceStore) tickerLock(ticker string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, ok := s.fetching[ticker]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.fetching[ticker] = lock
+	}
+
+	return lock
+}
+
+// MissingDateRanges returns the ranges of dates between first and last that must be retrieved
+// the ranges are adjacent to the coverage so it stays a single range, sorted by date
+func MissingDateRanges(coverage *models.PriceCoverage, first string, last string) []DateRange {
+	if coverage == nil {
+		return []DateRange{{From: first, To: last}}
+	}
+
+	var ranges []DateRange
+	if first < coverage.FirstDate {
+		ranges = append(ranges, DateRange{From: first, To: addDays(coverage.FirstDate, -1)})
+	}
+
+	if last > coverage.LastDate {
+		ranges = append(ranges, DateRange{From: addDays(coverage.LastDate, 1), To: last})
+	}
+
+	return ranges
+}
+
+// ExtendCoverage returns the coverage with the fetched range until the last date returned by the upstream and
+// until yesterday, today is never covered because its price changes until the close. The dates after the last
+// returned date are retrieved again, the upstream may not have them yet. Without returned dates the coverage
+// is not changed, returns nil if nothing before today is covered
+func ExtendCoverage(coverage *models.PriceCoverage, ticker string, fetched DateRange, lastDate string, today string) *models.PriceCoverage {
+	if lastDate == "" {
+		return coverage
+	}
+
+	yesterday := addDays(today, -1)
+	to := min(fetched.To, lastDate, yesterday)
+
+	if coverage == nil {
+		if fetched.From > to {
+			return nil
+		}
+
+		return &models.PriceCoverage{Ticker: ticker, FirstDate: fetched.From, LastDate: to}
+	}
+
+	updated := *coverage
+	updated.FirstDate = min(updated.FirstDate, fetched.From)
+	updated.LastDate = max(updated.LastDate, to)
+	return &updated
+}
+
+// addDays adds days to a date with the format YYYY-MM-DD
+func addDays(date string, days int) string {
+	parsed, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return date
+	}
+
+	return parsed.AddDate(0, 0, days).Format(dateLayout)
+}
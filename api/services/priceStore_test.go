@@ -0,0 +1,140 @@
+package services_test
+
+import (
+	"api/models"
+	"api/services"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingDateRanges(t *testing.T) {
+	coverage := &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-03-01", LastDate: "2025-03-31"}
+
+	testCases := []struct {
+		desc     string
+		coverage *models.PriceCoverage
+		first    string
+		last     string
+		expected []services.DateRange
+	}{
+		{desc: "without coverage", first: "2025-01-01", last: "2025-01-31", expected: []services.DateRange{{From: "2025-01-01", To: "2025-01-31"}}},
+		{desc: "covered", coverage: coverage, first: "2025-03-10", last: "2025-03-20", expected: nil},
+		{desc: "before the coverage", coverage: coverage, first: "2025-02-01", last: "2025-03-10", expected: []services.DateRange{{From: "2025-02-01", To: "2025-02-28"}}},
+		{desc: "after the coverage", coverage: coverage, first: "2025-03-10", last: "2025-04-05", expected: []services.DateRange{{From: "2025-04-01", To: "2025-04-05"}}},
+		{desc: "far after the coverage", coverage: coverage, first: "2025-06-01", last: "2025-06-30", expected: []services.DateRange{{From: "2025-04-01", To: "2025-06-30"}}},
+		{
+			desc:     "around the coverage",
+			coverage: coverage,
+			first:    "2025-02-15",
+			last:     "2025-04-15",
+			expected: []services.DateRange{{From: "2025-02-15", To: "2025-02-28"}, {From: "2025-04-01", To: "2025-04-15"}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, services.MissingDateRanges(tC.coverage, tC.first, tC.last))
+		})
+	}
+}
+
+func TestExtendCoverage(t *testing.T) {
+	coverage := &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-03-01", LastDate: "2025-03-31"}
+
+	testCases := []struct {
+		desc     string
+		coverage *models.PriceCoverage
+		fetched  services.DateRange
+		lastDate string
+		expected *models.PriceCoverage
+	}{
+		{
+			desc:     "new coverage",
+			fetched:  services.DateRange{From: "2025-01-01", To: "2025-01-31"},
+			lastDate: "2025-01-31",
+			expected: &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-01-01", LastDate: "2025-01-31"},
+		},
+		{
+			desc:     "without today",
+			coverage: coverage,
+			fetched:  services.DateRange{From: "2025-04-01", To: "2025-04-10"},
+			lastDate: "2025-04-10",
+			expected: &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-03-01", LastDate: "2025-04-09"},
+		},
+		{
+			desc:     "until the last returned date",
+			coverage: coverage,
+			fetched:  services.DateRange{From: "2025-04-01", To: "2025-04-10"},
+			lastDate: "2025-04-04",
+			expected: &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-03-01", LastDate: "2025-04-04"},
+		},
+		{
+			desc:     "before the coverage",
+			coverage: coverage,
+			fetched:  services.DateRange{From: "2025-02-01", To: "2025-02-28"},
+			lastDate: "2025-02-27",
+			expected: &models.PriceCoverage{Ticker: "AAPL", FirstDate: "2025-02-01", LastDate: "2025-03-31"},
+		},
+		{desc: "empty response", coverage: coverage, fetched: services.DateRange{From: "2025-04-01", To: "2025-04-09"}, expected: coverage},
+		{desc: "empty response without coverage", fetched: services.DateRange{From: "2025-04-01", To: "2025-04-09"}, expected: nil},
+		{desc: "only today", fetched: services.DateRange{From: "2025-04-10", To: "2025-04-10"}, lastDate: "2025-04-10", expected: nil},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, services.ExtendCoverage(tC.coverage, "AAPL", tC.fetched, tC.lastDate, "2025-04-10"))
+		})
+	}
+}
+
+// fakeUpstreamPrices returns the prices of the dates between the requested dates and counts the requests
+type fakeUpstreamPrices struct {
+	prices []models.HistoricalPrice
+	calls  int
+}
+
+func (f *fakeUpstreamPrices) GetHistoricalPrices(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.HistoricalPrice, error) {
+	f.calls++
+
+	var prices []models.HistoricalPrice
+	for _, price := range f.prices {
+		if price.Date >= from.Format("2006-01-02") && price.Date <= to.Format("2006-01-02") {
+			prices = append(prices, price)
+		}
+	}
+	return prices, nil
+}
+
+func TestHistoricalPriceStoreEmptyUpstream(t *testing.T) {
+	db := newTestDB(t, &models.HistoricalPrice{}, &models.PriceCoverage{})
+	upstream := &fakeUpstreamPrices{}
+	store := services.NewHistoricalPriceStore(db, upstream)
+
+	from := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	// the upstream has no prices yet, the range is not covered
+	prices, err := store.GetHistoricalPrices(context.Background(), "AAPL", from, to)
+	assert.NoError(t, err)
+	assert.Empty(t, prices)
+
+	var coverages int64
+	assert.NoError(t, db.Model(&models.PriceCoverage{}).Count(&coverages).Error)
+	assert.Equal(t, int64(0), coverages)
+
+	// the range is retrieved again once the upstream has the prices
+	upstream.prices = []models.HistoricalPrice{
+		{Date: "2025-03-05", Close: 101},
+		{Date: "2025-03-04", Close: 100},
+	}
+	prices, err = store.GetHistoricalPrices(context.Background(), "AAPL", from, to)
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, 2, upstream.calls)
+
+	var coverage models.PriceCoverage
+	assert.NoError(t, db.Where("ticker = ?", "AAPL").First(&coverage).Error)
+	assert.Equal(t, "2025-03-03", coverage.FirstDate)
+	assert.Equal(t, "2025-03-05", coverage.LastDate)
+}
@@ -0,0 +1,435 @@
+package services
+
+import (
+	"api/cache"
+	"api/config"
+	apilogger "api/logger"
+	"api/metrics"
+	"api/models"
+	CustomClient "api/services/customClient"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// names of the market data providers of the configuration
+const (
+	FMPProvider      = "fmp"
+	FinnhubProvider  = "finnhub"
+	DatabaseProvider = "db"
+)
+
+// ErrNoProviders is returned when a service has no provider configured
+var ErrNoProviders = errors.New("no providers configured")
+
+// Provider implementation of a service by a market data provider
+type Provider[T any] struct {
+	Name    string
+	Service T
+}
+
+// ProviderHealth state of a provider of a service
+// a provider is unhealthy after the failure threshold and is skipped until RetryAt,
+// LastError only describes the failure with the status and the host, never the text of the error
+type ProviderHealth struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError,omitempty"`
+	LastFailureAt       *time.Time `json:"lastFailureAt,omitempty"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+// FailoverOptions when a provider is skipped
+// FailureThreshold consecutive failures mark the provider unhealthy for Cooldown
+type FailoverOptions struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// Failover calls the providers of a service in order until one succeeds
+// the unhealthy providers are skipped, if all of them are unhealthy they are called anyway
+type Failover[T any] struct {
+	service   string
+	options   FailoverOptions
+	providers []Provider[T]
+	mu        sync.Mutex
+	health    []ProviderHealth
+}
+
+// NewFailover creates the failover of the service with the providers in order
+func NewFailover[T any](service string, options FailoverOptions, providers ...Provider[T]) *Failover[T] {
+	if options.FailureThreshold < 1 {
+		options.FailureThreshold = 1
+	}
+
+	health := make([]ProviderHealth, len(providers))
+	for i, provider := range providers {
+		health[i] = ProviderHealth{Name: provider.Name, Healthy: true}
+	}
+
+	return &Failover[T]{
+		service:   service,
+		options:   options,
+		providers: providers,
+		health:    health,
+	}
+}
+
+// Health returns the state of the providers in order
+func (f *Failover[T]) Health() []ProviderHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	health := make([]ProviderHealth, len(f.health))
+	copy(health, f.health)
+	return health
+}
+
+// available checks the provider is healthy or its cooldown has passed
+func (f *Failover[T]) available(i int, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.health[i].Healthy || f.health[i].RetryAt == nil || !now.Before(*f.health[i].RetryAt)
+}
+
+// record updates the health of the provider with the result of a call
+func (f *Failover[T]) record(i int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	health := &f.health[i]
+
+	if err == nil {
+		health.Healthy = true
+		health.ConsecutiveFailures = 0
+		health.RetryAt = nil
+		health.LastSuccessAt = &now
+		metrics.ProviderRequestsTotal.WithLabelValues(f.service, health.Name, "success").Inc()
+		return
+	}
+
+	health.ConsecutiveFailures++
+	health.LastError = failureReason(err)
+	health.LastFailureAt = &now
+	metrics.ProviderRequestsTotal.WithLabelValues(f.service, health.Name, "error").Inc()
+
+	if health.ConsecutiveFailures >= f.options.FailureThreshold {
+		if health.Healthy {
+			apilogger.Logger().Warn().Err(err).Msg(fmt.Sprintf("[Failover] provider %s of %s is unhealthy", health.Name, f.service))
+		}
+
+		retryAt := now.Add(f.options.Cooldown)
+		health.Healthy = false
+		health.RetryAt = &retryAt
+	}
+}
+
+// failureReason describes the failure without the text of the error, the errors of the transport
+// print the url of the request with the api keys of the query
+func failureReason(err error) string {
+	var httpError *CustomClient.HTTPError
+	switch {
+	case errors.As(err, &httpError) && httpError.StatusCode != 0:
+		return fmt.Sprintf("status %d from %s%s", httpError.StatusCode, httpError.Host, httpError.Path)
+	case errors.As(err, &httpError):
+		return fmt.Sprintf("no response from %s%s", httpError.Host, httpError.Path)
+	case errors.Is(err, CustomClient.ErrCircuitOpen):
+		return "circuit open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	return "request failed"
+}
+
+// callFailover calls the providers of the failover until one succeeds
+// returns the errors of all the providers if none succeeds, a cancelled context stops the failover
+func callFailover[T any, R any](ctx context.Context, f *Failover[T], call func(T) (R, error)) (R, error) {
+	var zero R
+	if len(f.providers) == 0 {
+		return zero, fmt.Errorf("[Failover] %s: %w", f.service, ErrNoProviders)
+	}
+
+	now := time.Now()
+	var errs []error
+	var skipped []int
+
+	try := func(i int) (R, bool) {
+		result, err := call(f.providers[i].Service)
+		if err != nil && ctx.Err() != nil {
+			errs = append(errs, err)
+			return zero, false
+		}
+
+		// the provider answered, not finding the data is not a failure of the provider
+		if CustomClient.IsNotFound(err) {
+			metrics.ProviderRequestsTotal.WithLabelValues(f.service, f.providers[i].Name, "not_found").Inc()
+			errs = append(errs, fmt.Errorf("%s: %w", f.providers[i].Name, err))
+			return zero, false
+		}
+
+		f.record(i, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.providers[i].Name, err))
+			return zero, false
+		}
+
+		return result, true
+	}
+
+	for i := range f.providers {
+		if !f.available(i, now) {
+			metrics.ProviderRequestsTotal.WithLabelValues(f.service, f.providers[i].Name, "skipped").Inc()
+			skipped = append(skipped, i)
+			continue
+		}
+
+		if result, ok := try(i); ok {
+			return result, nil
+		}
+
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+	}
+
+	// all the healthy providers failed, the unhealthy ones are the last chance
+	for _, i := range skipped {
+		if result, ok := try(i); ok {
+			return result, nil
+		}
+
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+	}
+
+	return zero, fmt.Errorf("[Failover] all the providers of %s failed: %w", f.service, errors.Join(errs...))
+}
+
+// FailoverPrices implements HistoricalPriceService with a failover of providers
+type FailoverPrices struct {
+	*Failover[HistoricalPriceService]
+}
+
+func (f FailoverPrices) GetHistoricalPrices(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.HistoricalPrice, error) {
+	return callFailover(ctx, f.Failover, func(service HistoricalPriceService) ([]models.HistoricalPrice, error) {
+		return service.GetHistoricalPrices(ctx, ticker, from, to)
+	})
+}
+
+// FailoverCompanyData implements CompanyDataService with a failover of providers
+type FailoverCompanyData struct {
+	*Failover[CompanyDataService]
+}
+
+func (f FailoverCompanyData) GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error) {
+	return callFailover(ctx, f.Failover, func(service CompanyDataService) (models.CompanyData, error) {
+		return service.GetCompanyData(ctx, ticker)
+	})
+}
+
+// FailoverLogos implements LogoService with a failover of providers
+type FailoverLogos struct {
+	*Failover[LogoService]
+}
+
+func (f FailoverLogos) GetLogo(ctx context.Context, ticker string) ([]byte, error) {
+	return callFailover(ctx, f.Failover, func(service LogoService) ([]byte, error) {
+		return service.GetLogo(ctx, ticker)
+	})
+}
+
+func (f FailoverLogos) GetLogoUrl(ctx context.Context, ticker string) (string, error) {
+	return callFailover(ctx, f.Failover, func(service LogoService) (string, error) {
+		return service.GetLogoUrl(ctx, ticker)
+	})
+}
+
+// FailoverNews implements CompanyNewsService with a failover of providers
+type FailoverNews struct {
+	*Failover[CompanyNewsService]
+}
+
+func (f FailoverNews) GetNews(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.CompanyNew, error) {
+	return callFailover(ctx, f.Failover, func(service CompanyNewsService) ([]models.CompanyNew, error) {
+		return service.GetNews(ctx, ticker, from, to)
+	})
+}
+
+// storedCompanyData implements CompanyDataService with the company snapshots of the screener
+type storedCompanyData struct {
+	db *gorm.DB
+}
+
+func (s storedCompanyData) GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error) {
+	ticker = strings.ToUpper(ticker)
+
+	var snapshot models.CompanySnapshot
+	if err := s.db.WithContext(ctx).Where("ticker_id = ?", ticker).First(&snapshot).Error; err != nil {
+		return models.CompanyData{}, fmt.Errorf("[ProviderRegistry] failed to retrieve company snapshot id: %s: %w", ticker, err)
+	}
+
+	var company string
+	s.db.WithContext(ctx).Model(&models.Ticker{}).Where("id = ?", ticker).Pluck("company", &company)
+
+	return models.CompanyData{
+		Symbol:      ticker,
+		Price:       snapshot.Price,
+		MarketCap:   snapshot.MarketCap,
+		Beta:        snapshot.Beta,
+		Volume:      snapshot.Volume,
+		CompanyName: company,
+		Industry:    snapshot.Industry,
+		Sector:      snapshot.Sector,
+		Country:     snapshot.Country,
+	}, nil
+}
+
+// ProviderRegistry failovers of the market data services with the providers of the configuration
+// the db provider of the historical prices stores the prices of the other providers and reads them first
+//
+// the registry keeps the health of the providers, so the server creates one and shares it with all the services
+type ProviderRegistry struct {
+	historicalPrices HistoricalPriceService
+	prices           *Failover[HistoricalPriceService]
+	companyData      *Failover[CompanyDataService]
+	liveCompanyData  *Failover[CompanyDataService]
+	logos            *Failover[LogoService]
+	news             *Failover[CompanyNewsService]
+}
+
+// NewProviderRegistry creates the failovers of the providers of the configuration
+// the unknown providers of each service are logged and ignored
+func NewProviderRegistry(db *gorm.DB, cache cache.ICache, providers *config.ProvidersConfig) *ProviderRegistry {
+	fmp := NewFinancialService(cache, FinancialCacheExpiration{})
+	finnhub := NewFinghubService(cache, FinghubCacheExpiration{})
+	// the live prices of the stream are not cached
+	liveFmp := NewFinancialService(nil, FinancialCacheExpiration{})
+	liveFinnhub := NewFinghubService(nil, FinghubCacheExpiration{})
+	options := FailoverOptions{
+		FailureThreshold: providers.FailureThreshold,
+		Cooldown:         providers.Cooldown,
+	}
+
+	registry := &ProviderRegistry{}
+	storePrices := false
+
+	var prices []Provider[HistoricalPriceService]
+	for _, name := range providers.HistoricalPrices {
+		switch name {
+		case FMPProvider:
+			prices = append(prices, Provider[HistoricalPriceService]{Name: name, Service: fmp})
+		case FinnhubProvider:
+			prices = append(prices, Provider[HistoricalPriceService]{Name: name, Service: finnhub})
+		case DatabaseProvider:
+			storePrices = db != nil
+		default:
+			logUnknownProvider("historical prices", name)
+		}
+	}
+
+	// the snapshots of the db provider are refreshed periodically, they are not live prices
+	var companyData, liveCompanyData []Provider[CompanyDataService]
+	for _, name := range providers.CompanyData {
+		switch name {
+		case FMPProvider:
+			companyData = append(companyData, Provider[CompanyDataService]{Name: name, Service: fmp})
+			liveCompanyData = append(liveCompanyData, Provider[CompanyDataService]{Name: name, Service: liveFmp})
+		case FinnhubProvider:
+			companyData = append(companyData, Provider[CompanyDataService]{Name: name, Service: finnhub})
+			liveCompanyData = append(liveCompanyData, Provider[CompanyDataService]{Name: name, Service: liveFinnhub})
+		case DatabaseProvider:
+			if db != nil {
+				companyData = append(companyData, Provider[CompanyDataService]{Name: name, Service: storedCompanyData{db: db}})
+			}
+		default:
+			logUnknownProvider("company data", name)
+		}
+	}
+
+	var logos []Provider[LogoService]
+	for _, name := range providers.Logos {
+		switch name {
+		case FMPProvider:
+			logos = append(logos, Provider[LogoService]{Name: name, Service: fmp})
+		case FinnhubProvider:
+			logos = append(logos, Provider[LogoService]{Name: name, Service: finnhub})
+		default:
+			logUnknownProvider("logos", name)
+		}
+	}
+
+	var news []Provider[CompanyNewsService]
+	for _, name := range providers.News {
+		switch name {
+		case FinnhubProvider:
+			news = append(news, Provider[CompanyNewsService]{Name: name, Service: finnhub})
+		default:
+			logUnknownProvider("news", name)
+		}
+	}
+
+	registry.prices = NewFailover("historical_prices", options, prices...)
+	registry.companyData = NewFailover("company_data", options, companyData...)
+	registry.liveCompanyData = NewFailover("live_company_data", options, liveCompanyData...)
+	registry.logos = NewFailover("logos", options, logos...)
+	registry.news = NewFailover("news", options, news...)
+
+	registry.historicalPrices = FailoverPrices{registry.prices}
+	if storePrices {
+		registry.historicalPrices = NewHistoricalPriceStore(db, FailoverPrices{registry.prices})
+	}
+
+	return registry
+}
+
+func logUnknownProvider(service string, name string) {
+	apilogger.Logger().Warn().Msg(fmt.Sprintf("[ProviderRegistry] unknown provider %q of %s, the providers are %s, %s and %s", name, service, FMPProvider, FinnhubProvider, DatabaseProvider))
+}
+
+// HistoricalPriceService returns the historical prices with the failover of the providers
+func (r *ProviderRegistry) HistoricalPriceService() HistoricalPriceService {
+	return r.historicalPrices
+}
+
+// CompanyDataService returns the company data with the failover of the providers
+func (r *ProviderRegistry) CompanyDataService() CompanyDataService {
+	return FailoverCompanyData{r.companyData}
+}
+
+// LiveCompanyDataService returns the company data without cache with the failover of the providers
+// used by the stream to poll the prices
+func (r *ProviderRegistry) LiveCompanyDataService() CompanyDataService {
+	return FailoverCompanyData{r.liveCompanyData}
+}
+
+// LogoService returns the logos with the failover of the providers
+func (r *ProviderRegistry) LogoService() LogoService {
+	return FailoverLogos{r.logos}
+}
+
+// CompanyNewsService returns the news with the failover of the providers
+func (r *ProviderRegistry) CompanyNewsService() CompanyNewsService {
+	return FailoverNews{r.news}
+}
+
+// Health returns the state of the providers of each service
+func (r *ProviderRegistry) Health() map[string][]ProviderHealth {
+	return map[string][]ProviderHealth{
+		r.prices.service:          r.prices.Health(),
+		r.companyData.service:     r.companyData.Health(),
+		r.liveCompanyData.service: r.liveCompanyData.Health(),
+		r.logos.service:           r.logos.Health(),
+		r.news.service:            r.news.Health(),
+	}
+}
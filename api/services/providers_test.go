@@ -0,0 +1,268 @@
+package services_test
+
+import (
+	"api/config"
+	"api/models"
+	"api/services"
+	CustomClient "api/services/customClient"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFMPStandIn returns a financial API that fails while failing is set and counts its requests
+func newFMPStandIn(t *testing.T, failing *atomic.Bool, calls *atomic.Int32) *services.FinancialService {
+	server := initMockServer(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/stable/profile":
+			json.NewEncoder(w).Encode([]models.CompanyData{{Symbol: "AAPL", CompanyName: "Apple Inc.", Price: 150}})
+		case "/stable/historical-price-eod/full":
+			json.NewEncoder(w).Encode([]models.HistoricalPrice{{Symbol: "AAPL", Date: "2025-01-03", Close: 150}})
+		case "/image-stock/AAPL.png":
+			w.Write([]byte("fmp-logo"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	t.Cleanup(server.Close)
+
+	// one attempt per call so the requests are counted by provider and not by retry
+	client := CustomClient.NewCustomClient(server.URL)
+	client.Policy.MaxAttempts = 1
+
+	return &services.FinancialService{
+		Client:  client,
+		BaseURL: server.URL,
+		Token:   "test_token",
+	}
+}
+
+// newFinnhubStandIn returns a finnhub API with the profile, the quote, the candles and the logo of AAPL
+func newFinnhubStandIn(t *testing.T) *services.FinghubService {
+	var serverURL string
+	server := initMockServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "AAPL" && r.URL.Path != "/logo.png" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/stock/profile2":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ticker":               "AAPL",
+				"name":                 "Apple Inc",
+				"country":              "US",
+				"exchange":             "NASDAQ NMS - GLOBAL MARKET",
+				"finnhubIndustry":      "Technology",
+				"logo":                 serverURL + "/logo.png",
+				"marketCapitalization": 3000000,
+				"weburl":               "https://www.apple.com/",
+			})
+		case "/quote":
+			w.Write([]byte(`{"c":151.5,"d":1.5,"dp":1}`))
+		case "/stock/candle":
+			// 2025-01-02 and 2025-01-03 at 00:00 UTC
+			w.Write([]byte(`{"s":"ok","t":[1735776000,1735862400],"o":[100,110],"h":[112,121],"l":[98,108],"c":[110,121],"v":[1000,2000]}`))
+		case "/logo.png":
+			w.Write([]byte("finnhub-logo"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	serverURL = server.URL
+	t.Cleanup(server.Close)
+
+	return &services.FinghubService{
+		Client:          CustomClient.NewCustomClient(server.URL),
+		BaseURL:         server.URL,
+		Token:           "test_token",
+		CacheExpiration: services.FinghubCacheExpiration{}.Normalize(),
+	}
+}
+
+func TestFinnhubHistoricalPrices(t *testing.T) {
+	finnhub := newFinnhubStandIn(t)
+
+	prices, err := finnhub.GetHistoricalPrices(context.Background(), "aapl", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC))
+
+	assert.NoError(t, err)
+	assert.Len(t, prices, 2)
+	assert.Equal(t, "2025-01-03", prices[0].Date)
+	assert.Equal(t, "AAPL", prices[0].Symbol)
+	assert.InDelta(t, 121, prices[0].Close, 1e-9)
+	assert.InDelta(t, 11, prices[0].Change, 1e-9)
+	assert.InDelta(t, 10, prices[0].ChangeP, 1e-9)
+	assert.Equal(t, "2025-01-02", prices[1].Date)
+}
+
+func TestFinnhubCompanyData(t *testing.T) {
+	finnhub := newFinnhubStandIn(t)
+
+	companyData, err := finnhub.GetCompanyData(context.Background(), "AAPL")
+	assert.NoError(t, err)
+	assert.Equal(t, "Apple Inc", companyData.CompanyName)
+	assert.Equal(t, "Technology", companyData.Sector)
+	assert.InDelta(t, 151.5, companyData.Price, 1e-9)
+	assert.InDelta(t, 3e12, companyData.MarketCap, 1)
+
+	_, err = finnhub.GetCompanyData(context.Background(), "NVG")
+	assert.ErrorContains(t, err, "[FinghubService] company data not found id: NVG")
+}
+
+func TestFailoverCompanyData(t *testing.T) {
+	var failing atomic.Bool
+	var fmpCalls atomic.Int32
+	failing.Store(true)
+
+	fmp := newFMPStandIn(t, &failing, &fmpCalls)
+	finnhub := newFinnhubStandIn(t)
+
+	failover := services.NewFailover("company_data", services.FailoverOptions{FailureThreshold: 2, Cooldown: 50 * time.Millisecond},
+		services.Provider[services.CompanyDataService]{Name: "fmp", Service: fmp},
+		services.Provider[services.CompanyDataService]{Name: "finnhub", Service: finnhub},
+	)
+	companyData := services.FailoverCompanyData{Failover: failover}
+
+	t.Run("fails over to the next provider", func(t *testing.T) {
+		data, err := companyData.GetCompanyData(context.Background(), "AAPL")
+		assert.NoError(t, err)
+		assert.Equal(t, "Apple Inc", data.CompanyName)
+
+		health := failover.Health()
+		assert.True(t, health[0].Healthy)
+		assert.Equal(t, 1, health[0].ConsecutiveFailures)
+		assert.NotNil(t, health[1].LastSuccessAt)
+	})
+
+	t.Run("skips the unhealthy provider", func(t *testing.T) {
+		_, err := companyData.GetCompanyData(context.Background(), "AAPL")
+		assert.NoError(t, err)
+		assert.False(t, failover.Health()[0].Healthy)
+		assert.Equal(t, int32(2), fmpCalls.Load())
+
+		_, err = companyData.GetCompanyData(context.Background(), "AAPL")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), fmpCalls.Load())
+	})
+
+	t.Run("retries the provider after the cooldown", func(t *testing.T) {
+		failing.Store(false)
+		time.Sleep(60 * time.Millisecond)
+
+		data, err := companyData.GetCompanyData(context.Background(), "AAPL")
+		assert.NoError(t, err)
+		assert.Equal(t, "Apple Inc.", data.CompanyName)
+		assert.True(t, failover.Health()[0].Healthy)
+		assert.Equal(t, 0, failover.Health()[0].ConsecutiveFailures)
+	})
+}
+
+func TestFailoverHistoricalPrices(t *testing.T) {
+	var failing atomic.Bool
+	var fmpCalls atomic.Int32
+	failing.Store(true)
+
+	prices := services.FailoverPrices{Failover: services.NewFailover("historical_prices", services.FailoverOptions{FailureThreshold: 1, Cooldown: time.Minute},
+		services.Provider[services.HistoricalPriceService]{Name: "fmp", Service: newFMPStandIn(t, &failing, &fmpCalls)},
+		services.Provider[services.HistoricalPriceService]{Name: "finnhub", Service: newFinnhubStandIn(t)},
+	)}
+
+	result, err := prices.GetHistoricalPrices(context.Background(), "AAPL", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+}
+
+func TestFailoverLogos(t *testing.T) {
+	var failing atomic.Bool
+	var fmpCalls atomic.Int32
+	failing.Store(true)
+
+	logos := services.FailoverLogos{Failover: services.NewFailover("logos", services.FailoverOptions{FailureThreshold: 1, Cooldown: time.Minute},
+		services.Provider[services.LogoService]{Name: "fmp", Service: newFMPStandIn(t, &failing, &fmpCalls)},
+		services.Provider[services.LogoService]{Name: "finnhub", Service: newFinnhubStandIn(t)},
+	)}
+
+	logo, err := logos.GetLogo(context.Background(), "AAPL")
+	assert.NoError(t, err)
+	assert.Equal(t, "finnhub-logo", string(logo))
+}
+
+func TestFailoverAllProvidersFail(t *testing.T) {
+	var failing atomic.Bool
+	var fmpCalls atomic.Int32
+	failing.Store(true)
+
+	companyData := services.FailoverCompanyData{Failover: services.NewFailover("company_data", services.FailoverOptions{FailureThreshold: 1, Cooldown: time.Minute},
+		services.Provider[services.CompanyDataService]{Name: "fmp", Service: newFMPStandIn(t, &failing, &fmpCalls)},
+		services.Provider[services.CompanyDataService]{Name: "finnhub", Service: newFinnhubStandIn(t)},
+	)}
+
+	_, err := companyData.GetCompanyData(context.Background(), "NVG")
+	assert.ErrorContains(t, err, "all the providers of company_data failed")
+	assert.ErrorContains(t, err, "fmp: ")
+	assert.ErrorContains(t, err, "finnhub: ")
+
+	// the unhealthy providers are called when no other provider is left
+	_, err = companyData.GetCompanyData(context.Background(), "NVG")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), fmpCalls.Load())
+
+	empty := services.FailoverNews{Failover: services.NewFailover[services.CompanyNewsService]("news", services.FailoverOptions{})}
+	_, err = empty.GetNews(context.Background(), "AAPL", time.Time{}, time.Time{})
+	assert.True(t, errors.Is(err, services.ErrNoProviders))
+}
+
+func TestProviderRegistryHealth(t *testing.T) {
+	registry := services.NewProviderRegistry(newTestDB(t), nil, &config.ProvidersConfig{
+		HistoricalPrices: []string{"fmp"},
+		CompanyData:      []string{"db", "fmp", "finnhub"},
+		Logos:            []string{"fmp"},
+		News:             []string{"finnhub"},
+	})
+
+	health := registry.Health()
+	assert.Len(t, health["historical_prices"], 1)
+	assert.Len(t, health["company_data"], 3)
+	// the snapshots of the db provider are not live prices
+	assert.Len(t, health["live_company_data"], 2)
+	assert.Equal(t, "fmp", health["live_company_data"][0].Name)
+	assert.True(t, health["live_company_data"][0].Healthy)
+	assert.Len(t, health["logos"], 1)
+	assert.Len(t, health["news"], 1)
+}
+
+func TestFailoverHealthWithoutErrorText(t *testing.T) {
+	// the transport errors print the url of the request with the api key
+	server := initMockServer(func(w http.ResponseWriter, r *http.Request) {})
+	server.Close()
+
+	client := CustomClient.NewCustomClient(server.URL)
+	client.Policy.MaxAttempts = 1
+	fmp := &services.FinancialService{Client: client, BaseURL: server.URL, Token: "secret_token"}
+
+	failover := services.NewFailover("company_data", services.FailoverOptions{FailureThreshold: 1, Cooldown: time.Minute},
+		services.Provider[services.CompanyDataService]{Name: "fmp", Service: fmp},
+	)
+	_, err := services.FailoverCompanyData{Failover: failover}.GetCompanyData(context.Background(), "AAPL")
+	assert.Error(t, err)
+
+	health := failover.Health()
+	assert.Equal(t, "no response from "+strings.TrimPrefix(server.URL, "http://")+"/stable/profile", health[0].LastError)
+	assert.NotContains(t, health[0].LastError, "secret_token")
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"api/cache"
+	apilogger "api/logger"
+	"api/models"
+	"api/stream"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnalystRatingsCheckpoint is the checkpoint name used by the analyst ratings sync
+const AnalystRatingsCheckpoint = "analyst_ratings"
+
+// RatingsSyncResult summary of a sync execution
+type RatingsSyncResult struct {
+	Pages      int   `json:"pages"`
+	Fetched    int   `json:"fetched"`
+	Inserted   int64 `json:"inserted"`
+	Skipped    int   `json:"skipped"`
+	Tickers    int64 `json:"tickers"`
+	Brokerages int64 `json:"brokerages"`
+	// Since is the time of the checkpoint the sync resumed from, zero in the first sync
+	Since    time.Time `json:"since"`
+	LastTime time.Time `json:"lastTime"`
+}
+
+// RatingsSyncService ingests only the new recommendations from the stock api
+// using the checkpoint saved by the previous execution
+type RatingsSyncService struct {
+	db             *gorm.DB
+	analystRatings AnalystRatingsServiceInterface
+	tickerService  TickerService
+	batchSize      int
+
+	// pubsub publishes the inserted recommendations to the live stream, optional
+	pubsub cache.IPubSub
+}
+
+// NewRatingsSyncService creates a new RatingsSyncService
+func NewRatingsSyncService(db *gorm.DB, analystRatings AnalystRatingsServiceInterface, tickerService TickerService) *RatingsSyncService {
+	return &RatingsSyncService{
+		db:             db,
+		analystRatings: analystRatings,
+		tickerService:  tickerService,
+		batchSize:      1000,
+	}
+}
+
+// WithPubSub publishes the recommendations inserted by the sync to the subscribers of their tickers
+func (s *RatingsSyncService) WithPubSub(pubsub cache.IPubSub) *RatingsSyncService {
+	s.pubsub = pubsub
+	return s
+}
+
+// GetCheckpoint returns the checkpoint of the analyst ratings sync
+// if the sync never run returns an empty checkpoint
+func (s *RatingsSyncService) GetCheckpoint(ctx context.Context) (models.SyncCheckpoint, error) {
+	var checkpoint models.SyncCheckpoint
+	err := s.db.WithContext(ctx).Where("name = ?", AnalystRatingsCheckpoint).First(&checkpoint).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.SyncCheckpoint{Name: AnalystRatingsCheckpoint}, nil
+	}
+
+	if err != nil {
+		return checkpoint, fmt.Errorf("[RatingsSyncService] failed to get checkpoint: %w", err)
+	}
+
+	return checkpoint, nil
+}
+
+// Sync walks the pages of the stock api until it reaches recommendations already ingested,
+// inserts the new ones and saves the checkpoint
+//
+// a page is considered already ingested when all its items are older than the checkpoint
+// or when its cursor is the last cursor read by the previous execution, the items with the time
+// of the checkpoint are read again because other ratings can share it, the insert ignores the duplicates
+func (s *RatingsSyncService) Sync(ctx context.Context) (RatingsSyncResult, error) {
+	var result RatingsSyncResult
+
+	checkpoint, err := s.GetCheckpoint(ctx)
+	if err != nil {
+		return result, err
+	}
+	result.Since = checkpoint.LastTime
+
+	var pending []models.StockRecommendation
+	newest := checkpoint.LastTime
+	cursor := ""
+	lastCursor := checkpoint.Cursor
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		page, err := s.analystRatings.GetWithNext(ctx, cursor)
+		if err != nil {
+			return result, fmt.Errorf("[RatingsSyncService] failed to get page %d: %w", result.Pages+1, err)
+		}
+
+		result.Pages++
+		result.Fetched += len(page.Items)
+		if cursor != "" {
+			lastCursor = cursor
+		}
+
+		fresh := 0
+		for _, item := range page.Items {
+			itemTime, err := ParseTimeNanoToRFC3339(item.Time)
+			if err != nil || (!checkpoint.LastTime.IsZero() && itemTime.Before(checkpoint.LastTime)) {
+				result.Skipped++
+				continue
+			}
+
+			if itemTime.After(newest) {
+				newest = itemTime
+			}
+
+			pending = append(pending, item)
+			fresh++
+		}
+
+		next := page.Next
+		if next == "" || strings.ToLower(next) == "null" {
+			break
+		}
+
+		if !checkpoint.LastTime.IsZero() && fresh == 0 {
+			apilogger.Logger().Debug().Msg(fmt.Sprintf("[RatingsSyncService] page %d already ingested, stop", result.Pages))
+			break
+		}
+
+		if checkpoint.Cursor != "" && next == checkpoint.Cursor {
+			apilogger.Logger().Debug().Msg(fmt.Sprintf("[RatingsSyncService] reached checkpoint cursor %s, stop", next))
+			break
+		}
+
+		cursor = next
+	}
+
+	if err := s.insert(ctx, pending, &result); err != nil {
+		return result, err
+	}
+
+	// the first sync loads the whole history, only the later syncs are live updates
+	if result.Inserted > 0 && !checkpoint.LastTime.IsZero() {
+		s.publish(ctx, checkpoint.LastTime)
+	}
+
+	checkpoint.Cursor = lastCursor
+	checkpoint.LastTime = newest
+	if err := s.db.WithContext(ctx).Save(&checkpoint).Error; err != nil {
+		return result, fmt.Errorf("[RatingsSyncService] failed to save checkpoint: %w", err)
+	}
+
+	result.LastTime = newest
+	return result, nil
+}
+
+// publish sends the recommendations newer than the checkpoint to the live stream
+// the failures are logged, the recommendations are already saved
+func (s *RatingsSyncService) publish(ctx context.Context, since time.Time) {
+	if s.pubsub == nil {
+		return
+	}
+
+	var recommendations []models.Recommendation
+	err := s.db.WithContext(ctx).
+		Preload("Brokerage").
+		Where("time > ?", since).
+		Order("time asc").
+		Find(&recommendations).Error
+	if err != nil {
+		apilogger.Logger().Err(err).Msg("[RatingsSyncService] failed to retrieve the inserted recommendations")
+		return
+	}
+
+	if err := stream.PublishRecommendations(ctx, s.pubsub, recommendations); err != nil {
+		apilogger.Logger().Err(err).Msg("[RatingsSyncService] failed to publish the inserted recommendations")
+	}
+}
+
+// insert inserts the tickers, brokerages and recommendations of the new items
+func (s *RatingsSyncService) insert(ctx context.Context, pending []models.StockRecommendation, result *RatingsSyncResult) error {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tickers, brokerages := PrepareEntities(pending)
+
+	affected, err := s.tickerService.InsertTickers(ctx, tickers, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("[RatingsSyncService] failed to insert tickers: %w", err)
+	}
+	result.Tickers = affected
+
+	affected, err = s.tickerService.InsertBrokerages(ctx, brokerages, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("[RatingsSyncService] failed to insert brokerages: %w", err)
+	}
+	result.Brokerages = affected
+
+	recommendations := BuildRecommendations(pending, BrokerageIDsByName(brokerages))
+	affected, err = s.tickerService.InsertRecommendations(ctx, recommendations, s.batchSize)
+	if err != nil {
+		return fmt.Errorf("[RatingsSyncService] failed to insert recommendations: %w", err)
+	}
+
+	// duplicates are ignored by the insert
+	result.Inserted = affected
+	result.Skipped += len(recommendations) - int(affected)
+
+	return nil
+}
+
+// PrepareEntities cleans and prepares the entities for insertion and remove the brokerages with empty name
+func PrepareEntities(stockRecommendations []models.StockRecommendation) ([]models.Ticker, []models.Brokerage) {
+	var brokerages []models.Brokerage = make([]models.Brokerage, 0)
+	var tickers []models.Ticker = make([]models.Ticker, 0)
+	var brokeragesMap = make(map[string]bool)
+	var tickersMap = make(map[string]bool)
+
+	for _, recommendation := range stockRecommendations {
+		if recommendation.Ticker != "" {
+			if _, ok := tickersMap[recommendation.Ticker]; !ok {
+				ticker := models.Ticker{
+					ID:      models.TickerID(recommendation.Ticker),
+					Company: recommendation.Company,
+				}
+
+				tickersMap[recommendation.Ticker] = true
+				tickers = append(tickers, ticker)
+			}
+		}
+
+		if recommendation.Brokerage != "" {
+			if _, ok := brokeragesMap[recommendation.Brokerage]; !ok {
+				brokeragesMap[recommendation.Brokerage] = true
+
+				brokerage := models.Brokerage{
+					Name: recommendation.Brokerage,
+				}
+				brokerages = append(brokerages, brokerage)
+			}
+		}
+	}
+
+	return tickers, brokerages
+}
+
+// BrokerageIDsByName creates the map of brokerage names with their ids
+func BrokerageIDsByName(brokerages []models.Brokerage) map[string]uint {
+	var brokeragesMap = make(map[string]uint)
+	for _, brokerage := range brokerages {
+		if brokerage.Name != "" {
+			brokeragesMap[brokerage.Name] = brokerage.ID
+		}
+	}
+
+	return brokeragesMap
+}
+
+// BuildRecommendations converts the stock recommendations to recommendations ready to insert
+// the recommendations with invalid time are discarded
+func BuildRecommendations(stockRecommendations []models.StockRecommendation, brokeragesWithIdsMap map[string]uint) []models.Recommendation {
+	var recommendations []models.Recommendation = make([]models.Recommendation, 0)
+	for _, recommendation := range stockRecommendations {
+
+		parsedTime, err := ParseTimeNanoToRFC3339(recommendation.Time)
+		if err != nil {
+			apilogger.Logger().Err(err).Msg("[BuildRecommendations] failed to parse time, recommendation: " + recommendation.Ticker)
+			continue
+		}
+
+		recommendation := models.Recommendation{
+			TickerID:    recommendation.Ticker,
+			BrokerageID: brokeragesWithIdsMap[recommendation.Brokerage],
+			TargetFrom:  recommendation.TargetFrom.CurrencyToFloat(),
+			TargetTo:    recommendation.TargetTo.CurrencyToFloat(),
+			Action:      recommendation.Action,
+			RatingFrom:  recommendation.RatingFrom,
+			RatingTo:    recommendation.RatingTo,
+			Time:        parsedTime,
+		}
+
+		recommendations = append(recommendations, recommendation)
+	}
+
+	return recommendations
+}
@@ -0,0 +1,144 @@
+package services_test
+
+import (
+	"api/config"
+	"api/models"
+	"api/services"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeAnalystRatings returns the pages by cursor and records the cursors requested
+type fakeAnalystRatings struct {
+	pages     map[string]services.AnalystRatingResponse
+	requested []string
+}
+
+func (f *fakeAnalystRatings) GetAll(ctx context.Context) ([]models.StockRecommendation, error) {
+	var items []models.StockRecommendation
+	for _, page := range f.pages {
+		items = append(items, page.Items...)
+	}
+	return items, nil
+}
+
+func (f *fakeAnalystRatings) GetWithNext(ctx context.Context, nextPage string) (services.AnalystRatingResponse, error) {
+	f.requested = append(f.requested, nextPage)
+	page, ok := f.pages[nextPage]
+	if !ok {
+		return page, fmt.Errorf("unexpected page %q", nextPage)
+	}
+	return page, nil
+}
+
+func rating(ticker string, brokerage string, at time.Time) models.StockRecommendation {
+	return models.StockRecommendation{
+		Ticker:    ticker,
+		Company:   ticker + " Inc.",
+		Action:    "upgraded by",
+		Brokerage: brokerage,
+		RatingTo:  "Buy",
+		Time:      at,
+	}
+}
+
+func newTestRatingsSync(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	return newTestDB(t, &models.Ticker{}, &models.Brokerage{}, &models.Recommendation{}, &models.SyncCheckpoint{})
+}
+
+func syncRatings(t *testing.T, db *gorm.DB, ratings *fakeAnalystRatings) services.RatingsSyncResult {
+	t.Helper()
+
+	service := services.NewRatingsSyncService(db, ratings, services.NewTickerService(db, nil, services.NewProviderRegistry(db, nil, &config.ProvidersConfig{})))
+	result, err := service.Sync(context.Background())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return result
+}
+
+func TestRatingsSyncResume(t *testing.T) {
+	db := newTestRatingsSync(t)
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	first := &fakeAnalystRatings{pages: map[string]services.AnalystRatingResponse{
+		"": {Next: "p2", Items: []models.StockRecommendation{
+			rating("AAPL", "Broker A", start.Add(2*time.Hour)),
+			rating("MSFT", "Broker A", start.Add(time.Hour)),
+		}},
+		"p2": {Next: "", Items: []models.StockRecommendation{
+			rating("TSLA", "Broker B", start),
+		}},
+	}}
+
+	result := syncRatings(t, db, first)
+	assert.Equal(t, []string{"", "p2"}, first.requested)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 3, result.Fetched)
+	assert.Equal(t, int64(3), result.Inserted)
+	assert.Equal(t, 0, result.Skipped)
+	assert.True(t, result.Since.IsZero())
+	assert.True(t, start.Add(2*time.Hour).Equal(result.LastTime))
+
+	var checkpoint models.SyncCheckpoint
+	assert.NoError(t, db.Where("name = ?", services.AnalystRatingsCheckpoint).First(&checkpoint).Error)
+	assert.Equal(t, "p2", checkpoint.Cursor)
+	assert.True(t, start.Add(2*time.Hour).Equal(checkpoint.LastTime))
+
+	// the new ratings are on the first page, the rating with the time of the checkpoint is new
+	// and the second page is already ingested
+	second := &fakeAnalystRatings{pages: map[string]services.AnalystRatingResponse{
+		"": {Next: "q2", Items: []models.StockRecommendation{
+			rating("AAPL", "Broker B", start.Add(3*time.Hour)),
+			rating("NVDA", "Broker B", start.Add(2*time.Hour)),
+			rating("AAPL", "Broker A", start.Add(2*time.Hour)),
+		}},
+		"q2": {Next: "q3", Items: []models.StockRecommendation{
+			rating("MSFT", "Broker A", start.Add(time.Hour)),
+			rating("TSLA", "Broker B", start),
+		}},
+	}}
+
+	result = syncRatings(t, db, second)
+	assert.Equal(t, []string{"", "q2"}, second.requested)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, int64(2), result.Inserted)
+	// the two older ratings and the duplicate with the time of the checkpoint
+	assert.Equal(t, 3, result.Skipped)
+	assert.True(t, start.Add(2*time.Hour).Equal(result.Since))
+	assert.True(t, start.Add(3*time.Hour).Equal(result.LastTime))
+
+	var total int64
+	assert.NoError(t, db.Model(&models.Recommendation{}).Count(&total).Error)
+	assert.Equal(t, int64(5), total)
+}
+
+func TestRatingsSyncStopsAtCheckpointCursor(t *testing.T) {
+	db := newTestRatingsSync(t)
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	checkpoint := models.SyncCheckpoint{Name: services.AnalystRatingsCheckpoint, Cursor: "p2", LastTime: start}
+	assert.NoError(t, db.Create(&checkpoint).Error)
+
+	ratings := &fakeAnalystRatings{pages: map[string]services.AnalystRatingResponse{
+		"": {Next: "p2", Items: []models.StockRecommendation{
+			rating("AAPL", "Broker A", start.Add(time.Hour)),
+		}},
+	}}
+
+	result := syncRatings(t, db, ratings)
+	assert.Equal(t, []string{""}, ratings.requested)
+	assert.Equal(t, int64(1), result.Inserted)
+
+	assert.NoError(t, db.Where("name = ?", services.AnalystRatingsCheckpoint).First(&checkpoint).Error)
+	assert.Equal(t, "p2", checkpoint.Cursor)
+	assert.True(t, start.Add(time.Hour).Equal(checkpoint.LastTime))
+}
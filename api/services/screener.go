@@ -0,0 +1,203 @@
+package services
+
+import (
+	apilogger "api/logger"
+	"api/models"
+	"api/models/filters"
+	"api/screener"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SnapshotsResult summary of a refresh of the company snapshots
+// FailedTickers are the tickers without company data, their previous snapshot is kept
+type SnapshotsResult struct {
+	Tickers       int
+	FailedTickers int
+}
+
+// ScreenerService filters the tickers with the company snapshots, the sentiment and the recommendations
+type ScreenerService struct {
+	db          *gorm.DB
+	companyData CompanyDataService
+}
+
+// NewScreenerService creates a new ScreenerService
+// companyData is used to refresh the snapshots of the tickers
+func NewScreenerService(db *gorm.DB, companyData CompanyDataService) ScreenerService {
+	return ScreenerService{
+		db:          db,
+		companyData: companyData,
+	}
+}
+
+// Screen retrieves a paginated list of the tickers that meet the filter
+// the query filters by ticker or company, OrderBy can be any field of the screener except recentActions (default ticker)
+// returns screener.ErrInvalidFilter if the filter or the order is not valid
+func (s *ScreenerService) Screen(ctx context.Context, filter screener.Filter, f filters.Filters) ([]screener.Row, int64, error) {
+	f.Normalize()
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	if f.OrderBy == "" {
+		f.OrderBy = "ticker"
+	}
+
+	if !screener.IsSortable(f.OrderBy) {
+		return nil, 0, fmt.Errorf("%w: the results can not be ordered by %q", screener.ErrInvalidFilter, f.OrderBy)
+	}
+
+	// the conditions of the company data are evaluated by the database, the conditions of the
+	// sentiment and the recommendations only over the tickers that meet them
+	query := s.db.WithContext(ctx).
+		Model(&models.Ticker{}).
+		Joins("LEFT JOIN company_snapshots ON company_snapshots.ticker_id = tickers.id")
+	if f.Query != "" {
+		query = query.Where("(tickers.id LIKE ? OR LOWER(tickers.company) LIKE ?)", strings.ToUpper(f.Query)+"%", "%"+strings.ToLower(f.Query)+"%")
+	}
+
+	condition, args, exact := filter.SQL()
+	if condition != "" {
+		query = query.Where(condition, args...)
+	}
+	query = query.Session(&gorm.Session{})
+
+	// when the database evaluates the whole filter and the order, only the page is loaded
+	order, sortable := screener.OrderSQL(f.OrderBy, f.Sort == filters.DESC)
+	if exact && sortable {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return nil, 0, fmt.Errorf("[ScreenerService] failed to count tickers: %w", err)
+		}
+
+		rows, err := s.rows(ctx, query.Order(order).Offset(f.Offset()).Limit(f.PageSize), f)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return rows, total, nil
+	}
+
+	candidates, err := s.rows(ctx, query, f)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows := []screener.Row{}
+	for _, row := range candidates {
+		if filter.Match(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	screener.SortRows(rows, f.OrderBy, f.Sort == filters.DESC)
+
+	total := int64(len(rows))
+	start := min(f.Offset(), len(rows))
+	end := min(start+f.PageSize, len(rows))
+
+	return rows[start:end], total, nil
+}
+
+// rows retrieves the tickers of the query with their snapshot and calculates their sentiment
+func (s *ScreenerService) rows(ctx context.Context, query *gorm.DB, f filters.Filters) ([]screener.Row, error) {
+	var tickers []models.Ticker
+	if err := query.Select("tickers.*").Preload("Recommendations").Find(&tickers).Error; err != nil {
+		return nil, fmt.Errorf("[ScreenerService] failed to retrieve tickers: %w", err)
+	}
+
+	if len(tickers) == 0 {
+		return []screener.Row{}, nil
+	}
+
+	if err := calculateSentiment(ctx, s.db, tickers, f.Sentiment); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(tickers))
+	for _, ticker := range tickers {
+		ids = append(ids, ticker.ID.String())
+	}
+
+	var snapshots []models.CompanySnapshot
+	if err := s.db.WithContext(ctx).Where("ticker_id IN ?", ids).Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("[ScreenerService] failed to retrieve company snapshots: %w", err)
+	}
+
+	snapshotsByTicker := make(map[string]*models.CompanySnapshot, len(snapshots))
+	for i := range snapshots {
+		snapshotsByTicker[snapshots[i].TickerID] = &snapshots[i]
+	}
+
+	now := time.Now()
+	rows := make([]screener.Row, 0, len(tickers))
+	for _, ticker := range tickers {
+		rows = append(rows, screener.NewRow(ticker, snapshotsByTicker[ticker.ID.String()], now))
+	}
+
+	return rows, nil
+}
+
+// RefreshSnapshots saves the current company data of all the tickers
+func (s *ScreenerService) RefreshSnapshots(ctx context.Context) (SnapshotsResult, error) {
+	var result SnapshotsResult
+
+	var tickers []string
+	if err := s.db.WithContext(ctx).Model(&models.Ticker{}).Pluck("id", &tickers).Error; err != nil {
+		return result, fmt.Errorf("[ScreenerService] failed to retrieve tickers: %w", err)
+	}
+	result.Tickers = len(tickers)
+
+	var mu sync.Mutex
+	snapshots := make([]models.CompanySnapshot, 0, len(tickers))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(5)
+
+	for _, ticker := range tickers {
+		group.Go(func() error {
+			data, err := s.companyData.GetCompanyData(groupCtx, ticker)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// a ticker without company data does not stop the refresh
+			if err != nil {
+				apilogger.Logger().Warn().Err(err).Msg("[ScreenerService] failed to retrieve company data of " + ticker)
+				result.FailedTickers++
+				return groupCtx.Err()
+			}
+
+			snapshots = append(snapshots, models.NewCompanySnapshot(strings.ToUpper(ticker), data))
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return result, fmt.Errorf("[ScreenerService] failed to retrieve company data: %w", err)
+	}
+
+	if len(snapshots) == 0 {
+		return result, nil
+	}
+
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ticker_id"}},
+			UpdateAll: true,
+		}).
+		CreateInBatches(snapshots, 500).Error
+	if err != nil {
+		return result, fmt.Errorf("[ScreenerService] failed to save company snapshots: %w", err)
+	}
+
+	return result, nil
+}
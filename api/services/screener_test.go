@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"api/models"
+	"api/models/filters"
+	"api/screener"
+	"api/services"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestScreener creates the screener with AAPL and MSFT of technology, XOM of energy and NEW without snapshot,
+// AAPL was upgraded recently
+func newTestScreener(t *testing.T) services.ScreenerService {
+	t.Helper()
+
+	db := newTestDB(t, &models.Ticker{}, &models.Brokerage{}, &models.Recommendation{}, &models.CompanySnapshot{})
+	assert.NoError(t, db.Create([]models.Ticker{
+		{ID: "AAPL", Company: "Apple Inc."},
+		{ID: "MSFT", Company: "Microsoft Corporation"},
+		{ID: "XOM", Company: "Exxon Mobil Corporation"},
+		{ID: "NEW", Company: "New Company"},
+	}).Error)
+	assert.NoError(t, db.Create([]models.CompanySnapshot{
+		{TickerID: "AAPL", Sector: "Technology", Country: "US", MarketCap: 3e12, Price: 200},
+		{TickerID: "MSFT", Sector: "Technology", Country: "US", MarketCap: 3.5e12, Price: 400},
+		{TickerID: "XOM", Sector: "Energy", Country: "US", MarketCap: 5e11, Price: 100},
+	}).Error)
+	assert.NoError(t, db.Create(&models.Brokerage{Name: "Broker"}).Error)
+	assert.NoError(t, db.Create(&models.Recommendation{
+		TickerID:    "AAPL",
+		BrokerageID: 1,
+		TargetTo:    250,
+		Action:      "upgraded by",
+		RatingTo:    "Buy",
+		Time:        time.Now().AddDate(0, 0, -1),
+	}).Error)
+
+	return services.NewScreenerService(db, nil)
+}
+
+func screen(t *testing.T, service services.ScreenerService, body string, f filters.Filters) ([]string, int64) {
+	t.Helper()
+
+	var filter screener.Filter
+	assert.NoError(t, json.Unmarshal([]byte(body), &filter))
+
+	rows, total, err := service.Screen(context.Background(), filter, f)
+	assert.NoError(t, err)
+
+	tickers := []string{}
+	for _, row := range rows {
+		tickers = append(tickers, row.Ticker)
+	}
+	return tickers, total
+}
+
+func TestScreenerServiceScreen(t *testing.T) {
+	service := newTestScreener(t)
+
+	testCases := []struct {
+		desc     string
+		body     string
+		filters  filters.Filters
+		expected []string
+		total    int64
+	}{
+		{desc: "all", body: `{}`, expected: []string{"AAPL", "MSFT", "NEW", "XOM"}, total: 4},
+		{
+			desc:     "company data page",
+			body:     `{"field":"sector","op":"eq","value":"technology"}`,
+			filters:  filters.Filters{Page: 2, PageSize: 1, OrderBy: "price", Sort: filters.DESC},
+			expected: []string{"AAPL"},
+			total:    2,
+		},
+		{desc: "without value last", body: `{}`, filters: filters.Filters{OrderBy: "price"}, expected: []string{"XOM", "AAPL", "MSFT", "NEW"}, total: 4},
+		{desc: "query", body: `{"field":"country","op":"in","value":["us"]}`, filters: filters.Filters{Query: "corp"}, expected: []string{"MSFT", "XOM"}, total: 2},
+		{desc: "not equal without value", body: `{"field":"sector","op":"neq","value":"Energy"}`, expected: []string{"AAPL", "MSFT"}, total: 2},
+		{
+			desc:     "calculated field",
+			body:     `{"and":[{"field":"sector","op":"eq","value":"Technology"},{"field":"recentActions","op":"contains","value":"upgraded"}]}`,
+			expected: []string{"AAPL"},
+			total:    1,
+		},
+		{
+			desc:     "or with calculated field",
+			body:     `{"or":[{"field":"sector","op":"eq","value":"Energy"},{"field":"lastAction","op":"eq","value":"upgraded"}]}`,
+			expected: []string{"AAPL", "XOM"},
+			total:    2,
+		},
+		{desc: "order by calculated field", body: `{}`, filters: filters.Filters{OrderBy: "targetUpside", PageSize: 1}, expected: []string{"AAPL"}, total: 4},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tickers, total := screen(t, service, tC.body, tC.filters)
+			assert.Equal(t, tC.expected, tickers)
+			assert.Equal(t, tC.total, total)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"api/analytics"
+	"api/models"
+	"api/models/ratings"
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// calculateSentiment sets the sentiment of the tickers from their recommendations
+// in the weighted mode the stats of the brokerages are loaded to weight their ratings
+func calculateSentiment(ctx context.Context, db *gorm.DB, tickers []models.Ticker, mode ratings.SentimentMode) error {
+	var stats map[uint]*models.BrokerageStats
+	if mode == ratings.WeightedSentimentMode {
+		var err error
+		if stats, err = loadBrokerageStats(ctx, db, tickers); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for i := range tickers {
+		ratingCollection := createRatingCollection(tickers[i].Recommendations)
+
+		var tickerSentiment ratings.SentimentScore
+		if mode == ratings.WeightedSentimentMode {
+			tickerSentiment = ratingCollection.CalculateWeightedSentiment(analytics.SentimentWeights(tickers[i].Recommendations, stats, now))
+		} else {
+			tickerSentiment = ratingCollection.CalculateSentiment()
+		}
+
+		tickers[i].Sentiment = tickerSentiment.Sentiment
+		tickers[i].SentimentScore = tickerSentiment
+	}
+
+	return nil
+}
+
+// loadBrokerageStats retrieves the stats of the brokerages of the recommendations of the tickers by id
+func loadBrokerageStats(ctx context.Context, db *gorm.DB, tickers []models.Ticker) (map[uint]*models.BrokerageStats, error) {
+	ids := make(map[uint]bool)
+	for _, ticker := range tickers {
+		for _, recommendation := range ticker.Recommendations {
+			ids[recommendation.BrokerageID] = true
+		}
+	}
+
+	stats := make(map[uint]*models.BrokerageStats, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	brokerageIDs := make([]uint, 0, len(ids))
+	for id := range ids {
+		brokerageIDs = append(brokerageIDs, id)
+	}
+
+	var rows []models.BrokerageStats
+	if err := db.WithContext(ctx).Where("brokerage_id IN ?", brokerageIDs).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("[TickerService] failed to retrieve brokerage stats: %w", err)
+	}
+
+	for i := range rows {
+		stats[rows[i].BrokerageID] = &rows[i]
+	}
+
+	return stats, nil
+}
@@ -3,9 +3,12 @@ package services
 import (
 	"api/cache"
 	"api/database/scopes"
+	"api/indicators"
 	"api/models"
 	"api/models/filters"
 	"api/models/ratings"
+	"api/models/responses"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -17,10 +20,14 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// TickersTotalCacheKey is the cache key of the total of tickers
+const TickersTotalCacheKey = "tickers:total"
+
 // TickerService defines the interface for stock-related operations
 type TickerService interface {
 	GetTickers(ctx context.Context, filters filters.Filters) ([]models.Ticker, int64, error)
-	GetTickerByID(ctx context.Context, id string) (*models.Ticker, error)
+	GetTickerByID(ctx context.Context, id string, mode ratings.SentimentMode) (*models.Ticker, error)
+	ValidateTickers(ctx context.Context, tickers []string) ([]string, error)
 	GetRecommendations(ctx context.Context, filters filters.Filters) ([]models.Recommendation, error)
 
 	// Insert operations
@@ -34,6 +41,7 @@ type TickerService interface {
 	GetLogoUrl(ctx context.Context, ticker string) (string, error)
 	GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error)
 	GetNews(ctx context.Context, ticker string, from time.Time, to time.Time) ([]models.CompanyNew, error)
+	GetIndicators(ctx context.Context, ticker string, from time.Time, to time.Time, config indicators.Config) (responses.TickerIndicators, error)
 }
 
 type tickerService struct {
@@ -46,16 +54,14 @@ type tickerService struct {
 }
 
 // NewTickerService creates a new instance of TickerService
-func NewTickerService(db *gorm.DB, cache cache.ICache) TickerService {
-	financialApi := NewFinancialService(cache, FinancialCacheExpiration{})
-	finhubApi := NewFinghubService(cache, FinghubCacheExpiration{})
-
+// the market data is retrieved with the failovers of the registry
+func NewTickerService(db *gorm.DB, cache cache.ICache, registry *ProviderRegistry) TickerService {
 	return &tickerService{
 		db:                     db,
-		HistoricalPriceService: financialApi,
-		LogoService:            financialApi,
-		CompanyDataService:     financialApi,
-		CompanyNewsService:     finhubApi,
+		HistoricalPriceService: registry.HistoricalPriceService(),
+		LogoService:            registry.LogoService(),
+		CompanyDataService:     registry.CompanyDataService(),
+		CompanyNewsService:     registry.CompanyNewsService(),
 		cache:                  cache,
 	}
 }
@@ -64,7 +70,7 @@ func NewTickerService(db *gorm.DB, cache cache.ICache) TickerService {
 // GetTickers retrieves a paginated list of tickers
 // If pageSize and page are 0 or less, returns all tickers
 func (s *tickerService) GetTickers(ctx context.Context, filter filters.Filters) (tickers []models.Ticker, total int64, err error) {
-	var cacheKey string = "tickers:total"
+	var cacheKey string = TickersTotalCacheKey
 
 	query := s.db.WithContext(ctx).Model(&models.Ticker{})
 	filter.Normalize()
@@ -78,9 +84,9 @@ func (s *tickerService) GetTickers(ctx context.Context, filter filters.Filters)
 	}
 
 	// get total items
-	total, err = cache.GetOrLoad(ctx, s.cache, cacheKey, 30*time.Minute, func() (int64, error) {
+	total, err = cache.GetOrLoad(ctx, s.cache, cacheKey, 30*time.Minute, func(ctx context.Context) (int64, error) {
 		var total int64
-		err = query.Count(&total).Error
+		err := query.WithContext(ctx).Count(&total).Error
 		return total, err
 	})
 
@@ -92,12 +98,8 @@ func (s *tickerService) GetTickers(ctx context.Context, filter filters.Filters)
 	}
 
 	// calculate sentiment for each ticker
-	for i := range tickers {
-		if tickers[i].Recommendations == nil {
-			continue
-		}
-		tickerSentiment := createRatingCollection(tickers[i].Recommendations).CalculateSentiment()
-		tickers[i].Sentiment = tickerSentiment.Sentiment
+	if err = calculateSentiment(ctx, s.db, tickers, filter.Sentiment); err != nil {
+		return nil, 0, err
 	}
 
 	tickers = sortByPrefix(tickers, filter.Query)
@@ -142,7 +144,8 @@ func createRatingCollection(recommendations []models.Recommendation) ratings.Rat
 
 // GetTickerByID implements TickerService interface
 // GetTickerByID retrieves a single ticker by ID with its recommendations preloaded
-func (s *tickerService) GetTickerByID(ctx context.Context, id string) (*models.Ticker, error) {
+// and the sentiment calculated with the mode
+func (s *tickerService) GetTickerByID(ctx context.Context, id string, mode ratings.SentimentMode) (*models.Ticker, error) {
 	var ticker models.Ticker
 	err := s.db.WithContext(ctx).
 		Preload("Recommendations.Brokerage").
@@ -153,20 +156,34 @@ func (s *tickerService) GetTickerByID(ctx context.Context, id string) (*models.T
 		return nil, fmt.Errorf("[TickerService] failed to retrieve ticker by id: %s: %w", id, err)
 	}
 
-	var ratingCollection ratings.RatingCollection
-
 	for i := range ticker.Recommendations {
 		if ticker.Recommendations[i].Brokerage.Name == "" {
 			ticker.Recommendations[i].Brokerage.Name = "Anonymous"
 		}
+	}
 
-		ratingCollection = append(ratingCollection, ratings.Rating(ticker.Recommendations[i].RatingTo))
+	tickers := []models.Ticker{ticker}
+	if err := calculateSentiment(ctx, s.db, tickers, mode); err != nil {
+		return nil, err
 	}
 
-	tickerSentiment := ratingCollection.CalculateSentiment()
-	ticker.Sentiment = tickerSentiment.Sentiment
+	return &tickers[0], nil
+}
+
+// ValidateTickers implements TickerService interface
+// ValidateTickers normalizes the tickers and checks that all exist in the database
+// returns ErrUnknownTickers with the tickers that do not exist
+func (s *tickerService) ValidateTickers(ctx context.Context, tickers []string) ([]string, error) {
+	tickers = normalizeTickers(tickers)
+	if err := findUnknownTickers(ctx, s.db, tickers); err != nil {
+		if errors.Is(err, ErrUnknownTickers) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("[TickerService] failed to validate tickers: %w", err)
+	}
 
-	return &ticker, nil
+	return tickers, nil
 }
 
 // InsertTickers implements TickerService interface
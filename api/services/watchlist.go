@@ -0,0 +1,292 @@
+package services
+
+import (
+	"api/database/scopes"
+	"api/models"
+	"api/models/filters"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnknownTickers is returned when a watchlist receives tickers that are not in the database
+var ErrUnknownTickers = errors.New("unknown tickers")
+
+// WatchlistService defines the interface for the watchlists of the users
+// all the operations are scoped to the owner of the watchlist
+type WatchlistService interface {
+	GetWatchlists(ctx context.Context, owner string) ([]models.Watchlist, error)
+	GetWatchlist(ctx context.Context, owner string, id uint) (*models.Watchlist, error)
+	CreateWatchlist(ctx context.Context, owner string, name string, tickers []string) (*models.Watchlist, error)
+	UpdateWatchlist(ctx context.Context, owner string, id uint, name string) (*models.Watchlist, error)
+	DeleteWatchlist(ctx context.Context, owner string, id uint) error
+
+	// Tickers operations
+	AddTickers(ctx context.Context, owner string, id uint, tickers []string) (*models.Watchlist, error)
+	RemoveTicker(ctx context.Context, owner string, id uint, ticker string) error
+	GetWatchlistTickers(ctx context.Context, owner string, id uint, filter filters.Filters) ([]models.Ticker, int64, error)
+}
+
+type watchlistService struct {
+	db *gorm.DB
+}
+
+// NewWatchlistService creates a new instance of WatchlistService
+func NewWatchlistService(db *gorm.DB) WatchlistService {
+	return &watchlistService{db: db}
+}
+
+// GetWatchlists implements WatchlistService interface
+// GetWatchlists retrieves the watchlists of the owner with their items
+func (s *watchlistService) GetWatchlists(ctx context.Context, owner string) ([]models.Watchlist, error) {
+	var watchlists []models.Watchlist
+	err := s.db.WithContext(ctx).
+		Preload("Items").
+		Where("owner = ?", owner).
+		Order("name asc").
+		Find(&watchlists).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[WatchlistService] failed to retrieve watchlists: %w", err)
+	}
+
+	return watchlists, nil
+}
+
+// GetWatchlist implements WatchlistService interface
+// GetWatchlist retrieves a watchlist of the owner with its items
+func (s *watchlistService) GetWatchlist(ctx context.Context, owner string, id uint) (*models.Watchlist, error) {
+	var watchlist models.Watchlist
+	err := s.db.WithContext(ctx).
+		Preload("Items").
+		Where("id = ? AND owner = ?", id, owner).
+		First(&watchlist).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("[WatchlistService] failed to retrieve watchlist by id: %d: %w", id, err)
+	}
+
+	return &watchlist, nil
+}
+
+// CreateWatchlist implements WatchlistService interface
+// CreateWatchlist creates a watchlist with the given tickers, the tickers must exist
+func (s *watchlistService) CreateWatchlist(ctx context.Context, owner string, name string, tickers []string) (*models.Watchlist, error) {
+	tickers = normalizeTickers(tickers)
+	if err := s.validateTickers(ctx, tickers); err != nil {
+		return nil, err
+	}
+
+	watchlist := models.Watchlist{
+		Owner: owner,
+		Name:  name,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&watchlist).Error; err != nil {
+			return err
+		}
+
+		return insertWatchlistItems(tx, watchlist.ID, tickers)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("[WatchlistService] failed to create watchlist: %w", err)
+	}
+
+	return s.GetWatchlist(ctx, owner, watchlist.ID)
+}
+
+// UpdateWatchlist implements WatchlistService interface
+// UpdateWatchlist renames a watchlist
+func (s *watchlistService) UpdateWatchlist(ctx context.Context, owner string, id uint, name string) (*models.Watchlist, error) {
+	watchlist, err := s.GetWatchlist(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	watchlist.Name = name
+	err = s.db.WithContext(ctx).Model(watchlist).Update("name", name).Error
+	if err != nil {
+		return nil, fmt.Errorf("[WatchlistService] failed to update watchlist: %w", err)
+	}
+
+	return watchlist, nil
+}
+
+// DeleteWatchlist implements WatchlistService interface
+// DeleteWatchlist deletes a watchlist and its items
+func (s *watchlistService) DeleteWatchlist(ctx context.Context, owner string, id uint) error {
+	if _, err := s.GetWatchlist(ctx, owner, id); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("watchlist_id = ?", id).Delete(&models.WatchlistItem{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ? AND owner = ?", id, owner).Delete(&models.Watchlist{}).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("[WatchlistService] failed to delete watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// AddTickers implements WatchlistService interface
+// AddTickers adds the tickers to the watchlist, the tickers already saved are ignored
+func (s *watchlistService) AddTickers(ctx context.Context, owner string, id uint, tickers []string) (*models.Watchlist, error) {
+	if _, err := s.GetWatchlist(ctx, owner, id); err != nil {
+		return nil, err
+	}
+
+	tickers = normalizeTickers(tickers)
+	if err := s.validateTickers(ctx, tickers); err != nil {
+		return nil, err
+	}
+
+	if err := insertWatchlistItems(s.db.WithContext(ctx), id, tickers); err != nil {
+		return nil, fmt.Errorf("[WatchlistService] failed to add tickers: %w", err)
+	}
+
+	return s.GetWatchlist(ctx, owner, id)
+}
+
+// RemoveTicker implements WatchlistService interface
+// RemoveTicker removes a ticker from the watchlist
+func (s *watchlistService) RemoveTicker(ctx context.Context, owner string, id uint, ticker string) error {
+	if _, err := s.GetWatchlist(ctx, owner, id); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).
+		Where("watchlist_id = ? AND ticker_id = ?", id, strings.ToUpper(ticker)).
+		Delete(&models.WatchlistItem{}).Error
+
+	if err != nil {
+		return fmt.Errorf("[WatchlistService] failed to remove ticker %s: %w", ticker, err)
+	}
+
+	return nil
+}
+
+// GetWatchlistTickers implements WatchlistService interface
+// GetWatchlistTickers retrieves a paginated list of the tickers of a watchlist
+// with their recommendations and sentiment, like TickerService.GetTickers
+func (s *watchlistService) GetWatchlistTickers(ctx context.Context, owner string, id uint, filter filters.Filters) (tickers []models.Ticker, total int64, err error) {
+	if _, err := s.GetWatchlist(ctx, owner, id); err != nil {
+		return nil, 0, err
+	}
+
+	filter.Normalize()
+
+	query := s.db.WithContext(ctx).Model(&models.Ticker{}).
+		Joins("JOIN watchlist_items ON watchlist_items.ticker_id = tickers.id").
+		Where("watchlist_items.watchlist_id = ?", id)
+
+	if err = query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("[WatchlistService] failed to count tickers: %w", err)
+	}
+
+	err = query.
+		Scopes(scopes.SortCompany(filter.Sort), scopes.Pagination(filter.Page, filter.PageSize)).
+		Preload("Recommendations.Brokerage").
+		Find(&tickers).Error
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("[WatchlistService] failed to retrieve tickers: %w", err)
+	}
+
+	// calculate sentiment for each ticker
+	if err = calculateSentiment(ctx, s.db, tickers, filter.Sentiment); err != nil {
+		return nil, 0, err
+	}
+
+	return tickers, total, nil
+}
+
+// validateTickers checks that all the tickers exist in the database
+func (s *watchlistService) validateTickers(ctx context.Context, tickers []string) error {
+	if err := findUnknownTickers(ctx, s.db, tickers); err != nil {
+		if errors.Is(err, ErrUnknownTickers) {
+			return err
+		}
+
+		return fmt.Errorf("[WatchlistService] failed to validate tickers: %w", err)
+	}
+
+	return nil
+}
+
+// findUnknownTickers returns ErrUnknownTickers with the tickers that are not in the database
+func findUnknownTickers(ctx context.Context, db *gorm.DB, tickers []string) error {
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	var found []string
+	err := db.WithContext(ctx).Model(&models.Ticker{}).Where("id IN ?", tickers).Pluck("id", &found).Error
+	if err != nil {
+		return err
+	}
+
+	if len(found) == len(tickers) {
+		return nil
+	}
+
+	foundMap := make(map[string]bool, len(found))
+	for _, ticker := range found {
+		foundMap[ticker] = true
+	}
+
+	var unknown []string
+	for _, ticker := range tickers {
+		if !foundMap[ticker] {
+			unknown = append(unknown, ticker)
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownTickers, strings.Join(unknown, ", "))
+}
+
+// insertWatchlistItems inserts the items of a watchlist ignoring the duplicated
+func insertWatchlistItems(db *gorm.DB, watchlistID uint, tickers []string) error {
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	items := make([]models.WatchlistItem, len(tickers))
+	for i, ticker := range tickers {
+		items[i] = models.WatchlistItem{
+			WatchlistID: watchlistID,
+			TickerID:    ticker,
+		}
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error
+}
+
+// normalizeTickers converts the tickers to upper case and removes the empty and duplicated
+func normalizeTickers(tickers []string) []string {
+	normalized := make([]string, 0, len(tickers))
+	seen := make(map[string]bool, len(tickers))
+
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker == "" || seen[ticker] {
+			continue
+		}
+
+		seen[ticker] = true
+		normalized = append(normalized, ticker)
+	}
+
+	return normalized
+}
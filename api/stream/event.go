@@ -0,0 +1,166 @@
+package stream
+
+// stream delivers the live updates of the tickers to the clients of all the replicas,
+// the updates are published in redis pub/sub and kept in a short history to resume
+
+import (
+	"api/cache"
+	"api/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType kind of update of a ticker
+type EventType string
+
+const (
+	// QuoteEvent is sent when the price of the ticker changes
+	QuoteEvent EventType = "quote"
+	// RecommendationEvent is sent when a new recommendation of the ticker is ingested
+	RecommendationEvent EventType = "recommendation"
+)
+
+// Event is an update of a ticker, ID orders the events of all the tickers
+type Event struct {
+	ID     string          `json:"id"`
+	Type   EventType       `json:"type"`
+	Ticker string          `json:"ticker"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Quote is the data of the quote events
+type Quote struct {
+	Ticker           string    `json:"ticker"`
+	Price            float64   `json:"price"`
+	Change           float64   `json:"change"`
+	ChangePercentage float64   `json:"changePercentage"`
+	Volume           float64   `json:"volume"`
+	At               time.Time `json:"at"`
+}
+
+// NewQuote creates the quote of the company data
+func NewQuote(company models.CompanyData, at time.Time) Quote {
+	return Quote{
+		Ticker:           strings.ToUpper(company.Symbol),
+		Price:            company.Price,
+		Change:           company.Change,
+		ChangePercentage: company.ChangePercentage,
+		Volume:           company.Volume,
+		At:               at,
+	}
+}
+
+// Topic is the pub/sub topic of the updates of the ticker
+func Topic(ticker string) string {
+	return "stream:ticker:" + strings.ToUpper(ticker)
+}
+
+// Publish sends an update of the ticker to the subscribers of all the replicas
+func Publish(ctx context.Context, pubsub cache.IPubSub, eventType EventType, ticker string, data interface{}) (Event, error) {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("[stream] failed to encode %s event: %w", eventType, err)
+	}
+
+	event := Event{Type: eventType, Ticker: strings.ToUpper(ticker), Data: rawData}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return Event{}, fmt.Errorf("[stream] failed to encode %s event: %w", eventType, err)
+	}
+
+	event.ID, err = pubsub.Publish(ctx, Topic(ticker), payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("[stream] failed to publish %s event of %s: %w", eventType, ticker, err)
+	}
+
+	return event, nil
+}
+
+// PublishRecommendations sends the new recommendations to the subscribers of their tickers
+func PublishRecommendations(ctx context.Context, pubsub cache.IPubSub, recommendations []models.Recommendation) error {
+	for _, recommendation := range recommendations {
+		if _, err := Publish(ctx, pubsub, RecommendationEvent, recommendation.TickerID, recommendation); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeMessage converts a pub/sub message to the event
+func decodeMessage(message cache.Message) (Event, error) {
+	var event Event
+	if err := json.Unmarshal(message.Payload, &event); err != nil {
+		return event, fmt.Errorf("[stream] failed to decode message %s: %w", message.ID, err)
+	}
+
+	event.ID = message.ID
+	return event, nil
+}
+
+// ReplayedIDs last id replayed of each ticker, the ids of the tickers come from different streams
+// so they are only comparable with the ids of the same ticker
+type ReplayedIDs map[string]string
+
+// NewReplayedIDs returns the last id of each ticker of the replayed events
+func NewReplayedIDs(events []Event) ReplayedIDs {
+	replayed := make(ReplayedIDs)
+	for _, event := range events {
+		ticker := strings.ToUpper(event.Ticker)
+		if last, ok := replayed[ticker]; !ok || CompareIDs(event.ID, last) > 0 {
+			replayed[ticker] = event.ID
+		}
+	}
+
+	return replayed
+}
+
+// Duplicate checks the live event was already sent in the replay of its ticker
+func (r ReplayedIDs) Duplicate(event Event) bool {
+	last, ok := r[strings.ToUpper(event.Ticker)]
+	return ok && CompareIDs(event.ID, last) <= 0
+}
+
+// CompareIDs compares two event ids with the format <milliseconds>-<sequence>,
+// returns -1 if a is older than b, 1 if it is newer and 0 if they are equal
+func CompareIDs(a string, b string) int {
+	aTime, aSeq := splitID(a)
+	bTime, bSeq := splitID(b)
+
+	switch {
+	case aTime < bTime || (aTime == bTime && aSeq < bSeq):
+		return -1
+	case aTime > bTime || (aTime == bTime && aSeq > bSeq):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ValidID checks the id has the format <milliseconds>-<sequence>
+func ValidID(id string) bool {
+	parts := strings.Split(id, "-")
+	if len(parts) != 2 {
+		return false
+	}
+
+	_, errTime := strconv.ParseUint(parts[0], 10, 64)
+	_, errSeq := strconv.ParseUint(parts[1], 10, 64)
+	return errTime == nil && errSeq == nil
+}
+
+// splitID returns the milliseconds and the sequence of the id, the invalid ids are 0-0
+func splitID(id string) (uint64, uint64) {
+	parts := strings.Split(id, "-")
+	if len(parts) != 2 {
+		return 0, 0
+	}
+
+	milliseconds, _ := strconv.ParseUint(parts[0], 10, 64)
+	sequence, _ := strconv.ParseUint(parts[1], 10, 64)
+	return milliseconds, sequence
+}
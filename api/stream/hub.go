@@ -0,0 +1,424 @@
+package stream
+
+import (
+	"api/cache"
+	apilogger "api/logger"
+	"api/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSlowConsumer is the reason of the subscribers removed because their buffer was full
+var ErrSlowConsumer = errors.New("the subscriber did not read the events in time")
+
+// ErrHubClosed is the reason of the subscribers removed because the hub was closed
+var ErrHubClosed = errors.New("the stream is closed")
+
+// QuoteSource retrieves the price of the tickers, implemented by the FinancialService
+type QuoteSource interface {
+	GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error)
+}
+
+// HubOptions configuration of the hub
+// PollInterval is the interval of the price polling of each ticker
+// BufferSize is the number of events buffered per subscriber, when it is full the subscriber is removed
+type HubOptions struct {
+	PollInterval time.Duration
+	BufferSize   int
+}
+
+// Hub fans out the events of the tickers to the subscribers of this replica
+//
+// the hub has one pub/sub subscription with the topics of the tickers that have subscribers
+// and runs one price poller per ticker, the pollers of all the replicas share a lock per ticker
+// so the upstream api is polled once per ticker
+//
+// mu guards the subscribers and is never held during the calls to the pub/sub,
+// topicsMu serializes the changes of the topics of the subscription, see syncTopics
+type Hub struct {
+	pubsub  cache.IPubSub
+	locker  cache.ILocker
+	quotes  QuoteSource
+	options HubOptions
+	owner   string
+
+	mu           sync.Mutex
+	subscription cache.Subscription
+	subscribers  map[string]map[*Subscriber]bool
+	pollers      map[string]context.CancelFunc
+	closed       bool
+
+	topicsMu sync.Mutex
+	topics   map[string]bool
+}
+
+// NewHub creates a new Hub, locker is optional, without it each replica polls its tickers
+func NewHub(pubsub cache.IPubSub, locker cache.ILocker, quotes QuoteSource, options HubOptions) *Hub {
+	if options.PollInterval <= 0 {
+		options.PollInterval = 15 * time.Second
+	}
+
+	if options.BufferSize <= 0 {
+		options.BufferSize = 64
+	}
+
+	return &Hub{
+		pubsub:      pubsub,
+		locker:      locker,
+		quotes:      quotes,
+		options:     options,
+		owner:       newOwnerID(),
+		subscribers: make(map[string]map[*Subscriber]bool),
+		pollers:     make(map[string]context.CancelFunc),
+		topics:      make(map[string]bool),
+	}
+}
+
+// Subscriber receives the events of its tickers
+type Subscriber struct {
+	events  chan Event
+	done    chan struct{}
+	tickers map[string]bool
+	err     error
+}
+
+// NewSubscriber creates a subscriber without tickers
+func (h *Hub) NewSubscriber() *Subscriber {
+	return &Subscriber{
+		events:  make(chan Event, h.options.BufferSize),
+		done:    make(chan struct{}),
+		tickers: make(map[string]bool),
+	}
+}
+
+// Events returns the channel of the events of the subscriber
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscriber is removed by the hub, Err returns the reason
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the subscriber was removed, nil while it is active
+func (s *Subscriber) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Subscribe adds the tickers to the subscriber, the tickers without subscribers start their poller
+// if the topics can not be subscribed the tickers added are removed again
+func (h *Hub) Subscribe(ctx context.Context, subscriber *Subscriber, tickers ...string) error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return ErrHubClosed
+	}
+
+	if h.subscription == nil {
+		h.subscription = h.pubsub.Subscribe(context.Background())
+		go h.dispatch(h.subscription)
+	}
+
+	var added []string
+	for _, ticker := range tickers {
+		ticker = strings.ToUpper(ticker)
+		if subscriber.tickers[ticker] {
+			continue
+		}
+
+		if _, ok := h.subscribers[ticker]; !ok {
+			h.subscribers[ticker] = make(map[*Subscriber]bool)
+			h.startPoller(ticker)
+		}
+
+		h.subscribers[ticker][subscriber] = true
+		subscriber.tickers[ticker] = true
+		added = append(added, ticker)
+	}
+	h.mu.Unlock()
+
+	if err := h.syncTopics(ctx, added); err != nil {
+		h.Unsubscribe(ctx, subscriber, added...)
+		return err
+	}
+
+	return nil
+}
+
+// Unsubscribe removes the tickers of the subscriber, the tickers without subscribers stop their poller
+func (h *Hub) Unsubscribe(ctx context.Context, subscriber *Subscriber, tickers ...string) {
+	h.mu.Lock()
+	var unused []string
+	for _, ticker := range tickers {
+		unused = append(unused, h.unsubscribeLocked(subscriber, strings.ToUpper(ticker))...)
+	}
+	h.mu.Unlock()
+
+	h.syncTopics(ctx, unused)
+}
+
+// Remove removes all the tickers of the subscriber
+func (h *Hub) Remove(subscriber *Subscriber) {
+	h.mu.Lock()
+	unused := h.removeLocked(subscriber, nil)
+	h.mu.Unlock()
+
+	h.unsubscribeTopics(unused)
+}
+
+// Close removes all the subscribers and stops the pollers and the pub/sub subscription
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+
+	for _, subscribers := range h.subscribers {
+		for subscriber := range subscribers {
+			h.removeLocked(subscriber, ErrHubClosed)
+		}
+	}
+	subscription := h.subscription
+	h.mu.Unlock()
+
+	if subscription != nil {
+		return subscription.Close()
+	}
+
+	return nil
+}
+
+// Replay returns the events of the tickers newer than afterID, the oldest first
+func (h *Hub) Replay(ctx context.Context, tickers []string, afterID string) ([]Event, error) {
+	var events []Event
+	for _, ticker := range tickers {
+		messages, err := h.pubsub.History(ctx, Topic(ticker), afterID)
+		if err != nil {
+			return nil, fmt.Errorf("[stream] failed to retrieve the history of %s: %w", ticker, err)
+		}
+
+		for _, message := range messages {
+			event, err := decodeMessage(message)
+			if err != nil {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return CompareIDs(events[i].ID, events[j].ID) < 0
+	})
+
+	return events, nil
+}
+
+// dispatch delivers the messages of the subscription to the subscribers of the ticker
+// the subscribers with the buffer full are removed, so a slow client does not block the others
+func (h *Hub) dispatch(subscription cache.Subscription) {
+	for message := range subscription.Messages() {
+		event, err := decodeMessage(message)
+		if err != nil {
+			apilogger.Logger().Warn().Err(err).Msg("[stream] discarded message")
+			continue
+		}
+
+		var unused []string
+		h.mu.Lock()
+		for subscriber := range h.subscribers[event.Ticker] {
+			select {
+			case subscriber.events <- event:
+			default:
+				unused = append(unused, h.removeLocked(subscriber, ErrSlowConsumer)...)
+			}
+		}
+		h.mu.Unlock()
+
+		h.unsubscribeTopics(unused)
+	}
+}
+
+// syncTopics subscribes the topics of the tickers with subscribers and unsubscribes the topics of the tickers without them,
+// the subscribers are read again after taking topicsMu, so the last change of each ticker is the one applied
+func (h *Hub) syncTopics(ctx context.Context, tickers []string) error {
+	if len(tickers) == 0 {
+		return nil
+	}
+
+	h.topicsMu.Lock()
+	defer h.topicsMu.Unlock()
+
+	var errs []error
+	for _, ticker := range tickers {
+		h.mu.Lock()
+		subscription, closed := h.subscription, h.closed
+		wanted := len(h.subscribers[ticker]) > 0
+		h.mu.Unlock()
+
+		if subscription == nil || closed || wanted == h.topics[ticker] {
+			continue
+		}
+
+		if wanted {
+			if err := subscription.Subscribe(ctx, Topic(ticker)); err != nil {
+				errs = append(errs, fmt.Errorf("[stream] failed to subscribe to %s: %w", ticker, err))
+				continue
+			}
+		} else if err := subscription.Unsubscribe(ctx, Topic(ticker)); err != nil {
+			apilogger.Logger().Warn().Err(err).Msg("[stream] failed to unsubscribe from " + ticker)
+			continue
+		}
+
+		h.topics[ticker] = wanted
+	}
+
+	return errors.Join(errs...)
+}
+
+// unsubscribeTopics unsubscribes the topics of the tickers left without subscribers by a removal
+func (h *Hub) unsubscribeTopics(tickers []string) {
+	if len(tickers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h.syncTopics(ctx, tickers)
+}
+
+// unsubscribeLocked removes the ticker of the subscriber and returns it if it has no subscribers left,
+// its topic must be unsubscribed with syncTopics after releasing the lock. the caller must hold the lock
+func (h *Hub) unsubscribeLocked(subscriber *Subscriber, ticker string) []string {
+	if !subscriber.tickers[ticker] {
+		return nil
+	}
+
+	delete(subscriber.tickers, ticker)
+	delete(h.subscribers[ticker], subscriber)
+	if len(h.subscribers[ticker]) > 0 {
+		return nil
+	}
+
+	delete(h.subscribers, ticker)
+	if cancel, ok := h.pollers[ticker]; ok {
+		cancel()
+		delete(h.pollers, ticker)
+	}
+
+	return []string{ticker}
+}
+
+// removeLocked removes all the tickers of the subscriber and closes it with the reason,
+// a nil reason is a removal requested by the subscriber. returns the tickers without subscribers left,
+// like unsubscribeLocked. the caller must hold the lock
+func (h *Hub) removeLocked(subscriber *Subscriber, reason error) []string {
+	var unused []string
+	for ticker := range subscriber.tickers {
+		unused = append(unused, h.unsubscribeLocked(subscriber, ticker)...)
+	}
+
+	select {
+	case <-subscriber.done:
+	default:
+		subscriber.err = reason
+		close(subscriber.done)
+	}
+
+	return unused
+}
+
+// startPoller starts the price poller of the ticker, the caller must hold the lock
+func (h *Hub) startPoller(ticker string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.pollers[ticker] = cancel
+
+	go h.poll(ctx, ticker)
+}
+
+// poll publishes a quote event each time the price of the ticker changes until the context is cancelled
+func (h *Hub) poll(ctx context.Context, ticker string) {
+	interval := time.NewTicker(h.options.PollInterval)
+	defer interval.Stop()
+
+	lockKey := "stream:poller:" + ticker
+	defer func() {
+		if h.locker == nil {
+			return
+		}
+
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.locker.Unlock(releaseCtx, lockKey, h.owner)
+	}()
+
+	var lastPrice float64
+	for {
+		lastPrice = h.pollOnce(ctx, ticker, lockKey, lastPrice)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-interval.C:
+		}
+	}
+}
+
+// pollOnce publishes the quote if this replica has the lock of the ticker and the price changed
+// returns the last price published, 0 if this replica does not poll the ticker
+func (h *Hub) pollOnce(ctx context.Context, ticker string, lockKey string, lastPrice float64) float64 {
+	if h.locker != nil {
+		locked, err := h.locker.TryLock(ctx, lockKey, h.owner, 2*h.options.PollInterval)
+		if err != nil || !locked {
+			return 0
+		}
+	}
+
+	company, err := h.quotes.GetCompanyData(ctx, ticker)
+	if err != nil {
+		if ctx.Err() == nil {
+			apilogger.Logger().Warn().Err(err).Msg("[stream] failed to poll the price of " + ticker)
+		}
+		return lastPrice
+	}
+
+	if company.Price <= 0 || company.Price == lastPrice {
+		return lastPrice
+	}
+
+	if company.Symbol == "" {
+		company.Symbol = ticker
+	}
+
+	if _, err := Publish(ctx, h.pubsub, QuoteEvent, ticker, NewQuote(company, time.Now())); err != nil {
+		apilogger.Logger().Warn().Err(err).Msg("[stream] failed to publish the price of " + ticker)
+		return lastPrice
+	}
+
+	return company.Price
+}
+
+// newOwnerID creates the id of the locks of this replica
+func newOwnerID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("hub-%d", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(bytes)
+}
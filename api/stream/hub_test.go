@@ -0,0 +1,376 @@
+package stream
+
+import (
+	"api/cache"
+	"api/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryPubSub is an in-memory pub/sub with history, the ids are sequential
+// if gate is set the topics are subscribed after it is closed, like a slow redis
+type memoryPubSub struct {
+	mu            sync.Mutex
+	sequence      int
+	history       map[string][]cache.Message
+	subscriptions []*memorySubscription
+	gate          chan struct{}
+}
+
+func newMemoryPubSub() *memoryPubSub {
+	return &memoryPubSub{history: make(map[string][]cache.Message)}
+}
+
+func (p *memoryPubSub) Publish(ctx context.Context, topic string, payload []byte) (string, error) {
+	p.mu.Lock()
+	p.sequence++
+	message := cache.Message{ID: fmt.Sprintf("1000-%d", p.sequence), Topic: topic, Payload: payload}
+	p.history[topic] = append(p.history[topic], message)
+	subscriptions := append([]*memorySubscription(nil), p.subscriptions...)
+	p.mu.Unlock()
+
+	for _, subscription := range subscriptions {
+		subscription.deliver(message)
+	}
+
+	return message.ID, nil
+}
+
+func (p *memoryPubSub) History(ctx context.Context, topic string, afterID string) ([]cache.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var messages []cache.Message
+	for _, message := range p.history[topic] {
+		if CompareIDs(message.ID, afterID) > 0 {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (p *memoryPubSub) Subscribe(ctx context.Context) cache.Subscription {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subscription := &memorySubscription{pubsub: p, topics: make(map[string]bool), messages: make(chan cache.Message, 100)}
+	p.subscriptions = append(p.subscriptions, subscription)
+	return subscription
+}
+
+type memorySubscription struct {
+	pubsub   *memoryPubSub
+	mu       sync.Mutex
+	topics   map[string]bool
+	messages chan cache.Message
+	closed   bool
+}
+
+func (s *memorySubscription) deliver(message cache.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || !s.topics[message.Topic] {
+		return
+	}
+
+	// like redis, the messages are dropped when the subscription does not read them in time
+	select {
+	case s.messages <- message:
+	default:
+	}
+}
+
+func (s *memorySubscription) Subscribe(ctx context.Context, topics ...string) error {
+	s.pubsub.mu.Lock()
+	gate := s.pubsub.gate
+	s.pubsub.mu.Unlock()
+
+	if gate != nil {
+		<-gate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+	return nil
+}
+
+func (s *memorySubscription) Unsubscribe(ctx context.Context, topics ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+	return nil
+}
+
+func (s *memorySubscription) Messages() <-chan cache.Message {
+	return s.messages
+}
+
+func (s *memorySubscription) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.messages)
+	}
+	return nil
+}
+
+// fakeQuotes returns the price of the tickers and counts the calls
+// if gate is not nil the prices are returned after it is closed
+type fakeQuotes struct {
+	mu     sync.Mutex
+	prices map[string]float64
+	calls  map[string]int
+	gate   chan struct{}
+}
+
+func (q *fakeQuotes) GetCompanyData(ctx context.Context, ticker string) (models.CompanyData, error) {
+	if q.gate != nil {
+		select {
+		case <-q.gate:
+		case <-ctx.Done():
+			return models.CompanyData{}, ctx.Err()
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.calls[ticker]++
+	return models.CompanyData{Symbol: ticker, Price: q.prices[ticker]}, nil
+}
+
+func (q *fakeQuotes) Calls(ticker string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.calls[ticker]
+}
+
+func receive(t *testing.T, subscriber *Subscriber) Event {
+	t.Helper()
+
+	select {
+	case event := <-subscriber.Events():
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestHubSharesPollerPerTicker(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{"AAPL": 150}, calls: make(map[string]int), gate: make(chan struct{})}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+	defer hub.Close()
+
+	first := hub.NewSubscriber()
+	second := hub.NewSubscriber()
+	assert.NoError(t, hub.Subscribe(context.Background(), first, "aapl"))
+	assert.NoError(t, hub.Subscribe(context.Background(), second, "AAPL"))
+	close(quotes.gate)
+
+	for _, subscriber := range []*Subscriber{first, second} {
+		event := receive(t, subscriber)
+		assert.Equal(t, QuoteEvent, event.Type)
+		assert.Equal(t, "AAPL", event.Ticker)
+
+		var quote Quote
+		assert.NoError(t, json.Unmarshal(event.Data, &quote))
+		assert.Equal(t, 150.0, quote.Price)
+	}
+
+	assert.Equal(t, 1, quotes.Calls("AAPL"))
+}
+
+func TestHubDeliversOnlySubscribedTickers(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+	defer hub.Close()
+
+	subscriber := hub.NewSubscriber()
+	assert.NoError(t, hub.Subscribe(context.Background(), subscriber, "AAPL", "MSFT"))
+	hub.Unsubscribe(context.Background(), subscriber, "MSFT")
+
+	_, err := Publish(context.Background(), pubsub, RecommendationEvent, "MSFT", map[string]string{"action": "upgraded"})
+	assert.NoError(t, err)
+	published, err := Publish(context.Background(), pubsub, RecommendationEvent, "AAPL", map[string]string{"action": "upgraded"})
+	assert.NoError(t, err)
+
+	event := receive(t, subscriber)
+	assert.Equal(t, published.ID, event.ID)
+	assert.Equal(t, "AAPL", event.Ticker)
+}
+
+func TestHubRemovesSlowConsumer(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour, BufferSize: 1})
+	defer hub.Close()
+
+	slow := hub.NewSubscriber()
+	assert.NoError(t, hub.Subscribe(context.Background(), slow, "AAPL"))
+
+	for i := 0; i < 3; i++ {
+		_, err := Publish(context.Background(), pubsub, RecommendationEvent, "AAPL", i)
+		assert.NoError(t, err)
+	}
+
+	select {
+	case <-slow.Done():
+		assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+	case <-time.After(time.Second):
+		t.Fatal("the slow consumer was not removed")
+	}
+}
+
+func TestHubDeliversDuringSlowSubscribe(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+	defer hub.Close()
+
+	subscriber := hub.NewSubscriber()
+	assert.NoError(t, hub.Subscribe(context.Background(), subscriber, "AAPL"))
+
+	gate := make(chan struct{})
+	release := sync.OnceFunc(func() { close(gate) })
+	defer release()
+
+	pubsub.mu.Lock()
+	pubsub.gate = gate
+	pubsub.mu.Unlock()
+
+	subscribed := make(chan error)
+	go func() {
+		subscribed <- hub.Subscribe(context.Background(), hub.NewSubscriber(), "MSFT")
+	}()
+
+	// the subscription to MSFT waits for redis without blocking the events of AAPL
+	published, err := Publish(context.Background(), pubsub, RecommendationEvent, "AAPL", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, published.ID, receive(t, subscriber).ID)
+
+	release()
+	assert.NoError(t, <-subscribed)
+}
+
+func TestHubReplay(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{})
+	defer hub.Close()
+
+	first, err := Publish(context.Background(), pubsub, RecommendationEvent, "AAPL", 1)
+	assert.NoError(t, err)
+	second, err := Publish(context.Background(), pubsub, QuoteEvent, "MSFT", 2)
+	assert.NoError(t, err)
+	third, err := Publish(context.Background(), pubsub, QuoteEvent, "AAPL", 3)
+	assert.NoError(t, err)
+	_, err = Publish(context.Background(), pubsub, QuoteEvent, "TSLA", 4)
+	assert.NoError(t, err)
+
+	events, err := hub.Replay(context.Background(), []string{"AAPL", "MSFT"}, first.ID)
+	assert.NoError(t, err)
+
+	if !assert.Len(t, events, 2) {
+		return
+	}
+	assert.Equal(t, second.ID, events[0].ID)
+	assert.Equal(t, third.ID, events[1].ID)
+}
+
+func TestHubClose(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+
+	subscriber := hub.NewSubscriber()
+	assert.NoError(t, hub.Subscribe(context.Background(), subscriber, "AAPL"))
+	assert.NoError(t, hub.Close())
+
+	<-subscriber.Done()
+	assert.ErrorIs(t, subscriber.Err(), ErrHubClosed)
+	assert.ErrorIs(t, hub.Subscribe(context.Background(), hub.NewSubscriber(), "AAPL"), ErrHubClosed)
+}
+
+func TestCompareIDs(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		a        string
+		b        string
+		expected int
+	}{
+		{desc: "older time", a: "1000-5", b: "1001-0", expected: -1},
+		{desc: "newer time", a: "1002-0", b: "1001-9", expected: 1},
+		{desc: "older sequence", a: "1000-1", b: "1000-2", expected: -1},
+		{desc: "equal", a: "1000-2", b: "1000-2", expected: 0},
+		{desc: "numeric not lexical", a: "999-0", b: "1000-0", expected: -1},
+		{desc: "invalid is the oldest", a: "invalid", b: "0-1", expected: -1},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, CompareIDs(tC.a, tC.b))
+		})
+	}
+}
+
+func TestReplayedIDs(t *testing.T) {
+	replayed := NewReplayedIDs([]Event{
+		{ID: "1000-0", Ticker: "AAPL"},
+		{ID: "1002-0", Ticker: "AAPL"},
+		{ID: "1001-0", Ticker: "msft"},
+	})
+
+	testCases := []struct {
+		desc     string
+		event    Event
+		expected bool
+	}{
+		{desc: "replayed event", event: Event{ID: "1002-0", Ticker: "AAPL"}, expected: true},
+		{desc: "older event of the ticker", event: Event{ID: "1000-0", Ticker: "AAPL"}, expected: true},
+		{desc: "newer event of the ticker", event: Event{ID: "1003-0", Ticker: "AAPL"}, expected: false},
+		{desc: "same id of another ticker", event: Event{ID: "1002-0", Ticker: "MSFT"}, expected: false},
+		{desc: "older id of another ticker", event: Event{ID: "1000-0", Ticker: "TSLA"}, expected: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, replayed.Duplicate(tC.event))
+		})
+	}
+}
+
+func TestReplayedIDsWithoutResume(t *testing.T) {
+	// the streams of two tickers give the same id to the events of the same millisecond
+	replayed := NewReplayedIDs(nil)
+
+	assert.False(t, replayed.Duplicate(Event{ID: "1000-0", Ticker: "AAPL"}))
+	assert.False(t, replayed.Duplicate(Event{ID: "1000-0", Ticker: "MSFT"}))
+	assert.False(t, replayed.Duplicate(Event{ID: "999-0", Ticker: "TSLA"}))
+}
+
+func TestValidID(t *testing.T) {
+	assert.True(t, ValidID("1700000000000-0"))
+	assert.False(t, ValidID(""))
+	assert.False(t, ValidID("1700000000000"))
+	assert.False(t, ValidID("a-0"))
+	assert.False(t, ValidID("1-2-3"))
+}
@@ -0,0 +1,398 @@
+package stream
+
+import (
+	apilogger "api/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// MessageType kind of the messages of the websocket protocol
+type MessageType string
+
+const (
+	// SubscribeMessage is sent by the client to add tickers to the connection
+	SubscribeMessage MessageType = "subscribe"
+	// UnsubscribeMessage is sent by the client to remove tickers of the connection
+	UnsubscribeMessage MessageType = "unsubscribe"
+	// PingMessage is sent by the client to check the connection, the server answers with pong
+	PingMessage MessageType = "ping"
+
+	// SubscribedMessage confirms a subscribe or unsubscribe with the tickers of the connection
+	SubscribedMessage MessageType = "subscribed"
+	// PongMessage is the answer of a ping
+	PongMessage MessageType = "pong"
+	// QuoteMessage is sent when the price of a subscribed ticker changes
+	QuoteMessage MessageType = MessageType(QuoteEvent)
+	// RecommendationMessage is sent when a recommendation of a subscribed ticker is ingested
+	RecommendationMessage MessageType = MessageType(RecommendationEvent)
+	// AdviceMessage is sent once for each ticker subscribed, with the advice of the last days
+	AdviceMessage MessageType = "advice"
+	// ErrorMessage is sent when a message of the client can not be processed, the connection stays open
+	ErrorMessage MessageType = "error"
+)
+
+// ErrSubscriptionLimit is returned when the connection exceeds the max subscriptions
+var ErrSubscriptionLimit = errors.New("subscription limit reached")
+
+// ClientMessage is a message sent by the client
+// ID is optional, it is returned in the answer of the message
+type ClientMessage struct {
+	Type    MessageType `json:"type"`
+	ID      string      `json:"id,omitempty"`
+	Tickers []string    `json:"tickers,omitempty"`
+}
+
+// ServerMessage is a message sent to the client
+// the quote and recommendation messages have the id of the event, the other messages the id of the client message
+type ServerMessage struct {
+	Type    MessageType     `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Ticker  string          `json:"ticker,omitempty"`
+	Tickers []string        `json:"tickers,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Advice is the data of the advice messages
+type Advice struct {
+	Ticker string    `json:"ticker"`
+	Advice string    `json:"advice"`
+	At     time.Time `json:"at"`
+}
+
+// SessionOptions configuration of a websocket connection
+// MaxSubscriptions is the max tickers of the connection, 0 is unlimited
+// BufferSize is the number of messages buffered to send, when it is full the connection is closed
+// ValidateTickers normalizes the tickers and returns an error if any does not exist
+// Advice generates the advice of a ticker, optional
+type SessionOptions struct {
+	MaxSubscriptions int
+	BufferSize       int
+	PingInterval     time.Duration
+	WriteTimeout     time.Duration
+	ValidateTickers  func(ctx context.Context, tickers []string) ([]string, error)
+	Advice           func(ctx context.Context, ticker string) (string, error)
+}
+
+// maxClientMessageSize is the max size of the messages of the client
+const maxClientMessageSize = 4096
+
+// session is a websocket connection subscribed to the hub
+// only the writer goroutine writes the messages, the reader goroutine processes the client messages
+type session struct {
+	conn       *websocket.Conn
+	hub        *Hub
+	subscriber *Subscriber
+	options    SessionOptions
+
+	send    chan ServerMessage
+	tickers map[string]bool
+	advices chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	once   sync.Once
+}
+
+// Serve runs the protocol of the websocket connection until the client disconnects,
+// the context is cancelled or the connection falls behind, the connection is closed on return
+func Serve(ctx context.Context, conn *websocket.Conn, hub *Hub, options SessionOptions) error {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 64
+	}
+
+	if options.PingInterval <= 0 {
+		options.PingInterval = 30 * time.Second
+	}
+
+	if options.WriteTimeout <= 0 {
+		options.WriteTimeout = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	s := &session{
+		conn:       conn,
+		hub:        hub,
+		subscriber: hub.NewSubscriber(),
+		options:    options,
+		send:       make(chan ServerMessage, options.BufferSize),
+		tickers:    make(map[string]bool),
+		advices:    make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	defer hub.Remove(s.subscriber)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		s.writeLoop()
+	}()
+	go func() {
+		defer wg.Done()
+		s.forwardEvents()
+	}()
+
+	s.readLoop()
+	s.close(nil)
+	wg.Wait()
+
+	err := context.Cause(ctx)
+	if errors.Is(err, errClientClosed) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+// errClientClosed is the cause of the sessions closed by the client
+var errClientClosed = errors.New("closed by the client")
+
+// close stops the session with the reason, the first reason is the one reported
+func (s *session) close(reason error) {
+	s.once.Do(func() {
+		if reason == nil {
+			reason = errClientClosed
+		}
+		s.cancel(reason)
+
+		code := websocket.CloseNormalClosure
+		switch {
+		case errors.Is(reason, ErrSlowConsumer):
+			code = websocket.ClosePolicyViolation
+		case errors.Is(reason, ErrHubClosed), errors.Is(reason, context.Canceled):
+			code = websocket.CloseGoingAway
+		}
+
+		message := websocket.FormatCloseMessage(code, closeText(reason))
+		s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(s.options.WriteTimeout))
+		s.conn.Close()
+	})
+}
+
+// closeText is the reason sent in the close frame, limited to the size of a control frame
+func closeText(reason error) string {
+	if errors.Is(reason, errClientClosed) {
+		return ""
+	}
+
+	text := reason.Error()
+	if len(text) > 120 {
+		text = text[:120]
+	}
+
+	return text
+}
+
+// enqueue sends the message to the writer, if the buffer is full the client is too slow and the session is closed
+func (s *session) enqueue(message ServerMessage) {
+	select {
+	case <-s.ctx.Done():
+	case s.send <- message:
+	default:
+		s.close(ErrSlowConsumer)
+	}
+}
+
+// readLoop processes the messages of the client until the connection is closed
+func (s *session) readLoop() {
+	pongWait := 2 * s.options.PingInterval
+	s.conn.SetReadLimit(maxClientMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var message ClientMessage
+		if err := s.conn.ReadJSON(&message); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				s.enqueue(ServerMessage{Type: ErrorMessage, Error: "invalid message: must be a json object"})
+				continue
+			}
+
+			return
+		}
+
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+		s.handle(message)
+	}
+}
+
+// handle processes a message of the client
+func (s *session) handle(message ClientMessage) {
+	switch message.Type {
+	case PingMessage:
+		s.enqueue(ServerMessage{Type: PongMessage, ID: message.ID})
+	case SubscribeMessage:
+		s.subscribe(message)
+	case UnsubscribeMessage:
+		s.unsubscribe(message)
+	default:
+		s.enqueue(ServerMessage{
+			Type:  ErrorMessage,
+			ID:    message.ID,
+			Error: fmt.Sprintf("unknown message type %q: the supported types are subscribe, unsubscribe and ping", message.Type),
+		})
+	}
+}
+
+// subscribe adds the new tickers of the message to the connection and sends their advice
+func (s *session) subscribe(message ClientMessage) {
+	var added []string
+	for _, ticker := range message.Tickers {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if ticker != "" && !s.tickers[ticker] && !slices.Contains(added, ticker) {
+			added = append(added, ticker)
+		}
+	}
+
+	if len(added) == 0 {
+		s.enqueue(ServerMessage{Type: SubscribedMessage, ID: message.ID, Tickers: s.subscribed()})
+		return
+	}
+
+	if s.options.MaxSubscriptions > 0 && len(s.tickers)+len(added) > s.options.MaxSubscriptions {
+		s.enqueue(ServerMessage{
+			Type:  ErrorMessage,
+			ID:    message.ID,
+			Error: fmt.Sprintf("%s: at most %d tickers per connection", ErrSubscriptionLimit, s.options.MaxSubscriptions),
+		})
+		return
+	}
+
+	if s.options.ValidateTickers != nil {
+		validated, err := s.options.ValidateTickers(s.ctx, added)
+		if err != nil {
+			s.enqueue(ServerMessage{Type: ErrorMessage, ID: message.ID, Error: err.Error()})
+			return
+		}
+		added = validated
+	}
+
+	if err := s.hub.Subscribe(s.ctx, s.subscriber, added...); err != nil {
+		apilogger.Logger().Error().Err(err).Msg("[stream] failed to subscribe the websocket")
+		s.enqueue(ServerMessage{Type: ErrorMessage, ID: message.ID, Error: "failed to subscribe"})
+		return
+	}
+
+	for _, ticker := range added {
+		s.tickers[ticker] = true
+	}
+
+	s.enqueue(ServerMessage{Type: SubscribedMessage, ID: message.ID, Tickers: s.subscribed()})
+
+	if s.options.Advice != nil {
+		go s.sendAdvices(added)
+	}
+}
+
+// unsubscribe removes the tickers of the message from the connection
+func (s *session) unsubscribe(message ClientMessage) {
+	var removed []string
+	for _, ticker := range message.Tickers {
+		ticker = strings.ToUpper(strings.TrimSpace(ticker))
+		if s.tickers[ticker] {
+			delete(s.tickers, ticker)
+			removed = append(removed, ticker)
+		}
+	}
+
+	if len(removed) > 0 {
+		s.hub.Unsubscribe(s.ctx, s.subscriber, removed...)
+	}
+
+	s.enqueue(ServerMessage{Type: SubscribedMessage, ID: message.ID, Tickers: s.subscribed()})
+}
+
+// sendAdvices generates the advice of the tickers, one ticker at a time per connection
+// the failures are logged, the advice is an extra of the subscription
+func (s *session) sendAdvices(tickers []string) {
+	select {
+	case s.advices <- struct{}{}:
+		defer func() { <-s.advices }()
+	case <-s.ctx.Done():
+		return
+	}
+
+	for _, ticker := range tickers {
+		advice, err := s.options.Advice(s.ctx, ticker)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				apilogger.Logger().Warn().Err(err).Msg("[stream] failed to generate the advice of " + ticker)
+			}
+			continue
+		}
+
+		data, err := json.Marshal(Advice{Ticker: ticker, Advice: advice, At: time.Now()})
+		if err != nil {
+			continue
+		}
+
+		s.enqueue(ServerMessage{Type: AdviceMessage, Ticker: ticker, Data: data})
+	}
+}
+
+// forwardEvents sends the events of the hub to the client until the session is closed
+func (s *session) forwardEvents() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.subscriber.Done():
+			s.close(s.subscriber.Err())
+			return
+		case event := <-s.subscriber.Events():
+			s.enqueue(ServerMessage{Type: MessageType(event.Type), ID: event.ID, Ticker: event.Ticker, Data: event.Data})
+		}
+	}
+}
+
+// writeLoop writes the queued messages and the keepalive pings until the session is closed
+func (s *session) writeLoop() {
+	ping := time.NewTicker(s.options.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			// the context of the request can be cancelled before the client closes, the read must be released
+			s.close(context.Cause(s.ctx))
+			return
+		case message := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(s.options.WriteTimeout))
+			if err := s.conn.WriteJSON(message); err != nil {
+				s.close(err)
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.options.WriteTimeout)); err != nil {
+				s.close(err)
+				return
+			}
+		}
+	}
+}
+
+// subscribed returns the tickers of the connection sorted
+func (s *session) subscribed() []string {
+	tickers := make([]string, 0, len(s.tickers))
+	for ticker := range s.tickers {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	return tickers
+}
@@ -0,0 +1,204 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveWebsocket starts a server with the session and returns a client connected to it
+func serveWebsocket(t *testing.T, hub *Hub, options SessionOptions) *websocket.Conn {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		Serve(r.Context(), conn, hub, options)
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// readMessage reads the next message of the server
+func readMessage(t *testing.T, conn *websocket.Conn) ServerMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var message ServerMessage
+	if err := conn.ReadJSON(&message); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+
+	return message
+}
+
+func validTickers(known ...string) func(ctx context.Context, tickers []string) ([]string, error) {
+	return func(ctx context.Context, tickers []string) ([]string, error) {
+		for _, ticker := range tickers {
+			found := false
+			for _, k := range known {
+				found = found || k == ticker
+			}
+
+			if !found {
+				return nil, errors.New("unknown tickers: " + ticker)
+			}
+		}
+
+		return tickers, nil
+	}
+}
+
+func TestWebsocketProtocol(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+	defer hub.Close()
+
+	conn := serveWebsocket(t, hub, SessionOptions{
+		MaxSubscriptions: 2,
+		ValidateTickers:  validTickers("AAPL", "MSFT", "TSLA"),
+		Advice: func(ctx context.Context, ticker string) (string, error) {
+			return "BUY " + ticker, nil
+		},
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: PingMessage, ID: "1"})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, PongMessage, message.Type)
+		assert.Equal(t, "1", message.ID)
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: SubscribeMessage, ID: "2", Tickers: []string{"aapl", "AAPL"}})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, SubscribedMessage, message.Type)
+		assert.Equal(t, "2", message.ID)
+		assert.Equal(t, []string{"AAPL"}, message.Tickers)
+
+		message = readMessage(t, conn)
+		assert.Equal(t, AdviceMessage, message.Type)
+		assert.Equal(t, "AAPL", message.Ticker)
+
+		var advice Advice
+		assert.NoError(t, json.Unmarshal(message.Data, &advice))
+		assert.Equal(t, "BUY AAPL", advice.Advice)
+	})
+
+	t.Run("unknown ticker", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: SubscribeMessage, ID: "3", Tickers: []string{"NOPE"}})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, ErrorMessage, message.Type)
+		assert.Equal(t, "3", message.ID)
+		assert.Contains(t, message.Error, "NOPE")
+	})
+
+	t.Run("subscription limit", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: SubscribeMessage, ID: "4", Tickers: []string{"MSFT", "TSLA"}})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, ErrorMessage, message.Type)
+		assert.Contains(t, message.Error, ErrSubscriptionLimit.Error())
+	})
+
+	t.Run("events of the subscribed tickers", func(t *testing.T) {
+		_, err := Publish(context.Background(), pubsub, QuoteEvent, "MSFT", Quote{Ticker: "MSFT", Price: 1})
+		assert.NoError(t, err)
+		published, err := Publish(context.Background(), pubsub, QuoteEvent, "AAPL", Quote{Ticker: "AAPL", Price: 2})
+		assert.NoError(t, err)
+
+		message := readMessage(t, conn)
+		assert.Equal(t, QuoteMessage, message.Type)
+		assert.Equal(t, published.ID, message.ID)
+		assert.Equal(t, "AAPL", message.Ticker)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: UnsubscribeMessage, ID: "5", Tickers: []string{"aapl"}})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, SubscribedMessage, message.Type)
+		assert.Empty(t, message.Tickers)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		conn.WriteJSON(ClientMessage{Type: "advice", ID: "6"})
+
+		message := readMessage(t, conn)
+		assert.Equal(t, ErrorMessage, message.Type)
+		assert.Equal(t, "6", message.ID)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+
+		message := readMessage(t, conn)
+		assert.Equal(t, ErrorMessage, message.Type)
+	})
+}
+
+func TestWebsocketClosesSlowConsumer(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour, BufferSize: 1})
+	defer hub.Close()
+
+	conn := serveWebsocket(t, hub, SessionOptions{BufferSize: 1})
+	conn.WriteJSON(ClientMessage{Type: SubscribeMessage, Tickers: []string{"AAPL"}})
+	readMessage(t, conn)
+
+	// the client does not read, the events fill the buffers until the connection is closed
+	for i := 0; i < 5000; i++ {
+		Publish(context.Background(), pubsub, QuoteEvent, "AAPL", Quote{Ticker: "AAPL", Price: float64(i)})
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var message ServerMessage
+		err := conn.ReadJSON(&message)
+		if err == nil {
+			continue
+		}
+
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err.Error())
+		return
+	}
+}
+
+func TestWebsocketClosesWithHub(t *testing.T) {
+	pubsub := newMemoryPubSub()
+	quotes := &fakeQuotes{prices: map[string]float64{}, calls: make(map[string]int)}
+	hub := NewHub(pubsub, nil, quotes, HubOptions{PollInterval: time.Hour})
+
+	conn := serveWebsocket(t, hub, SessionOptions{})
+	conn.WriteJSON(ClientMessage{Type: SubscribeMessage, Tickers: []string{"AAPL"}})
+	readMessage(t, conn)
+
+	hub.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
@@ -317,7 +317,9 @@ resource "aws_ecs_task_definition" "api_task" {
         { name = "FINANCIAL_TOKEN",  value = var.financial_token },
         { name = "FINHUB_BASE_URL",  value = var.finhub_base_url },
         { name = "FINHUB_TOKEN",  value = var.finhub_token },
-        { name = "GEMINI_API_KEY",  value = var.gemini_api_key }
+        { name = "GEMINI_API_KEY",  value = var.gemini_api_key },
+        { name = "AUTH_ENABLED",  value = var.auth_enabled },
+        { name = "JWT_SECRET",  value = var.jwt_secret }
       ]
       logConfiguration = {
         logDriver = "awslogs"
@@ -158,5 +158,20 @@ variable "gemini_api_key" {
   }
 }
 
+# Auth variables
+# the web client does not send credentials yet, enable the auth only for clients with api keys or JWTs
+variable "auth_enabled" {
+  type    = string
+  default = "false"
 
+  validation {
+    condition     = contains(["true", "false"], var.auth_enabled)
+    error_message = "must be true or false"
+  }
+}
 
+variable "jwt_secret" {
+  type      = string
+  default   = ""
+  sensitive = true
+}